@@ -0,0 +1,578 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+// liveDomainData retrieves the current state of the given domain from
+// ZMS in the same DomainData format that is used by domain files.
+func (cli Zms) liveDomainData(dn string) (*zms.DomainData, error) {
+	domain, err := cli.Zms.GetDomain(zms.DomainName(dn))
+	if err != nil {
+		return nil, err
+	}
+	members := true
+	roles, err := cli.Zms.GetRoles(zms.DomainName(dn), &members, "", "")
+	if err != nil {
+		return nil, err
+	}
+	groups, err := cli.Zms.GetGroups(zms.DomainName(dn), &members, "", "")
+	if err != nil {
+		return nil, err
+	}
+	assertions := true
+	includeNonActive := false
+	policies, err := cli.Zms.GetPolicies(zms.DomainName(dn), &assertions, &includeNonActive)
+	if err != nil {
+		return nil, err
+	}
+	publicKeys := true
+	hosts := true
+	services, err := cli.Zms.GetServiceIdentities(zms.DomainName(dn), &publicKeys, &hosts)
+	if err != nil {
+		return nil, err
+	}
+	return &zms.DomainData{
+		Name:                  domain.Name,
+		Description:           domain.Description,
+		Enabled:               domain.Enabled,
+		Org:                   domain.Org,
+		AuditEnabled:          domain.AuditEnabled,
+		Account:               domain.Account,
+		AzureSubscription:     domain.AzureSubscription,
+		YpmId:                 domain.YpmId,
+		ApplicationId:         domain.ApplicationId,
+		BusinessService:       domain.BusinessService,
+		CertDnsDomain:         domain.CertDnsDomain,
+		UserAuthorityFilter:   domain.UserAuthorityFilter,
+		MemberExpiryDays:      domain.MemberExpiryDays,
+		ServiceExpiryDays:     domain.ServiceExpiryDays,
+		GroupExpiryDays:       domain.GroupExpiryDays,
+		TokenExpiryMins:       domain.TokenExpiryMins,
+		RoleCertExpiryMins:    domain.RoleCertExpiryMins,
+		ServiceCertExpiryMins: domain.ServiceCertExpiryMins,
+		SignAlgorithm:         domain.SignAlgorithm,
+		Tags:                  domain.Tags,
+		Roles:                 roles.List,
+		Groups:                groups.List,
+		Services:              services.List,
+		Policies: &zms.SignedPolicies{
+			Contents: &zms.DomainPolicies{
+				Domain:   zms.DomainName(dn),
+				Policies: policies.List,
+			},
+		},
+	}, nil
+}
+
+// planDomain loads the given domain file and computes the list of
+// changes required to bring the live domain in sync with the file.
+func (cli Zms) planDomain(dn string, filename string) (*zms.DomainData, *zms.DomainData, *DomainDiff, error) {
+	desired, err := loadDomainFile(filename)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if dn != "" && dn != string(desired.Name) {
+		return nil, nil, nil, fmt.Errorf("Domain name mismatch. Expected " + dn + ", encountered " + string(desired.Name))
+	}
+	current, err := cli.liveDomainData(string(desired.Name))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return current, desired, diffDomainData(current, desired), nil
+}
+
+func diffActionSymbol(action string) string {
+	switch action {
+	case diffActionAdd:
+		return "+"
+	case diffActionDelete:
+		return "-"
+	}
+	return "~"
+}
+
+func (cli Zms) dumpDomainPlan(buf *bytes.Buffer, diff *DomainDiff) {
+	if len(diff.Objects) == 0 {
+		buf.WriteString("[domain " + diff.Domain + " has no changes]\n")
+		return
+	}
+	cli.dumpDomainDiff(buf, diff)
+	buf.WriteString("plan: " + strconv.Itoa(diff.count(diffActionAdd)) + " to add, " +
+		strconv.Itoa(diff.count(diffActionUpdate)) + " to change, " +
+		strconv.Itoa(diff.count(diffActionDelete)) + " to delete\n")
+}
+
+func (cli Zms) dumpDomainDiff(buf *bytes.Buffer, diff *DomainDiff) {
+	for _, object := range diff.Objects {
+		buf.WriteString(diffActionSymbol(object.Action) + " " + object.ObjectType + " " + object.Name + "\n")
+		for _, change := range object.Changes {
+			buf.WriteString(indentLevel1 + diffActionSymbol(change.Action) + " " + change.Attribute)
+			if change.Value != "" {
+				buf.WriteString(" " + change.Value)
+			}
+			switch {
+			case change.From != "" && change.To != "":
+				buf.WriteString(": " + change.From + " => " + change.To)
+			case change.From != "" && change.Action == diffActionUpdate:
+				buf.WriteString(": " + change.From + " => \"\"")
+			case change.To != "" && change.Action == diffActionUpdate:
+				buf.WriteString(": \"\" => " + change.To)
+			case change.From != "":
+				buf.WriteString(" (" + change.From + ")")
+			case change.To != "":
+				buf.WriteString(" (" + change.To + ")")
+			}
+			buf.WriteString("\n")
+		}
+	}
+}
+
+func (cli Zms) PlanDomain(dn string, filename string) (*string, error) {
+	_, _, diff, err := cli.planDomain(dn, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		cli.dumpDomainPlan(&buf, diff)
+		s := buf.String()
+		return &s, nil
+	}
+
+	return cli.dumpByFormat(diff, oldYamlConverter)
+}
+
+// confirmChanges displays the summary of the changes and asks the user
+// to confirm them before they are applied unless auto confirmation is
+// enabled. The summary and the prompt are written to stderr so they're
+// not mixed with the command output, e.g. when json output is requested.
+func (cli Zms) confirmChanges(summary string) bool {
+	_, _ = fmt.Fprint(os.Stderr, summary)
+	if cli.AutoConfirm {
+		return true
+	}
+	_, _ = fmt.Fprint(os.Stderr, "Do you want to apply these changes? Only 'yes' will be accepted: ")
+	answer, _ := cli.stdinReader().ReadString('\n')
+	return strings.TrimSpace(answer) == "yes"
+}
+
+// stdinReader returns the reader shared by all the commands so that
+// input buffered while reading one line is not lost for the next one
+func (cli Zms) stdinReader() *bufio.Reader {
+	if cli.Stdin == nil {
+		return bufio.NewReader(os.Stdin)
+	}
+	return cli.Stdin
+}
+
+func (cli Zms) ApplyDomain(dn string, filename string) (*string, error) {
+	current, desired, diff, err := cli.planDomain(dn, filename)
+	if err != nil {
+		return nil, err
+	}
+	dn = diff.Domain
+	var buf bytes.Buffer
+	cli.dumpDomainPlan(&buf, diff)
+	if len(diff.Objects) == 0 {
+		message := SuccessMessage{
+			Status:  200,
+			Message: strings.TrimSpace(buf.String()),
+		}
+		return cli.dumpByFormat(message, cli.buildYAMLOutput)
+	}
+	if !cli.confirmChanges(buf.String()) {
+		return nil, fmt.Errorf("apply-domain cancelled - no changes were made to domain " + dn)
+	}
+	err = cli.applyDomainDiff(dn, diff, current, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	s := "[applied " + strconv.Itoa(len(diff.Objects)) + " change(s) to domain '" + dn + "' successfully]"
+	message := SuccessMessage{
+		Status:  200,
+		Message: s,
+	}
+
+	return cli.dumpByFormat(message, cli.buildYAMLOutput)
+}
+
+// applyDomainDiff applies all the changes from the given domain diff
+func (cli Zms) applyDomainDiff(dn string, diff *DomainDiff, current, desired *zms.DomainData) error {
+
+	// we're going to process all additions and updates first in
+	// the order of their dependencies (services and groups can be
+	// referenced by roles which are in turn referenced by policies)
+	// and then process deletions in the reverse order
+
+	objectTypes := []string{diffObjectDomain, diffObjectService, diffObjectGroup, diffObjectRole, diffObjectPolicy}
+	for _, objectType := range objectTypes {
+		for _, object := range diff.Objects {
+			if object.ObjectType != objectType || object.Action == diffActionDelete {
+				continue
+			}
+			err := cli.applyDomainObject(dn, object, current, desired)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for i := len(objectTypes) - 1; i >= 0; i-- {
+		for _, object := range diff.Objects {
+			if object.ObjectType != objectTypes[i] || object.Action != diffActionDelete {
+				continue
+			}
+			err := cli.applyDomainObject(dn, object, current, desired)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (cli Zms) applyDomainObject(dn string, object *DomainObjectDiff, current, desired *zms.DomainData) error {
+	_, _ = fmt.Fprintf(os.Stderr, "Processing %s %s %s...\n", object.Action, object.ObjectType, object.Name)
+	switch object.ObjectType {
+	case diffObjectDomain:
+		return cli.applyDomainMeta(dn, object, desired)
+	case diffObjectService:
+		return cli.applyService(dn, object, findService(dn, desired, object.Name))
+	case diffObjectGroup:
+		return cli.applyGroup(dn, object, findGroup(desired, object.Name))
+	case diffObjectRole:
+		return cli.applyRole(dn, object, findRole(desired, object.Name))
+	case diffObjectPolicy:
+		return cli.applyPolicy(dn, object, findPolicy(current, object.Name), findPolicy(desired, object.Name))
+	}
+	return fmt.Errorf("unknown object type: " + object.ObjectType)
+}
+
+func findService(dn string, domainData *zms.DomainData, name string) *zms.ServiceIdentity {
+	for _, service := range domainData.Services {
+		if shortname(dn, string(service.Name)) == name {
+			return service
+		}
+	}
+	return nil
+}
+
+func findGroup(domainData *zms.DomainData, name string) *zms.Group {
+	for _, group := range domainData.Groups {
+		if localName(string(group.Name), ":group.") == name {
+			return group
+		}
+	}
+	return nil
+}
+
+func findRole(domainData *zms.DomainData, name string) *zms.Role {
+	for _, role := range domainData.Roles {
+		if localName(string(role.Name), ":role.") == name {
+			return role
+		}
+	}
+	return nil
+}
+
+func findPolicy(domainData *zms.DomainData, name string) *zms.Policy {
+	for _, policy := range domainPolicies(domainData) {
+		if localName(string(policy.Name), ":policy.") == name {
+			return policy
+		}
+	}
+	return nil
+}
+
+// metaBool and metaInt32 return the given value or, if the value is not
+// set, false/0 respectively since the server ignores attributes that are
+// not included in the meta object instead of resetting them.
+func metaBool(value *bool) *bool {
+	if value == nil {
+		value = new(bool)
+	}
+	return value
+}
+
+func metaInt32(value *int32) *int32 {
+	if value == nil {
+		value = new(int32)
+	}
+	return value
+}
+
+// applyDomainMeta updates the regular domain meta attributes and then
+// each changed system attribute with its own system meta request
+func (cli Zms) applyDomainMeta(dn string, object *DomainObjectDiff, desired *zms.DomainData) error {
+	meta := zms.DomainMeta{
+		Description:           desired.Description,
+		Org:                   desired.Org,
+		AuditEnabled:          metaBool(desired.AuditEnabled),
+		Account:               desired.Account,
+		YpmId:                 metaInt32(desired.YpmId),
+		ApplicationId:         desired.ApplicationId,
+		CertDnsDomain:         desired.CertDnsDomain,
+		MemberExpiryDays:      metaInt32(desired.MemberExpiryDays),
+		TokenExpiryMins:       metaInt32(desired.TokenExpiryMins),
+		ServiceCertExpiryMins: metaInt32(desired.ServiceCertExpiryMins),
+		RoleCertExpiryMins:    metaInt32(desired.RoleCertExpiryMins),
+		SignAlgorithm:         desired.SignAlgorithm,
+		ServiceExpiryDays:     metaInt32(desired.ServiceExpiryDays),
+		GroupExpiryDays:       metaInt32(desired.GroupExpiryDays),
+		UserAuthorityFilter:   desired.UserAuthorityFilter,
+		AzureSubscription:     desired.AzureSubscription,
+		Tags:                  desired.Tags,
+		BusinessService:       desired.BusinessService,
+	}
+	err := cli.Zms.PutDomainMeta(zms.DomainName(dn), cli.AuditRef, &meta)
+	if err != nil {
+		return err
+	}
+	for _, change := range object.Changes {
+		if attribute, ok := domainSystemMetaAttributes[change.Attribute]; ok {
+			err = cli.Zms.PutDomainSystemMeta(zms.DomainName(dn), zms.SimpleName(attribute), cli.AuditRef, &meta)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (cli Zms) applyService(dn string, object *DomainObjectDiff, service *zms.ServiceIdentity) error {
+	sn := object.Name
+	if object.Action == diffActionDelete {
+		return cli.Zms.DeleteServiceIdentity(zms.DomainName(dn), zms.SimpleName(sn), cli.AuditRef)
+	}
+	// the provider endpoint can only be set with the system meta api
+	detail := *service
+	detail.Name = zms.ServiceName(dn + "." + sn)
+	detail.ProviderEndpoint = ""
+	err := cli.Zms.PutServiceIdentity(zms.DomainName(dn), zms.SimpleName(sn), cli.AuditRef, &detail)
+	if err != nil {
+		return err
+	}
+	if object.Action == diffActionAdd && service.ProviderEndpoint == "" {
+		return nil
+	}
+	if object.Action == diffActionUpdate && !object.hasChange("provider-endpoint") {
+		return nil
+	}
+	_, err = cli.SetServiceEndpoint(dn, sn, service.ProviderEndpoint)
+	return err
+}
+
+func (cli Zms) applyGroup(dn string, object *DomainObjectDiff, group *zms.Group) error {
+	gn := object.Name
+	switch object.Action {
+	case diffActionDelete:
+		return cli.Zms.DeleteGroup(zms.DomainName(dn), zms.EntityName(gn), cli.AuditRef)
+	case diffActionAdd:
+		// review enabled groups cannot be created with members and the
+		// audit flag is a system attribute so both are set with the
+		// rest of the group meta once the group is created
+		newGroup := *group
+		newGroup.ReviewEnabled = nil
+		newGroup.AuditEnabled = nil
+		err := cli.Zms.PutGroup(zms.DomainName(dn), zms.EntityName(gn), cli.AuditRef, &newGroup)
+		if err != nil {
+			return err
+		}
+		return cli.applyGroupMeta(dn, diffGroup(&zms.Group{Name: group.Name}, group), group)
+	}
+	members := make(map[string]*zms.GroupMember)
+	for _, member := range group.GroupMembers {
+		members[string(member.MemberName)] = member
+	}
+	for _, change := range object.Changes {
+		if change.Attribute != "member" {
+			continue
+		}
+		var err error
+		if change.Action == diffActionDelete {
+			err = cli.Zms.DeleteGroupMembership(zms.DomainName(dn), zms.EntityName(gn), zms.GroupMemberName(change.Value), cli.AuditRef)
+		} else {
+			membership := zms.GroupMembership{
+				MemberName: zms.GroupMemberName(change.Value),
+				GroupName:  zms.ResourceName(gn),
+				Expiration: members[change.Value].Expiration,
+			}
+			err = cli.Zms.PutGroupMembership(zms.DomainName(dn), zms.EntityName(gn), zms.GroupMemberName(change.Value), cli.AuditRef, &membership)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return cli.applyGroupMeta(dn, object, group)
+}
+
+// applyGroupMeta updates the group meta and audit-enabled attributes if they have changed
+func (cli Zms) applyGroupMeta(dn string, object *DomainObjectDiff, group *zms.Group) error {
+	gn := object.Name
+	if object.hasChanges(groupMetaAttributes) {
+		meta := getGroupMetaObject(group)
+		meta.SelfServe = metaBool(group.SelfServe)
+		meta.ReviewEnabled = metaBool(group.ReviewEnabled)
+		meta.MemberExpiryDays = metaInt32(group.MemberExpiryDays)
+		meta.ServiceExpiryDays = metaInt32(group.ServiceExpiryDays)
+		err := cli.Zms.PutGroupMeta(zms.DomainName(dn), zms.EntityName(gn), cli.AuditRef, &meta)
+		if err != nil {
+			return err
+		}
+	}
+	if !object.hasChange("audit-enabled") {
+		return nil
+	}
+	meta := zms.GroupSystemMeta{
+		AuditEnabled: metaBool(group.AuditEnabled),
+	}
+	return cli.Zms.PutGroupSystemMeta(zms.DomainName(dn), zms.EntityName(gn), "auditenabled", cli.AuditRef, &meta)
+}
+
+func (cli Zms) applyRole(dn string, object *DomainObjectDiff, role *zms.Role) error {
+	rn := object.Name
+	switch object.Action {
+	case diffActionDelete:
+		if rn == "admin" {
+			_, _ = fmt.Fprintf(os.Stderr, "Skipping reserved 'admin' role...\n")
+			return nil
+		}
+		return cli.Zms.DeleteRole(zms.DomainName(dn), zms.EntityName(rn), cli.AuditRef)
+	case diffActionAdd:
+		// review enabled roles cannot be created with members and the
+		// audit flag is a system attribute so both are set with the
+		// rest of the role meta once the role is created
+		newRole := *role
+		newRole.ReviewEnabled = nil
+		newRole.AuditEnabled = nil
+		err := cli.Zms.PutRole(zms.DomainName(dn), zms.EntityName(rn), cli.AuditRef, &newRole)
+		if err != nil {
+			return err
+		}
+		return cli.applyRoleMeta(dn, diffRole(&zms.Role{Name: role.Name}, role), role)
+	}
+	if object.hasChange("trust") {
+		// switching between regular and delegated roles requires
+		// the role to be replaced with its full definition
+		err := cli.Zms.PutRole(zms.DomainName(dn), zms.EntityName(rn), cli.AuditRef, role)
+		if err != nil {
+			return err
+		}
+		return cli.applyRoleMeta(dn, object, role)
+	}
+	members := make(map[string]*zms.RoleMember)
+	for _, member := range role.RoleMembers {
+		members[string(member.MemberName)] = member
+	}
+	for _, change := range object.Changes {
+		if change.Attribute != "member" {
+			continue
+		}
+		var err error
+		if change.Action == diffActionDelete {
+			err = cli.Zms.DeleteMembership(zms.DomainName(dn), zms.EntityName(rn), zms.MemberName(change.Value), cli.AuditRef)
+		} else {
+			membership := zms.Membership{
+				MemberName:     zms.MemberName(change.Value),
+				RoleName:       zms.ResourceName(rn),
+				Expiration:     members[change.Value].Expiration,
+				ReviewReminder: members[change.Value].ReviewReminder,
+			}
+			err = cli.Zms.PutMembership(zms.DomainName(dn), zms.EntityName(rn), zms.MemberName(change.Value), cli.AuditRef, &membership)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return cli.applyRoleMeta(dn, object, role)
+}
+
+// applyRoleMeta updates the role meta and audit-enabled attributes if they have changed
+func (cli Zms) applyRoleMeta(dn string, object *DomainObjectDiff, role *zms.Role) error {
+	rn := object.Name
+	if object.hasChanges(roleMetaAttributes) {
+		meta := getRoleMetaObject(role)
+		meta.SelfServe = metaBool(role.SelfServe)
+		meta.ReviewEnabled = metaBool(role.ReviewEnabled)
+		meta.MemberExpiryDays = metaInt32(role.MemberExpiryDays)
+		meta.ServiceExpiryDays = metaInt32(role.ServiceExpiryDays)
+		meta.GroupExpiryDays = metaInt32(role.GroupExpiryDays)
+		meta.MemberReviewDays = metaInt32(role.MemberReviewDays)
+		meta.ServiceReviewDays = metaInt32(role.ServiceReviewDays)
+		meta.GroupReviewDays = metaInt32(role.GroupReviewDays)
+		meta.TokenExpiryMins = metaInt32(role.TokenExpiryMins)
+		meta.CertExpiryMins = metaInt32(role.CertExpiryMins)
+		err := cli.Zms.PutRoleMeta(zms.DomainName(dn), zms.EntityName(rn), cli.AuditRef, &meta)
+		if err != nil {
+			return err
+		}
+	}
+	if !object.hasChange("audit-enabled") {
+		return nil
+	}
+	meta := zms.RoleSystemMeta{
+		AuditEnabled: metaBool(role.AuditEnabled),
+	}
+	return cli.Zms.PutRoleSystemMeta(zms.DomainName(dn), zms.EntityName(rn), "auditenabled", cli.AuditRef, &meta)
+}
+
+func (cli Zms) applyPolicy(dn string, object *DomainObjectDiff, current, policy *zms.Policy) error {
+	pn := object.Name
+	switch object.Action {
+	case diffActionDelete:
+		if pn == "admin" {
+			_, _ = fmt.Fprintf(os.Stderr, "Skipping reserved 'admin' policy...\n")
+			return nil
+		}
+		return cli.Zms.DeletePolicy(zms.DomainName(dn), zms.EntityName(pn), cli.AuditRef)
+	case diffActionAdd:
+		err := cli.Zms.PutPolicy(zms.DomainName(dn), zms.EntityName(pn), cli.AuditRef, policy)
+		if err != nil {
+			return err
+		}
+		return cli.importAssertionConditions(dn, pn, policy.Assertions)
+	}
+	for _, change := range object.Changes {
+		if change.Action == diffActionAdd {
+			for _, assertion := range policy.Assertions {
+				if assertionDiffString(dn, assertion) == change.Value {
+					newAssertion, err := cli.Zms.PutAssertion(zms.DomainName(dn), zms.EntityName(pn), cli.AuditRef, assertion)
+					if err != nil {
+						return err
+					}
+					if assertion.Conditions != nil && newAssertion.Id != nil && newAssertion.Conditions == nil {
+						conditions := copyAssertionConditions(assertion.Conditions)
+						_, err = cli.Zms.PutAssertionConditions(zms.DomainName(dn), zms.EntityName(pn), *newAssertion.Id, cli.AuditRef, conditions)
+						if err != nil {
+							return err
+						}
+					}
+					break
+				}
+			}
+		} else {
+			for _, assertion := range current.Assertions {
+				if assertionDiffString(dn, assertion) == change.Value && assertion.Id != nil {
+					err := cli.Zms.DeleteAssertion(zms.DomainName(dn), zms.EntityName(pn), *assertion.Id, cli.AuditRef)
+					if err != nil {
+						return err
+					}
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,347 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+const (
+	memberActionAdd    = "add"
+	memberActionDelete = "delete"
+
+	memberImportStatusOK     = "ok"
+	memberImportStatusFailed = "failed"
+
+	defaultImportConcurrency = 5
+)
+
+var memberCSVHeader = []string{"domain", "role|group", "member", "expiration", "review", "action"}
+
+// MemberImportRow is a single membership change from the members csv file.
+// The object is a role name or a group name with the group. prefix.
+type MemberImportRow struct {
+	Line       int            `json:"line"`
+	Domain     string         `json:"domain"`
+	ObjectType string         `json:"type"`
+	Name       string         `json:"name"`
+	Member     string         `json:"member"`
+	Expiration *rdl.Timestamp `json:"expiration,omitempty"`
+	Review     *rdl.Timestamp `json:"review,omitempty"`
+	Action     string         `json:"action"`
+	Status     string         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	record     []string
+}
+
+// MemberImportReport is the per-row result of the members import
+type MemberImportReport struct {
+	File      string             `json:"file"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	RetryFile string             `json:"retryFile,omitempty"`
+	Rows      []*MemberImportRow `json:"rows"`
+}
+
+// memberObject returns the object type and name for the role|group
+// column value. Groups are identified with the group. prefix while
+// the role. prefix is optional for roles.
+func memberObject(value string) (string, string) {
+	if strings.HasPrefix(value, "group.") {
+		return "group", strings.TrimPrefix(value, "group.")
+	}
+	return "role", strings.TrimPrefix(value, "role.")
+}
+
+func parseOptionalTimestamp(value string) (*rdl.Timestamp, error) {
+	if value == "" {
+		return nil, nil
+	}
+	timestamp, err := getTimestamp(value)
+	if err != nil {
+		return nil, err
+	}
+	return &timestamp, nil
+}
+
+// parseMemberRecord parses the csv record into the import row. The
+// expiration, review and action columns are optional.
+func (cli Zms) parseMemberRecord(line int, record []string) *MemberImportRow {
+	row := &MemberImportRow{Line: line, Action: memberActionAdd, record: record}
+	fail := func(message string) *MemberImportRow {
+		row.Status = memberImportStatusFailed
+		row.Error = message
+		return row
+	}
+	fields := make([]string, len(memberCSVHeader))
+	for i := range fields {
+		if i < len(record) {
+			fields[i] = strings.TrimSpace(record[i])
+		}
+	}
+	if len(record) < 3 || len(record) > len(memberCSVHeader) {
+		return fail("expected domain, role|group and member columns with optional expiration, review and action")
+	}
+	row.Domain = fields[0]
+	row.ObjectType, row.Name = memberObject(fields[1])
+	if fields[2] != "" {
+		row.Member = cli.validatedUser(fields[2])
+	}
+	if row.Domain == "" || row.Name == "" || row.Member == "" {
+		return fail("domain, role|group and member values must be specified")
+	}
+	var err error
+	row.Expiration, err = parseOptionalTimestamp(fields[3])
+	if err != nil {
+		return fail("invalid expiration: " + err.Error())
+	}
+	row.Review, err = parseOptionalTimestamp(fields[4])
+	if err != nil {
+		return fail("invalid review: " + err.Error())
+	}
+	if row.Review != nil && row.ObjectType == "group" {
+		return fail("review dates are not supported for group members")
+	}
+	if fields[5] != "" {
+		row.Action = strings.ToLower(fields[5])
+	}
+	if row.Action != memberActionAdd && row.Action != memberActionDelete {
+		return fail("invalid action " + fields[5] + " - must be add or delete")
+	}
+	return row
+}
+
+// parseMembersCSV reads all the rows of the members csv file.
+// The header line is optional.
+func (cli Zms) parseMembersCSV(reader io.Reader) ([]*MemberImportRow, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	rows := make([]*MemberImportRow, 0)
+	for line := 1; ; line++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.TrimSpace(record[0]) == memberCSVHeader[0] {
+			continue
+		}
+		rows = append(rows, cli.parseMemberRecord(line, record))
+	}
+}
+
+func (cli Zms) importMemberRow(row *MemberImportRow) error {
+	dn := zms.DomainName(row.Domain)
+	name := zms.EntityName(row.Name)
+	if row.ObjectType == "group" {
+		if row.Action == memberActionDelete {
+			return cli.Zms.DeleteGroupMembership(dn, name, zms.GroupMemberName(row.Member), cli.AuditRef)
+		}
+		membership := zms.GroupMembership{
+			MemberName: zms.GroupMemberName(row.Member),
+			GroupName:  zms.ResourceName(row.Name),
+			Expiration: row.Expiration,
+		}
+		return cli.Zms.PutGroupMembership(dn, name, zms.GroupMemberName(row.Member), cli.AuditRef, &membership)
+	}
+	if row.Action == memberActionDelete {
+		return cli.Zms.DeleteMembership(dn, name, zms.MemberName(row.Member), cli.AuditRef)
+	}
+	membership := zms.Membership{
+		MemberName:     zms.MemberName(row.Member),
+		RoleName:       zms.ResourceName(row.Name),
+		Expiration:     row.Expiration,
+		ReviewReminder: row.Review,
+	}
+	return cli.Zms.PutMembership(dn, name, zms.MemberName(row.Member), cli.AuditRef, &membership)
+}
+
+// importMemberRows processes all the valid rows with at most the given
+// number of concurrent requests. The rows keep their original order.
+func importMemberRows(rows []*MemberImportRow, concurrency int, importRow func(*MemberImportRow) error) {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, concurrency)
+	for _, row := range rows {
+		if row.Status == memberImportStatusFailed {
+			continue
+		}
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(row *MemberImportRow) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			if err := importRow(row); err != nil {
+				row.Status = memberImportStatusFailed
+				row.Error = err.Error()
+			} else {
+				row.Status = memberImportStatusOK
+			}
+		}(row)
+	}
+	wg.Wait()
+}
+
+// writeRetryFile writes the original records of all failed rows so
+// they can be imported again once the problems are addressed
+func writeRetryFile(filename string, rows []*MemberImportRow) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	_ = writer.Write(memberCSVHeader)
+	for _, row := range rows {
+		if row.Status == memberImportStatusFailed {
+			_ = writer.Write(row.record)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (cli Zms) dumpMemberImportReport(buf *bytes.Buffer, report *MemberImportReport) {
+	for _, row := range report.Rows {
+		object := row.Domain + ":" + row.ObjectType + "." + row.Name
+		buf.WriteString("line " + strconv.Itoa(row.Line) + ": " + row.Action + " " + row.Member + " " + object + ": " + row.Status)
+		if row.Error != "" {
+			buf.WriteString(" - " + row.Error)
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("[" + strconv.Itoa(report.Succeeded) + " succeeded, " + strconv.Itoa(report.Failed) + " failed]\n")
+	dumpStringValue(buf, "", "retry-file", report.RetryFile)
+}
+
+// ImportMembers adds and deletes the role and group members listed in the
+// csv file using the given number of concurrent requests. All failed rows
+// are written to the retry file which has the same format as the input.
+func (cli Zms) ImportMembers(filename string, concurrency int, retryFile string) (*string, error) {
+	if concurrency <= 0 {
+		return nil, fmt.Errorf("invalid concurrency %d - must be a positive number", concurrency)
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	rows, err := cli.parseMembersCSV(file)
+	if err != nil {
+		return nil, err
+	}
+	importMemberRows(rows, concurrency, cli.importMemberRow)
+
+	report := &MemberImportReport{File: filename, Rows: rows}
+	for _, row := range rows {
+		if row.Status == memberImportStatusFailed {
+			report.Failed++
+		} else {
+			report.Succeeded++
+		}
+	}
+	if report.Failed != 0 {
+		if retryFile == "" {
+			retryFile = filename + ".retry"
+		}
+		err = writeRetryFile(retryFile, rows)
+		if err != nil {
+			return nil, err
+		}
+		report.RetryFile = retryFile
+	}
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		cli.dumpMemberImportReport(&buf, report)
+		s := buf.String()
+		return &s, nil
+	}
+	output, err := cli.dumpByFormat(report, oldYamlConverter)
+	if err != nil {
+		return nil, err
+	}
+	if report.Failed != 0 {
+		return nil, &CommandFailedError{
+			Output: *output,
+			Reason: "import-members failed to process " + strconv.Itoa(report.Failed) + " rows - see " + retryFile,
+		}
+	}
+	return output, nil
+}
+
+func timestampValue(timestamp *rdl.Timestamp) string {
+	if timestamp == nil {
+		return ""
+	}
+	return timestamp.String()
+}
+
+// memberRecords returns the csv records for all the role and group
+// members of the domain. Delegated roles and pending members are skipped.
+func memberRecords(dn string, roles []*zms.Role, groups []*zms.Group) [][]string {
+	records := make([][]string, 0)
+	for _, role := range roles {
+		if role.Trust != "" {
+			continue
+		}
+		rn := localName(string(role.Name), ":role.")
+		for _, member := range role.RoleMembers {
+			if isPendingMember(member.Approved) {
+				continue
+			}
+			records = append(records, []string{dn, rn, string(member.MemberName),
+				timestampValue(member.Expiration), timestampValue(member.ReviewReminder), memberActionAdd})
+		}
+	}
+	for _, group := range groups {
+		gn := localName(string(group.Name), ":group.")
+		for _, member := range group.GroupMembers {
+			if isPendingMember(member.Approved) {
+				continue
+			}
+			records = append(records, []string{dn, "group." + gn, string(member.MemberName),
+				timestampValue(member.Expiration), "", memberActionAdd})
+		}
+	}
+	return records
+}
+
+// ExportMembers writes all the role and group members of the domain
+// in the csv format accepted by the import-members command
+func (cli Zms) ExportMembers(dn string, filename string) (*string, error) {
+	members := true
+	roles, err := cli.Zms.GetRoles(zms.DomainName(dn), &members, "", "")
+	if err != nil {
+		return nil, err
+	}
+	groups, err := cli.Zms.GetGroups(zms.DomainName(dn), &members, "", "")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write(memberCSVHeader)
+	_ = writer.WriteAll(memberRecords(dn, roles.List, groups.List))
+	if err = writer.Error(); err != nil {
+		return nil, err
+	}
+	if filename == "-" {
+		fmt.Print(buf.String())
+		return nil, nil
+	}
+	return nil, ioutil.WriteFile(filename, buf.Bytes(), 0644)
+}
@@ -0,0 +1,108 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+func TestParseMembersCSV(test *testing.T) {
+	cli := Zms{UserDomain: "user"}
+	data := `domain,role|group,member,expiration,review,action
+coretech,readers,john
+coretech,role.writers,coretech.api,2030-01-01T00:00:00Z,,delete
+coretech,group.devs,user.jane,,2030-01-01T00:00:00Z
+coretech,readers,john,,,update
+coretech,readers
+`
+	rows, err := cli.parseMembersCSV(strings.NewReader(data))
+	if err != nil {
+		test.Fatalf("unable to parse members csv: %v", err)
+	}
+	if len(rows) != 5 {
+		test.Fatalf("expected 5 rows, got %d", len(rows))
+	}
+	if rows[0].Line != 2 || rows[0].ObjectType != "role" || rows[0].Name != "readers" ||
+		rows[0].Member != "user.john" || rows[0].Action != memberActionAdd || rows[0].Status != "" {
+		test.Errorf("unexpected first row: %+v", rows[0])
+	}
+	if rows[1].Name != "writers" || rows[1].Member != "coretech.api" || rows[1].Expiration == nil ||
+		rows[1].Action != memberActionDelete {
+		test.Errorf("unexpected second row: %+v", rows[1])
+	}
+	for _, row := range rows[2:] {
+		if row.Status != memberImportStatusFailed || row.Error == "" {
+			test.Errorf("expected line %d to fail: %+v", row.Line, row)
+		}
+	}
+}
+
+func TestImportMemberRows(test *testing.T) {
+	rows := []*MemberImportRow{
+		{Line: 1, Member: "user.john"},
+		{Line: 2, Member: "user.jane"},
+		{Line: 3, Member: "user.joe", Status: memberImportStatusFailed, Error: "invalid action"},
+		{Line: 4, Member: "user.jack"},
+	}
+	var lock sync.Mutex
+	imported := make([]string, 0)
+	importMemberRows(rows, 2, func(row *MemberImportRow) error {
+		lock.Lock()
+		defer lock.Unlock()
+		imported = append(imported, row.Member)
+		if row.Member == "user.jane" {
+			return errors.New("forbidden")
+		}
+		return nil
+	})
+	if len(imported) != 3 {
+		test.Errorf("expected 3 imported rows, got %v", imported)
+	}
+	expected := []string{memberImportStatusOK, memberImportStatusFailed, memberImportStatusFailed, memberImportStatusOK}
+	for i, row := range rows {
+		if row.Status != expected[i] {
+			test.Errorf("line %d: expected status %s, got %s", row.Line, expected[i], row.Status)
+		}
+	}
+	if rows[1].Error != "forbidden" || rows[2].Error != "invalid action" {
+		test.Errorf("unexpected errors: %s, %s", rows[1].Error, rows[2].Error)
+	}
+}
+
+func TestMemberRecords(test *testing.T) {
+	pending := false
+	expiration, _ := rdl.TimestampParse("2030-01-01T00:00:00Z")
+	roles := []*zms.Role{
+		{
+			Name: "coretech:role.readers",
+			RoleMembers: []*zms.RoleMember{
+				{MemberName: "user.john", Expiration: &expiration},
+				{MemberName: "user.jane", Approved: &pending},
+			},
+		},
+		{Name: "coretech:role.trusted", Trust: "sports"},
+	}
+	groups := []*zms.Group{
+		{
+			Name:         "coretech:group.devs",
+			GroupMembers: []*zms.GroupMember{{MemberName: "user.joe"}},
+		},
+	}
+	records := memberRecords("coretech", roles, groups)
+	if len(records) != 2 {
+		test.Fatalf("expected 2 records, got %v", records)
+	}
+	if strings.Join(records[0], ",") != "coretech,readers,user.john,2030-01-01T00:00:00.000Z,,add" {
+		test.Errorf("unexpected role record: %v", records[0])
+	}
+	if strings.Join(records[1], ",") != "coretech,group.devs,user.joe,,,add" {
+		test.Errorf("unexpected group record: %v", records[1])
+	}
+}
@@ -4,6 +4,7 @@
 package zmscli
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/tls"
 	"crypto/x509"
@@ -28,6 +29,10 @@ const (
 	YAMLOutputFormat = "yaml"
 	// DefaultOutputFormat is the default (old) YAML output format for commands.
 	DefaultOutputFormat = "manualYaml"
+	// TableOutputFormat is the aligned columns output format for commands.
+	TableOutputFormat = "table"
+	// CSVOutputFormat is the comma separated values output format for commands.
+	CSVOutputFormat = "csv"
 	// ErrInvalidOutputFormat is the error message for unsupported output formats.
 	ErrInvalidOutputFormat = "unsupported output format \"%s\""
 )
@@ -48,6 +53,22 @@ type Zms struct {
 	Debug            bool
 	AddSelf          bool
 	SkipErrors       bool
+	AutoConfirm      bool
+	AthenzConf       string
+	Columns          string
+	Stdin            *bufio.Reader
+}
+
+// CommandFailedError is returned by commands that completed and produced
+// output that must be displayed but still need to report a failure, for
+// example, so that the command can be used to gate CI pipelines.
+type CommandFailedError struct {
+	Output string
+	Reason string
+}
+
+func (e *CommandFailedError) Error() string {
+	return e.Reason
 }
 
 type SuccessMessage struct {
@@ -93,6 +114,8 @@ func (cli Zms) dumpByFormat(jsonResponse interface{}, manualYamlConverter YamlCo
 		return cli.buildJSONOutput(jsonResponse)
 	case YAMLOutputFormat:
 		return cli.buildYAMLOutput(jsonResponse)
+	case TableOutputFormat, CSVOutputFormat:
+		return cli.buildTableOutput(jsonResponse)
 	case DefaultOutputFormat:
 		return manualYamlConverter(jsonResponse)
 	default:
@@ -285,11 +308,77 @@ func (cli *Zms) EvalCommand(params []string) (*string, error) {
 				return cli.UpdateDomain(dn, yamlfile)
 			}
 			return cli.helpCommand(params)
+		case "diff-domain":
+			if argc == 1 {
+				return cli.DiffDomain(dn, args[0], "")
+			} else if argc == 2 {
+				return cli.DiffDomain(dn, args[0], args[1])
+			}
+			return cli.helpCommand(params)
+		case "plan-domain":
+			if argc == 1 {
+				return cli.PlanDomain(dn, args[0])
+			}
+			return cli.helpCommand(params)
+		case "apply-domain":
+			if argc == 1 {
+				return cli.ApplyDomain(dn, args[0])
+			}
+			return cli.helpCommand(params)
+		case "graph-domain":
+			if argc >= 1 && !strings.HasPrefix(args[0], "--") {
+				//override the default domain, this command can show any of them
+				dn = args[0]
+				args = args[1:]
+				argc--
+			}
+			if dn == "" {
+				return nil, fmt.Errorf("no domain specified")
+			}
+			depth := 1
+			format := graphFormatDot
+			for i := 0; i < argc; i += 2 {
+				if i+1 >= argc {
+					return cli.helpCommand(params)
+				}
+				switch args[i] {
+				case "--depth":
+					var err error
+					depth, err = strconv.Atoi(args[i+1])
+					if err != nil {
+						return nil, err
+					}
+				case "--format":
+					format = args[i+1]
+				default:
+					return cli.helpCommand(params)
+				}
+			}
+			return cli.GraphDomain(dn, depth, format)
+		case "lint-domain":
+			if argc == 1 {
+				return cli.LintDomain(args[0], nil)
+			} else if argc == 3 && args[1] == "--allowed-domains" {
+				return cli.LintDomain(args[0], strings.Split(args[2], ","))
+			}
+			return cli.helpCommand(params)
+		case "simulate-access":
+			if argc == 2 {
+				return cli.SimulateAccess(dn, args[0], args[1])
+			}
+			return cli.helpCommand(params)
 		case "system-backup":
 			if argc == 1 {
 				return cli.SystemBackup(args[0])
 			}
 			return cli.helpCommand(params)
+		case "system-restore":
+			if argc == 1 {
+				return cli.SystemRestore(args[0], "")
+			} else if argc == 2 {
+				return cli.SystemRestore(args[0], args[1])
+			}
+			return cli.helpCommand(params)
 		case "add-domain":
 			if argc > 0 {
 				dn = args[0]
@@ -307,10 +396,16 @@ func (cli *Zms) EvalCommand(params []string) (*string, error) {
 			}
 			return cli.helpCommand(params)
 		case "delete-domain":
-			if argc == 1 {
+			if argc == 1 || (argc == 2 && args[1] == "--force") {
 				if args[0] == dn {
 					return nil, fmt.Errorf("cannot delete domain while using it")
 				}
+				if argc == 1 {
+					err := cli.CheckDomainDependencies(args[0], "")
+					if err != nil {
+						return nil, err
+					}
+				}
 				return cli.DeleteDomain(args[0])
 			}
 			return cli.helpCommand(params)
@@ -319,11 +414,50 @@ func (cli *Zms) EvalCommand(params []string) (*string, error) {
 				return cli.SetDefaultAdmins(args[0], args[1:])
 			}
 		case "get-signed-domains":
+			verify := false
+			if argc > 0 && args[argc-1] == "--verify" {
+				verify = true
+				argc--
+			}
 			matchingTag := ""
 			if argc == 1 {
 				matchingTag = args[0]
 			}
-			return cli.GetSignedDomains("", matchingTag)
+			return cli.GetSignedDomains("", matchingTag, verify)
+		case "watch-domains":
+			prefix := ""
+			interval := defaultWatchInterval
+			command := ""
+			webhook := ""
+			for i := 0; i < argc; i += 2 {
+				if i+1 >= argc {
+					return cli.helpCommand(params)
+				}
+				switch args[i] {
+				case "--prefix":
+					prefix = args[i+1]
+				case "--interval":
+					var err error
+					interval, err = strconv.Atoi(args[i+1])
+					if err != nil {
+						return nil, err
+					}
+				case "--exec":
+					command = args[i+1]
+				case "--webhook":
+					webhook = args[i+1]
+				default:
+					return cli.helpCommand(params)
+				}
+			}
+			return cli.WatchDomains(prefix, interval, command, webhook)
+		case "get-jws-domain":
+			if argc == 1 {
+				return cli.GetJWSDomain(args[0], false)
+			} else if argc == 2 && args[1] == "--verify" {
+				return cli.GetJWSDomain(args[0], true)
+			}
+			return cli.helpCommand(params)
 		case "list-server-template", "list-server-templates":
 			return cli.ListServerTemplates()
 		case "list-domain-template", "list-domain-templates":
@@ -335,6 +469,17 @@ func (cli *Zms) EvalCommand(params []string) (*string, error) {
 				return cli.ListDomainTemplates(dn)
 			}
 			return nil, fmt.Errorf("no domain specified")
+		case "list-domain-template-details":
+			if argc == 1 {
+				//override the default domain, this command can show any of them
+				dn = args[0]
+			}
+			if dn != "" {
+				return cli.ListDomainTemplateDetails(dn)
+			}
+			return nil, fmt.Errorf("no domain specified")
+		case "list-server-template-details":
+			return cli.ListDomainTemplateDetails("")
 		case "show-server-template":
 			if argc == 1 {
 				return cli.ShowServerTemplate(args[0])
@@ -366,6 +511,197 @@ func (cli *Zms) EvalCommand(params []string) (*string, error) {
 				principal = args[0]
 			}
 			return cli.ListPendingDomainGroupMembers(principal, "")
+		case "purge-pending-members":
+			if argc == 1 || argc == 2 {
+				days, err := strconv.Atoi(args[0])
+				if err != nil {
+					return nil, err
+				}
+				pattern := ""
+				if argc == 2 {
+					pattern = args[1]
+				}
+				return cli.PurgePendingMembers(dn, days, pattern)
+			}
+			return cli.helpCommand(params)
+		case "approve-pending", "reject-pending":
+			name := ""
+			pattern := ""
+			days := 0
+			dryRun := false
+			for i := 0; i < argc; i++ {
+				if args[i] == "--dry-run" {
+					dryRun = true
+					continue
+				}
+				if i+1 >= argc {
+					return cli.helpCommand(params)
+				}
+				switch args[i] {
+				case "--domain":
+					dn = args[i+1]
+				case "--role":
+					name = args[i+1]
+				case "--member-pattern":
+					pattern = args[i+1]
+				case "--older-than":
+					var err error
+					days, err = strconv.Atoi(args[i+1])
+					if err != nil {
+						return nil, err
+					}
+				default:
+					return cli.helpCommand(params)
+				}
+				i++
+			}
+			return cli.PutPendingMembersDecision(dn, name, days, pattern, cmd == "approve-pending", dryRun)
+		case "clone-domain":
+			if argc < 2 {
+				return cli.helpCommand(params)
+			}
+			include := cloneObjectTypes
+			rewrite := false
+			withMeta := false
+			for i := 2; i < argc; i++ {
+				switch args[i] {
+				case "--rewrite-domain":
+					rewrite = true
+				case "--with-meta":
+					withMeta = true
+				case "--include":
+					if i+1 >= argc {
+						return cli.helpCommand(params)
+					}
+					var err error
+					include, err = parseCloneInclude(args[i+1])
+					if err != nil {
+						return nil, err
+					}
+					i++
+				default:
+					return cli.helpCommand(params)
+				}
+			}
+			return cli.CloneDomain(args[0], args[1], include, rewrite, withMeta)
+		case "copy-role":
+			if argc < 3 {
+				return cli.helpCommand(params)
+			}
+			rewrite := false
+			withMeta := false
+			for _, arg := range args[3:] {
+				switch arg {
+				case "--rewrite-domain":
+					rewrite = true
+				case "--with-meta":
+					withMeta = true
+				default:
+					return cli.helpCommand(params)
+				}
+			}
+			return cli.CopyRole(args[0], args[1], args[2], rewrite, withMeta)
+		case "replace-member":
+			if argc < 2 {
+				return cli.helpCommand(params)
+			}
+			prefix := ""
+			rollbackFile := ""
+			keepExpiry := false
+			dryRun := false
+			for i := 2; i < argc; i++ {
+				switch args[i] {
+				case "--keep-expiry":
+					keepExpiry = true
+				case "--dry-run":
+					dryRun = true
+				case "--domains", "--rollback":
+					if i+1 >= argc {
+						return cli.helpCommand(params)
+					}
+					if args[i] == "--domains" {
+						prefix = args[i+1]
+					} else {
+						rollbackFile = args[i+1]
+					}
+					i++
+				default:
+					return cli.helpCommand(params)
+				}
+			}
+			return cli.ReplaceMember(args[0], args[1], prefix, keepExpiry, dryRun, rollbackFile)
+		case "import-members":
+			if argc < 1 {
+				return cli.helpCommand(params)
+			}
+			concurrency := defaultImportConcurrency
+			retryFile := ""
+			for i := 1; i < argc; i += 2 {
+				if i+1 >= argc {
+					return cli.helpCommand(params)
+				}
+				switch args[i] {
+				case "--concurrency":
+					var err error
+					concurrency, err = strconv.Atoi(args[i+1])
+					if err != nil {
+						return nil, err
+					}
+				case "--retry-file":
+					retryFile = args[i+1]
+				default:
+					return cli.helpCommand(params)
+				}
+			}
+			return cli.ImportMembers(args[0], concurrency, retryFile)
+		case "export-members":
+			if argc == 1 || argc == 2 {
+				filename := "-"
+				if argc == 2 {
+					filename = args[1]
+				}
+				return cli.ExportMembers(args[0], filename)
+			}
+			return cli.helpCommand(params)
+		case "rollback-replace-member":
+			if argc == 1 {
+				return cli.RollbackReplaceMember(args[0])
+			}
+			return cli.helpCommand(params)
+		case "report-expiring-members":
+			days := defaultExpiringDays
+			prefix := ""
+			tagKey := ""
+			tagValue := ""
+			for i := 0; i < argc; i += 2 {
+				if i+1 >= argc {
+					return cli.helpCommand(params)
+				}
+				switch args[i] {
+				case "--days":
+					var err error
+					days, err = strconv.Atoi(args[i+1])
+					if err != nil {
+						return nil, err
+					}
+				case "--prefix":
+					prefix = args[i+1]
+				case "--tag":
+					tagKey, tagValue = parseTagFilter(args[i+1])
+				default:
+					return cli.helpCommand(params)
+				}
+			}
+			return cli.ReportExpiringMembers(days, prefix, tagKey, tagValue)
+		case "list-meta-store-values", "list-meta-store-value":
+			if argc == 1 {
+				return cli.ListMetaStoreValues(args[0], "")
+			} else if argc == 2 {
+				return cli.ListMetaStoreValues(args[0], args[1])
+			}
+			return cli.helpCommand(params)
+		case "list-user-authority-attributes", "list-user-authority-attribute":
+			return cli.ListUserAuthorityAttributes()
 		case "show-roles-principal":
 			if argc == 0 {
 				return cli.ShowRolesPrincipal("", dn)
@@ -384,6 +720,46 @@ func (cli *Zms) EvalCommand(params []string) (*string, error) {
 				dn = args[0]
 			}
 			return cli.GetStats(dn)
+		case "quota-report":
+			threshold := defaultQuotaThreshold
+			all := false
+			for i := 0; i < argc; i++ {
+				switch args[i] {
+				case "--all":
+					all = true
+				case "--threshold":
+					if i+1 >= argc {
+						return cli.helpCommand(params)
+					}
+					var err error
+					threshold, err = strconv.Atoi(args[i+1])
+					if err != nil {
+						return nil, err
+					}
+					i++
+				default:
+					if strings.HasPrefix(args[i], "--") {
+						return cli.helpCommand(params)
+					}
+					//override the default domain
+					dn = args[i]
+				}
+			}
+			if all {
+				dn = ""
+			} else if dn == "" {
+				return cli.helpCommand(params)
+			}
+			return cli.QuotaReport(dn, threshold)
+		case "get-dependent-service-resource-groups":
+			if argc == 1 {
+				//override the default domain
+				dn = args[0]
+			}
+			if dn != "" {
+				return cli.GetDependentServiceResourceGroups(dn)
+			}
+			return nil, fmt.Errorf("no domain specified")
 		case "get-dependent-domain-list":
 			if argc == 1 {
 				return cli.GetDependentDomainList(args[0])
@@ -444,6 +820,22 @@ func (cli *Zms) EvalCommand(params []string) (*string, error) {
 			if argc >= 1 {
 				return cli.DeleteAssertionPolicyVersion(dn, args[0], args[1], args[2:])
 			}
+		case "list-assertion-conditions":
+			if argc >= 2 {
+				return cli.ListAssertionConditions(dn, args[0], args[1:])
+			}
+		case "add-assertion-condition":
+			if argc >= 5 {
+				return cli.AddAssertionCondition(dn, args[0], args[1:])
+			}
+		case "delete-assertion-condition":
+			if argc >= 3 {
+				return cli.DeleteAssertionCondition(dn, args[0], args[1:])
+			}
+		case "delete-assertion-conditions":
+			if argc >= 2 {
+				return cli.DeleteAssertionConditions(dn, args[0], args[1:])
+			}
 		case "delete-policy":
 			if argc == 1 {
 				return cli.DeletePolicy(dn, args[0])
@@ -452,6 +844,16 @@ func (cli *Zms) EvalCommand(params []string) (*string, error) {
 			if argc == 2 {
 				return cli.DeletePolicyVersion(dn, args[0], args[1])
 			}
+		case "diff-policy-version":
+			if argc == 3 {
+				return cli.DiffPolicyVersion(dn, args[0], args[1], args[2])
+			}
+		case "promote-policy-version":
+			if argc == 2 {
+				return cli.PromotePolicyVersion(dn, args[0], args[1], "")
+			} else if argc == 4 && args[2] == "--check" {
+				return cli.PromotePolicyVersion(dn, args[0], args[1], args[3])
+			}
 		case "set-active-policy-version":
 			if argc == 2 {
 				return cli.SetActivePolicyVersion(dn, args[0], args[1])
@@ -480,6 +882,12 @@ func (cli *Zms) EvalCommand(params []string) (*string, error) {
 				}
 				return cli.ShowAccessExt(dn, args[0], args[1], altPrincipal, trustDomain)
 			}
+		case "explain-access":
+			if argc == 2 {
+				return cli.ExplainAccess(dn, args[0], args[1], nil)
+			} else if argc == 3 {
+				return cli.ExplainAccess(dn, args[0], args[1], &args[2])
+			}
 		case "list-role", "list-roles":
 			return cli.ListRoles(dn)
 		case "show-role":
@@ -547,6 +955,10 @@ func (cli *Zms) EvalCommand(params []string) (*string, error) {
 			if argc >= 2 {
 				return cli.DeleteMembers(dn, args[0], args[1:])
 			}
+		case "review-role":
+			if argc >= 1 {
+				return cli.ReviewRole(dn, args[0], args[1:])
+			}
 		case "check-member", "check-members", "show-member", "show-members":
 			if argc >= 2 {
 				return cli.CheckMembers(dn, args[0], args[1:])
@@ -593,6 +1005,10 @@ func (cli *Zms) EvalCommand(params []string) (*string, error) {
 			if argc >= 2 {
 				return cli.DeleteGroupMembers(dn, args[0], args[1:])
 			}
+		case "review-group":
+			if argc >= 1 {
+				return cli.ReviewGroup(dn, args[0], args[1:])
+			}
 		case "check-group-member", "check-group-members", "show-group-member", "show-group-members":
 			if argc >= 2 {
 				return cli.CheckGroupMembers(dn, args[0], args[1:])
@@ -702,7 +1118,13 @@ func (cli *Zms) EvalCommand(params []string) (*string, error) {
 				return nil, err
 			}
 		case "delete-tenancy":
-			if argc == 1 {
+			if argc == 1 || (argc == 2 && args[1] == "--force") {
+				if argc == 1 {
+					err := cli.CheckDomainDependencies(dn, args[0])
+					if err != nil {
+						return nil, err
+					}
+				}
 				return cli.DeleteTenancy(dn, args[0])
 			}
 		case "show-tenant-resource-group-roles":
@@ -837,6 +1259,19 @@ func (cli *Zms) EvalCommand(params []string) (*string, error) {
 			if argc >= 1 {
 				return cli.SetDomainTemplate(dn, args[0:])
 			}
+		case "preview-domain-template":
+			if argc >= 1 {
+				dryRun := false
+				templateArgs := make([]string, 0)
+				for _, arg := range args {
+					if arg == "--dry-run" {
+						dryRun = true
+					} else {
+						templateArgs = append(templateArgs, arg)
+					}
+				}
+				return cli.PreviewDomainTemplate(dn, templateArgs, dryRun)
+			}
 		case "delete-domain-template":
 			if argc == 1 {
 				return cli.DeleteDomainTemplate(dn, args[0])
@@ -957,6 +1392,10 @@ func (cli *Zms) EvalCommand(params []string) (*string, error) {
 			if argc == 2 {
 				return cli.SetRoleUserAuthorityExpiration(dn, args[0], args[1])
 			}
+		case "delete-pending-member":
+			if argc == 2 {
+				return cli.DeletePendingMembership(dn, args[0], args[1])
+			}
 		case "put-membership-decision":
 			if argc == 4 {
 				approval, err := strconv.ParseBool(args[3])
@@ -1035,6 +1474,10 @@ func (cli *Zms) EvalCommand(params []string) (*string, error) {
 				}
 				return cli.PutGroupMembershipDecision(dn, args[0], args[1], approval)
 			}
+		case "delete-pending-group-member":
+			if argc == 2 {
+				return cli.DeletePendingGroupMembership(dn, args[0], args[1])
+			}
 		case "add-role-tag":
 			if argc >= 3 {
 				return cli.AddRoleTags(dn, args[0], args[1], args[2:])
@@ -1447,6 +1890,98 @@ func (cli Zms) HelpSpecificCommand(interactive bool, cmd string) string {
 		buf.WriteString("   file.yaml : filename where the domain data is stored\n")
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   export-domain coretech /tmp/coretech.yaml\n")
+	case "diff-domain":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [-o json] diff-domain file.yaml [other-file.yaml]\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   file.yaml       : file with the domain contents in any export-domain format\n")
+		buf.WriteString("   other-file.yaml : second domain file to compare against. if not specified\n")
+		buf.WriteString("                   : the live domain is compared against file.yaml\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   reports the semantic differences between the domains regardless of the order\n")
+		buf.WriteString("   of objects in the files: role and group members with their expiration and\n")
+		buf.WriteString("   review dates, policy assertions, service public keys and hosts, tags and\n")
+		buf.WriteString("   domain, role and group meta attributes including the system attributes. the\n")
+		buf.WriteString("   domain enabled flag is not compared. changes are reported from the first domain\n")
+		buf.WriteString("   to the second.\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   diff-domain coretech-v1.yaml coretech-v2.yaml\n")
+		buf.WriteString("   -o json diff-domain coretech.yaml\n")
+	case "plan-domain":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [-o json] plan-domain file.yaml\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   file.yaml : file with the domain contents in any export-domain format\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   displays the roles, groups, policies, services and domain attributes that\n")
+		buf.WriteString("   must be added, updated or deleted for the domain to match the file contents.\n")
+		buf.WriteString("   the domain name is taken from the file. no changes are made to the domain.\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   plan-domain coretech.yaml\n")
+	case "graph-domain":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   graph-domain domain [--depth N] [--format dot|mermaid|json]\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   domain   : name of the domain to generate the graph for\n")
+		buf.WriteString("   --depth  : number of levels of referenced domains to expand (default 1).\n")
+		buf.WriteString("            : 0 only includes the objects of the given domain\n")
+		buf.WriteString("   --format : graph format - dot (graphviz), mermaid or json (default dot)\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   generates the authorization graph of the domain with nodes for roles,\n")
+		buf.WriteString("   groups, policies, services and referenced domains and edges for group and\n")
+		buf.WriteString("   service memberships, assertions, delegated (trust) roles, assume_role\n")
+		buf.WriteString("   assertions, tenancy and domain dependencies. users are not displayed as\n")
+		buf.WriteString("   separate nodes; the number of users is included in the role/group label\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   graph-domain coretech | dot -Tsvg -o coretech.svg\n")
+		buf.WriteString("   graph-domain coretech --depth 2 --format mermaid\n")
+	case "lint-domain":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [-o json] lint-domain file.yaml [--allowed-domains domain[,domain...]]\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   file.yaml         : file with the domain contents in any export-domain format\n")
+		buf.WriteString("   --allowed-domains : members must belong to the domain in the file or one of\n")
+		buf.WriteString("                     : these domains or their sub domains\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   checks the domain file without contacting the server and reports assertions\n")
+		buf.WriteString("   that reference unknown roles or have invalid resource names, duplicate\n")
+		buf.WriteString("   assertions, empty policies, roles not referenced by any policy, members\n")
+		buf.WriteString("   from domains that are not allowed, expired members and members of audit\n")
+		buf.WriteString("   enabled roles without an audit reference (unless -a is specified).\n")
+		buf.WriteString("   the command exits with a non-zero code if any errors are found\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   lint-domain coretech.yaml\n")
+		buf.WriteString("   -o json lint-domain coretech.json --allowed-domains " + cli.UserDomain + ",sports\n")
+	case "simulate-access":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [-o json] simulate-access file.yaml matrix-file\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   file.yaml   : file with the domain contents in any export-domain format\n")
+		buf.WriteString("   matrix-file : file with one access check per line in the format:\n")
+		buf.WriteString("               :   principal action resource\n")
+		buf.WriteString("               : values can be separated by spaces or commas. resources without\n")
+		buf.WriteString("               : a domain prefix belong to the domain in the file. empty lines\n")
+		buf.WriteString("               : and lines starting with # are ignored\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   evaluates each access check locally against both the live domain and the\n")
+		buf.WriteString("   domain contents in the file (see explain-access) and displays the checks\n")
+		buf.WriteString("   whose decision changes from allow to deny or from deny to allow.\n")
+		buf.WriteString("   no changes are made to the domain.\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   simulate-access coretech.yaml access-checks.txt\n")
+	case "apply-domain":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [-y] [-a audit-ref] apply-domain file.yaml\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   file.yaml : file with the domain contents in any export-domain format\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   displays the same changes as plan-domain and after confirmation applies\n")
+		buf.WriteString("   them to the domain including deleting any objects, members, assertions\n")
+		buf.WriteString("   and tags not present in the file. the reserved admin role and policy are\n")
+		buf.WriteString("   never deleted. use -y to skip the confirmation prompt.\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   apply-domain coretech.yaml\n")
+		buf.WriteString("   -y -a change-1234 apply-domain coretech.yaml\n")
 	case "add-domain-tag":
 		buf.WriteString(" syntax:\n")
 		buf.WriteString("   [-o json] " + domainParam + " add-domain-tag tag_key tag_value [tag_value ...]\n")
@@ -1479,11 +2014,17 @@ func (cli Zms) HelpSpecificCommand(interactive bool, cmd string) string {
 		buf.WriteString("   lookup-domain-by-tag tag_key tag_value\n")
 	case "delete-domain":
 		buf.WriteString(" syntax:\n")
-		buf.WriteString("   [-o json] delete-domain domain\n")
+		buf.WriteString("   [-o json] delete-domain domain [--force]\n")
 		buf.WriteString(" parameters:\n")
-		buf.WriteString("   domain : name of the domain to be deleted\n")
+		buf.WriteString("   domain  : name of the domain to be deleted\n")
+		buf.WriteString("   --force : skip the dependency check\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   before the domain is deleted, the provider services that still depend on\n")
+		buf.WriteString("   the domain are retrieved. if there are any, they are displayed along with\n")
+		buf.WriteString("   their resource groups and the domain is not deleted\n")
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   delete-domain coretech\n")
+		buf.WriteString("   delete-domain coretech --force\n")
 	case "set-default-admins":
 		buf.WriteString(" syntax:\n")
 		buf.WriteString("   set-default-admins domain admin [admin ...]\n")
@@ -1492,15 +2033,48 @@ func (cli Zms) HelpSpecificCommand(interactive bool, cmd string) string {
 		buf.WriteString("   admin  : list of administrators to be set for domain\n")
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   set-default-admins coretech.hosted " + cli.UserDomain + ".john " + cli.UserDomain + ".jane\n")
+	case "watch-domains":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   watch-domains [--prefix prefix] [--interval seconds] [--exec command] [--webhook url]\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   --prefix   : only watch domains with the given name prefix\n")
+		buf.WriteString("   --interval : number of seconds between polls (default 60)\n")
+		buf.WriteString("   --exec     : shell command executed for each event with the event json on\n")
+		buf.WriteString("              : stdin and ZMS_EVENT, ZMS_EVENT_DOMAIN and ZMS_EVENT_OBJECT set\n")
+		buf.WriteString("   --webhook  : url the event json is posted to for each event\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   polls the modified domains using conditional requests, compares each changed\n")
+		buf.WriteString("   domain with its previous snapshot and writes one json line per change to\n")
+		buf.WriteString("   stdout. events include role and group members added, removed or updated,\n")
+		buf.WriteString("   assertions added or removed, public keys added, removed or rotated, objects\n")
+		buf.WriteString("   added or deleted and meta changes. the first poll only records the current\n")
+		buf.WriteString("   state of the domains. the command runs until it's interrupted\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   watch-domains --prefix coretech --interval 30\n")
+		buf.WriteString("   watch-domains --exec 'test \"$ZMS_EVENT_OBJECT\" = role:admin && notify-admins'\n")
+		buf.WriteString("   watch-domains --webhook https://hooks.example.com/athenz\n")
 	case "get-signed-domains":
 		buf.WriteString(" syntax:\n")
-		buf.WriteString("   [-o json] get-signed-domains [matching_tag]\n")
+		buf.WriteString("   [-o json] get-signed-domains [matching_tag] [--verify]\n")
 		buf.WriteString(" parameters:\n")
 		buf.WriteString("   matching-tag : value of ETag header retrieved from previous get-signed-domain call\n")
 		buf.WriteString("                : server will return changes since this timestamp only\n")
+		buf.WriteString("   --verify     : verify the signature of each domain with the zms public keys\n")
+		buf.WriteString("                : from the athenz configuration file specified with the -conf option\n")
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   get-signed-domains\n")
 		buf.WriteString("   get-signed-domains \"2015-04-10T20:43:34.023Z-gzip\"\n")
+		buf.WriteString("   get-signed-domains --verify\n")
+	case "get-jws-domain":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [-o json] get-jws-domain domain [--verify]\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   domain   : name of the domain to retrieve as a JWS signed object\n")
+		buf.WriteString("   --verify : verify the JWS signature with the zms public keys\n")
+		buf.WriteString("            : from the athenz configuration file specified with the -conf option\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   get-jws-domain coretech\n")
+		buf.WriteString("   -conf /home/athenz/conf/athenz.conf get-jws-domain coretech --verify\n")
 	case "list-policy":
 		buf.WriteString(" syntax:\n")
 		buf.WriteString("   " + domainParam + " list-policy\n")
@@ -1641,6 +2215,63 @@ func (cli Zms) HelpSpecificCommand(interactive bool, cmd string) string {
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   " + domainExample + " delete-assertion-policy-version writers_policy onprem_version grant write to writers_role on articles.sports\n")
 		buf.WriteString("   " + domainExample + " delete-assertion-policy-version readers_policy 0 grant read to readers_role on " + cli.interactiveSingleQuoteString(interactive, "articles.*") + "\n")
+	case "list-assertion-conditions":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   " + domainParam + " list-assertion-conditions policy assertion-id|assertion\n")
+		buf.WriteString(" parameters:\n")
+		if !interactive {
+			buf.WriteString("   domain       : name of the domain that policy belongs to\n")
+		}
+		buf.WriteString("   policy       : name of the policy\n")
+		buf.WriteString("   assertion-id : id of the assertion in the policy\n")
+		buf.WriteString("   assertion    : existing assertion in the policy in the '<effect> <action> to <role> on <resource>' format\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   " + domainExample + " list-assertion-conditions writers_policy 12345\n")
+		buf.WriteString("   " + domainExample + " list-assertion-conditions writers_policy grant write to writers_role on articles.sports\n")
+	case "add-assertion-condition":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   " + domainParam + " add-assertion-condition policy assertion-id|assertion key operator value [key operator value ...]\n")
+		buf.WriteString(" parameters:\n")
+		if !interactive {
+			buf.WriteString("   domain       : name of the domain that policy belongs to\n")
+		}
+		buf.WriteString("   policy       : name of the policy\n")
+		buf.WriteString("   assertion-id : id of the assertion in the policy\n")
+		buf.WriteString("   assertion    : existing assertion in the policy in the '<effect> <action> to <role> on <resource>' format\n")
+		buf.WriteString("   key          : condition key (e.g. instances, enforcementstate)\n")
+		buf.WriteString("   operator     : condition operator - EQUALS\n")
+		buf.WriteString("   value        : condition value\n")
+		buf.WriteString("                : all key/operator/value triplets form a single condition with AND operation\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   " + domainExample + " add-assertion-condition writers_policy 12345 instances EQUALS host1,host2 enforcementstate EQUALS enforce\n")
+		buf.WriteString("   " + domainExample + " add-assertion-condition writers_policy grant write to writers_role on articles.sports instances EQUALS host1\n")
+	case "delete-assertion-condition":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   " + domainParam + " delete-assertion-condition policy assertion-id|assertion condition-id\n")
+		buf.WriteString(" parameters:\n")
+		if !interactive {
+			buf.WriteString("   domain       : name of the domain that policy belongs to\n")
+		}
+		buf.WriteString("   policy       : name of the policy\n")
+		buf.WriteString("   assertion-id : id of the assertion in the policy\n")
+		buf.WriteString("   assertion    : existing assertion in the policy in the '<effect> <action> to <role> on <resource>' format\n")
+		buf.WriteString("   condition-id : id of the condition to be deleted as displayed by list-assertion-conditions\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   " + domainExample + " delete-assertion-condition writers_policy 12345 1\n")
+		buf.WriteString("   " + domainExample + " delete-assertion-condition writers_policy grant write to writers_role on articles.sports 1\n")
+	case "delete-assertion-conditions":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   " + domainParam + " delete-assertion-conditions policy assertion-id|assertion\n")
+		buf.WriteString(" parameters:\n")
+		if !interactive {
+			buf.WriteString("   domain       : name of the domain that policy belongs to\n")
+		}
+		buf.WriteString("   policy       : name of the policy\n")
+		buf.WriteString("   assertion-id : id of the assertion in the policy\n")
+		buf.WriteString("   assertion    : existing assertion in the policy in the '<effect> <action> to <role> on <resource>' format\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   " + domainExample + " delete-assertion-conditions writers_policy 12345\n")
+		buf.WriteString("   " + domainExample + " delete-assertion-conditions writers_policy grant write to writers_role on articles.sports\n")
 	case "delete-policy":
 		buf.WriteString(" syntax:\n")
 		buf.WriteString("   " + domainParam + " delete-policy policy\n")
@@ -1673,6 +2304,41 @@ func (cli Zms) HelpSpecificCommand(interactive bool, cmd string) string {
 		buf.WriteString("   version : name of the version to set active\n")
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   " + domainExample + " set-active-policy-version readers dev_version\n")
+	case "diff-policy-version":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   " + domainParam + " diff-policy-version policy version1 version2\n")
+		buf.WriteString(" parameters:\n")
+		if !interactive {
+			buf.WriteString("   domain   : name of the domain that policy belongs to\n")
+		}
+		buf.WriteString("   policy   : name of the policy\n")
+		buf.WriteString("   version1 : name of the version to compare from\n")
+		buf.WriteString("   version2 : name of the version to compare to\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   displays the assertions added (+) and removed (-) in version2 of the policy\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   " + domainExample + " diff-policy-version readers 0 dev_version\n")
+	case "promote-policy-version":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   " + domainParam + " promote-policy-version policy version [--check matrix-file]\n")
+		buf.WriteString(" parameters:\n")
+		if !interactive {
+			buf.WriteString("   domain      : name of the domain that policy belongs to\n")
+		}
+		buf.WriteString("   policy      : name of the policy\n")
+		buf.WriteString("   version     : name of the version to activate\n")
+		buf.WriteString("   matrix-file : file with one access check per line in the format:\n")
+		buf.WriteString("               :   principal action resource [allow|deny]\n")
+		buf.WriteString("               : in the same format as the simulate-access command\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   displays the assertion differences between the active and the given version\n")
+		buf.WriteString("   and activates the version once confirmed. if the matrix file is given, each\n")
+		buf.WriteString("   access check is evaluated against the candidate version first and activation\n")
+		buf.WriteString("   is refused if the decision does not match the expected decision or, when none\n")
+		buf.WriteString("   is given, the decision with the active version. use -y to skip confirmation\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   " + domainExample + " promote-policy-version readers dev_version\n")
+		buf.WriteString("   " + domainExample + " promote-policy-version readers dev_version --check access-matrix.txt\n")
 	case "show-access":
 		buf.WriteString(" syntax:\n")
 		buf.WriteString("   " + domainParam + " show-access action resource [alt_identity [trust_domain]]\n")
@@ -1707,6 +2373,28 @@ func (cli Zms) HelpSpecificCommand(interactive bool, cmd string) string {
 		buf.WriteString("   " + domainExample + " show-access-ext node_sudo node.host1\n")
 		buf.WriteString("   " + domainExample + " show-access-ext node_sudo coretech:node.host1\n")
 		buf.WriteString("   " + domainExample + " show-access-ext node_sudo coretech:node.host1 " + cli.UserDomain + ".john\n")
+	case "explain-access":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   " + domainParam + " explain-access action resource [principal]\n")
+		buf.WriteString(" parameters:\n")
+		if !interactive {
+			buf.WriteString("   domain    : name of the domain that resource belongs to\n")
+		}
+		buf.WriteString("   action    : access check action value\n")
+		buf.WriteString("   resource  : access check resource (resource name)\n")
+		buf.WriteString("             : client will prepend 'domain:' to resource if not specified\n")
+		buf.WriteString("   principal : run the access check for this principal instead of the caller\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   evaluates the access check locally using the domain's roles, groups and\n")
+		buf.WriteString("   policies retrieved from the server and displays the decision along with\n")
+		buf.WriteString("   all matching assertions and the chain of role, group and delegated (trust)\n")
+		buf.WriteString("   role memberships that matched them. deny assertions take precedence over\n")
+		buf.WriteString("   allow assertions. expired, pending and disabled memberships that were\n")
+		buf.WriteString("   ignored are listed as well. assertion conditions are displayed but not\n")
+		buf.WriteString("   evaluated, so use show-access to confirm the server's decision\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   " + domainExample + " explain-access node_sudo node.host1\n")
+		buf.WriteString("   " + domainExample + " explain-access node_sudo coretech:node.host1 " + cli.UserDomain + ".john\n")
 	case "show-resource":
 		buf.WriteString(" syntax:\n")
 		buf.WriteString("   show-resource principal action\n")
@@ -1848,6 +2536,27 @@ func (cli Zms) HelpSpecificCommand(interactive bool, cmd string) string {
 		buf.WriteString("   user_or_service : users or services to be removed as members\n")
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   " + domainExample + " delete-member readers " + cli.UserDomain + ".john " + cli.UserDomain + ".joe media.sports.storage\n")
+	case "review-role":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   " + domainParam + " review-role role [review-file.yaml | extend|extend-all days [member ...]]\n")
+		buf.WriteString(" parameters:\n")
+		if !interactive {
+			buf.WriteString("   domain           : name of the domain that role belongs to\n")
+		}
+		buf.WriteString("   role             : name of the role to be reviewed\n")
+		buf.WriteString("   review-file.yaml : review file with the keep/extend/remove action for each member\n")
+		buf.WriteString("   days             : extend the expiration of all members by the given number of days from now.\n")
+		buf.WriteString("                      members without any dates are only extended with extend-all\n")
+		buf.WriteString("   member           : members excluded from the extension and left unchanged\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   without any arguments the current role members are written into a review file\n")
+		buf.WriteString("   which is opened with $EDITOR (default vi). set the action for each member to\n")
+		buf.WriteString("   keep, extend (with the new dates) or remove. once the editor is closed all\n")
+		buf.WriteString("   decisions are submitted as a single review request with the audit reference.\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   " + domainExample + " review-role readers\n")
+		buf.WriteString("   " + domainExample + " review-role readers readers-review.yaml\n")
+		buf.WriteString("   " + domainExample + " review-role readers extend 90 " + cli.UserDomain + ".john\n")
 	case "add-provider-role-member":
 		buf.WriteString(" syntax:\n")
 		buf.WriteString("   " + domainParam + " add-provider-role-member provider_service resource_group provider_role user_or_service [user_or_service ...]\n")
@@ -2008,6 +2717,27 @@ func (cli Zms) HelpSpecificCommand(interactive bool, cmd string) string {
 		buf.WriteString("   user_or_service : users or services to be removed as members\n")
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   " + domainExample + " delete-group-member readers " + cli.UserDomain + ".john " + cli.UserDomain + ".joe media.sports.storage\n")
+	case "review-group":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   " + domainParam + " review-group group [review-file.yaml | extend|extend-all days [member ...]]\n")
+		buf.WriteString(" parameters:\n")
+		if !interactive {
+			buf.WriteString("   domain           : name of the domain that group belongs to\n")
+		}
+		buf.WriteString("   group            : name of the group to be reviewed\n")
+		buf.WriteString("   review-file.yaml : review file with the keep/extend/remove action for each member\n")
+		buf.WriteString("   days             : extend the expiration of all members by the given number of days from now.\n")
+		buf.WriteString("                      members without any dates are only extended with extend-all\n")
+		buf.WriteString("   member           : members excluded from the extension and left unchanged\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   without any arguments the current group members are written into a review file\n")
+		buf.WriteString("   which is opened with $EDITOR (default vi). set the action for each member to\n")
+		buf.WriteString("   keep, extend (with the new dates) or remove. once the editor is closed all\n")
+		buf.WriteString("   decisions are submitted as a single review request with the audit reference.\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   " + domainExample + " review-group readers\n")
+		buf.WriteString("   " + domainExample + " review-group readers readers-review.yaml\n")
+		buf.WriteString("   " + domainExample + " review-group readers extend 90 " + cli.UserDomain + ".john\n")
 	case "delete-group":
 		buf.WriteString(" syntax:\n")
 		buf.WriteString("   " + domainParam + " delete-group group\n")
@@ -2317,13 +3047,18 @@ func (cli Zms) HelpSpecificCommand(interactive bool, cmd string) string {
 		buf.WriteString("   " + domainExample + " add-tenancy weather.storage false\n")
 	case "delete-tenancy":
 		buf.WriteString(" syntax:\n")
-		buf.WriteString("   " + domainParam + " delete-tenancy provider\n")
+		buf.WriteString("   " + domainParam + " delete-tenancy provider [--force]\n")
 		buf.WriteString(" parameters:\n")
 		if !interactive {
 			buf.WriteString("   domain   : name of the tenant's domain\n")
 		}
 		buf.WriteString("   provider : provider's service name to remove the tenant from\n")
 		buf.WriteString("            : the provider's name must be service common name in <domain>.<service> format\n")
+		buf.WriteString("   --force  : skip the resource group dependency check\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   before the tenancy is deleted, the resource groups the domain still has with\n")
+		buf.WriteString("   the provider are retrieved. if there are any, they are displayed and the\n")
+		buf.WriteString("   tenancy is not deleted\n")
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   " + domainExample + " delete-tenancy weather.storage\n")
 	case "show-tenant-resource-group-roles":
@@ -2439,14 +3174,45 @@ func (cli Zms) HelpSpecificCommand(interactive bool, cmd string) string {
 		buf.WriteString("   version\n")
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   version\n")
+	case "shell":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [-d domain] shell\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   domain : optional name of the domain to start the session with\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   starts an interactive session where commands are entered without the\n")
+		buf.WriteString("   zms-cli prefix and the domain is set with the use-domain command.\n")
+		buf.WriteString("   commands can be edited and retrieved from the session history with the\n")
+		buf.WriteString("   arrow keys. the tab key completes command names and the domain, role,\n")
+		buf.WriteString("   group, policy and service names. type exit or quit to end the session\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   -d coretech shell\n")
 	case "system-backup":
 		buf.WriteString(" syntax:\n")
-		buf.WriteString("   system-backup dir\n")
+		buf.WriteString("   [-o json] system-backup dir\n")
 		buf.WriteString(" parameters:\n")
-		buf.WriteString("   dir : directory path to store all exported domain's yaml files\n")
-		buf.WriteString("       : each filename will have the same filename as the domain name\n")
+		buf.WriteString("   dir : directory path to store all exported domain's yaml (or json) files\n")
+		buf.WriteString("       : each filename will be the domain name with a .yaml (or .json) extension\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   exports all domains including their non-active policy versions\n")
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   system-backup /home/athenz/var/backups/zms_data\n")
+	case "system-restore":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   system-restore dir [checkpoint-file]\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   dir             : directory path with the domain files generated by system-backup\n")
+		buf.WriteString("   checkpoint-file : file to record the successfully restored domains in.\n")
+		buf.WriteString("                   : default is " + restoreCheckpointFile + " in the backup directory\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   restores all domains from the backup directory with parent domains processed\n")
+		buf.WriteString("   before their sub-domains. missing domains are created and all roles, groups,\n")
+		buf.WriteString("   services, policies, tags and meta attributes are updated to match the backup.\n")
+		buf.WriteString("   non-active policy versions and the domain enabled flag are restored as well.\n")
+		buf.WriteString("   domains listed in the checkpoint file are skipped so the command can be\n")
+		buf.WriteString("   re-run to resume a restore that failed for some domains\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   system-restore /home/athenz/var/backups/zms_data\n")
 	case "list-server-template":
 		buf.WriteString(" syntax:\n")
 		buf.WriteString("   list-server-template\n")
@@ -2471,6 +3237,47 @@ func (cli Zms) HelpSpecificCommand(interactive bool, cmd string) string {
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   list-domain-template coretech.hosted\n")
 		buf.WriteString("   " + domainExample + " list-domain-template\n")
+	case "list-domain-template-details":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [-o json] list-domain-template-details domain\n")
+		buf.WriteString("   [-o json] " + domainParam + " list-domain-template-details\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   domain : retrieve details of the templates applied to this domain\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   lists the templates applied to the domain with their current and latest\n")
+		buf.WriteString("   versions and auto-update flag. templates with a newer version on the\n")
+		buf.WriteString("   server are marked with update-available\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   list-domain-template-details coretech.hosted\n")
+		buf.WriteString("   " + domainExample + " list-domain-template-details\n")
+	case "list-server-template-details":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [-o json] list-server-template-details\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   lists the solution templates defined on the server with their latest\n")
+		buf.WriteString("   versions, keywords to replace and auto-update flag\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   list-server-template-details\n")
+	case "preview-domain-template":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [-o json] " + domainParam + " preview-domain-template template [param-key=param-value ...] [--dry-run]\n")
+		buf.WriteString(" parameters:\n")
+		if !interactive {
+			buf.WriteString("   domain      : name of the domain to preview the template for\n")
+		}
+		buf.WriteString("   template    : name of the template to be applied to the domain\n")
+		buf.WriteString("   param-key   : optional parameter key name if template requires it\n")
+		buf.WriteString("   param-value : value for the specified parameter key\n")
+		buf.WriteString("   --dry-run   : only display the changes without applying the template\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   substitutes the _domain_ and _param-key_ keywords in the server template and\n")
+		buf.WriteString("   displays the roles, policies and services that would be created, along with\n")
+		buf.WriteString("   the members, assertions and attributes that would be added to existing ones.\n")
+		buf.WriteString("   once confirmed, the template is applied as with the set-domain-template\n")
+		buf.WriteString("   command. use the -y option to skip the confirmation\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   " + domainExample + " preview-domain-template vipng --dry-run\n")
+		buf.WriteString("   " + domainExample + " preview-domain-template aws_instance_launch_provider service=api\n")
 	case "set-domain-template":
 		buf.WriteString(" syntax:\n")
 		buf.WriteString("   " + domainParam + " set-domain-template template [template ...] [param-key=param-value ...]\n")
@@ -2517,6 +3324,27 @@ func (cli Zms) HelpSpecificCommand(interactive bool, cmd string) string {
 		}
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   " + domainExample + " get-quota\n")
+	case "quota-report":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [-o json] quota-report [domain|--all] [--threshold percent]\n")
+		buf.WriteString("   [-o json] " + domainParam + " quota-report [--threshold percent]\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   domain      : name of the domain to report quota usage for\n")
+		buf.WriteString("   --all       : report quota usage for all domains\n")
+		buf.WriteString("   --threshold : usage percentage at which a dimension is flagged (default 80)\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   compares the number of roles, role members, policies, assertions, services,\n")
+		buf.WriteString("   service hosts, public keys, entities, subdomains, groups and group members\n")
+		buf.WriteString("   in the domain against its quota and displays the used, limit and percent\n")
+		buf.WriteString("   values for each dimension. role-member, assertion, service-host, public-key\n")
+		buf.WriteString("   and group-member limits apply to each role, policy, service or group so\n")
+		buf.WriteString("   the usage of the largest object is displayed along with its name.\n")
+		buf.WriteString("   dimensions at or above the threshold are flagged.\n")
+		buf.WriteString("   with the --all option, only domains with flagged dimensions are displayed\n")
+		buf.WriteString("   along with the system wide object counts\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   quota-report coretech\n")
+		buf.WriteString("   -o json quota-report --all --threshold 90\n")
 	case "delete-quota":
 		buf.WriteString(" syntax:\n")
 		buf.WriteString("   [-o json] " + domainParam + " delete-quota\n")
@@ -2743,6 +3571,17 @@ func (cli Zms) HelpSpecificCommand(interactive bool, cmd string) string {
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   " + domainExample + " put-membership-decision readers " + cli.UserDomain + ".john true\n")
 		buf.WriteString("   " + domainExample + " put-membership-decision readers " + cli.UserDomain + ".john 2020-03-02T15:04:05.999Z true\n")
+	case "delete-pending-member":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   " + domainParam + " delete-pending-member role member\n")
+		buf.WriteString(" parameters:\n")
+		if !interactive {
+			buf.WriteString("   domain : name of the domain that role belongs to\n")
+		}
+		buf.WriteString("   role   : name of the role with the pending membership request\n")
+		buf.WriteString("   member : name of the member whose pending request is deleted\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   " + domainExample + " delete-pending-member readers " + cli.UserDomain + ".john\n")
 	case "set-group-audit-enabled":
 		buf.WriteString(" syntax:\n")
 		buf.WriteString("   " + domainParam + " set-group-audit-enabled group audit-enabled\n")
@@ -2860,6 +3699,192 @@ func (cli Zms) HelpSpecificCommand(interactive bool, cmd string) string {
 		buf.WriteString("   approval   : true/false depicting whether membership is approved or rejected\n")
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   " + domainExample + " put-group-membership-decision readers " + cli.UserDomain + ".john true\n")
+	case "delete-pending-group-member":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   " + domainParam + " delete-pending-group-member group member\n")
+		buf.WriteString(" parameters:\n")
+		if !interactive {
+			buf.WriteString("   domain : name of the domain that group belongs to\n")
+		}
+		buf.WriteString("   group  : name of the group with the pending membership request\n")
+		buf.WriteString("   member : name of the member whose pending request is deleted\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   " + domainExample + " delete-pending-group-member readers " + cli.UserDomain + ".john\n")
+	case "purge-pending-members":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [" + domainParam + "] purge-pending-members days [principal-pattern]\n")
+		buf.WriteString(" parameters:\n")
+		if !interactive {
+			buf.WriteString("   domain            : optional name of the domain to purge pending requests from\n")
+		}
+		buf.WriteString("   days              : only requests older than the given number of days are deleted.\n")
+		buf.WriteString("                     : a value of 0 selects requests regardless of their age\n")
+		buf.WriteString("   principal-pattern : optional shell pattern the pending member name must match\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   lists all matching pending role and group membership requests and\n")
+		buf.WriteString("   deletes them once confirmed. use the -y option to skip the confirmation\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   purge-pending-members 30\n")
+		buf.WriteString("   " + domainExample + " purge-pending-members 0 '" + cli.UserDomain + ".*'\n")
+	case "approve-pending", "reject-pending":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [-o json] " + cmd + " [--domain domain] [--role name] [--member-pattern pattern] [--older-than days] [--dry-run]\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   --domain         : only process requests for the given domain. if not\n")
+		buf.WriteString("                    : specified, the -d option domain is used if present\n")
+		buf.WriteString("   --role           : only process requests for the role or group with the given name\n")
+		buf.WriteString("   --member-pattern : shell pattern the pending member name must match\n")
+		buf.WriteString("   --older-than     : only process requests older than the given number of days\n")
+		buf.WriteString("   --dry-run        : only list the matching pending requests\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   lists all the matching pending role and group membership requests that\n")
+		buf.WriteString("   the caller can approve and once confirmed, submits the decision for each\n")
+		buf.WriteString("   of them. role requests with an expiration are processed as temporary\n")
+		buf.WriteString("   memberships. failed decisions are reported without stopping the remaining\n")
+		buf.WriteString("   requests. use the -y option to skip the confirmation\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   " + cmd + " --domain coretech --role readers --dry-run\n")
+		buf.WriteString("   -a TICKET-1234 " + cmd + " --member-pattern '" + cli.UserDomain + ".*' --older-than 7\n")
+	case "clone-domain":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   clone-domain src_domain dst_domain [--include roles,policies,services,groups] [--rewrite-domain] [--with-meta]\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   src_domain       : name of the domain to copy the objects from\n")
+		buf.WriteString("   dst_domain       : name of the existing domain to copy the objects to\n")
+		buf.WriteString("   --include        : comma separated list of object types to copy (default all)\n")
+		buf.WriteString("   --rewrite-domain : replace the source domain in service and group members\n")
+		buf.WriteString("                    : and delegated roles with the destination domain\n")
+		buf.WriteString("   --with-meta      : copy the role and group meta attributes and tags\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   copies the roles, policies, services and groups of the source domain into\n")
+		buf.WriteString("   the destination domain. role, group and policy names along with assertion\n")
+		buf.WriteString("   roles and resources are always rewritten to use the destination domain.\n")
+		buf.WriteString("   the admin role and policy are not copied and objects that already exist in\n")
+		buf.WriteString("   the destination domain are skipped\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   clone-domain coretech.prod coretech.stage --rewrite-domain\n")
+		buf.WriteString("   clone-domain coretech.prod coretech.dev --include roles,policies --with-meta\n")
+	case "copy-role":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   copy-role src_domain role dst_domain [--rewrite-domain] [--with-meta]\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   src_domain       : name of the domain the role belongs to\n")
+		buf.WriteString("   role             : name of the role to copy\n")
+		buf.WriteString("   dst_domain       : name of the domain to copy the role to\n")
+		buf.WriteString("   --rewrite-domain : replace the source domain in service and group members\n")
+		buf.WriteString("                    : and the delegated domain with the destination domain\n")
+		buf.WriteString("   --with-meta      : copy the role meta attributes and tags\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   copies the role along with its members into the destination domain\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   copy-role coretech.prod readers coretech.stage --rewrite-domain\n")
+	case "replace-member":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   replace-member old_member new_member [--domains domain] [--keep-expiry] [--dry-run] [--rollback file]\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   old_member    : user, service or group to be replaced\n")
+		buf.WriteString("   new_member    : user, service or group to replace the old member with\n")
+		buf.WriteString("   --domains     : only replace memberships in the given domain and its sub-domains\n")
+		buf.WriteString("   --keep-expiry : carry over the expiration and review dates of the old member\n")
+		buf.WriteString("   --dry-run     : only list the memberships that would be replaced\n")
+		buf.WriteString("   --rollback    : name of the rollback file (default replace-member-rollback-<time>.json)\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   finds all the roles and groups the old member belongs to and, once confirmed,\n")
+		buf.WriteString("   adds the new member and deletes the old member from each of them. if the\n")
+		buf.WriteString("   new member is already a member, only the old member is deleted. groups cannot\n")
+		buf.WriteString("   include other groups so those memberships are skipped when the new member\n")
+		buf.WriteString("   is a group. the changes use the audit reference given with the -a option or\n")
+		buf.WriteString("   a generated one if not specified. the planned memberships are stored in the\n")
+		buf.WriteString("   rollback file before any change is made and their status is updated after\n")
+		buf.WriteString("   each replacement. the file can be used with the rollback-replace-member command.\n")
+		buf.WriteString("   use the -y option to skip the confirmation\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   replace-member " + cli.UserDomain + ".alice " + cli.UserDomain + ".bob --dry-run\n")
+		buf.WriteString("   -a TICKET-1234 replace-member " + cli.UserDomain + ".alice coretech:group.devs --domains coretech --keep-expiry\n")
+	case "import-members":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [-o json] import-members file.csv [--concurrency N] [--retry-file file.csv]\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   file.csv      : csv file with domain, role|group, member, expiration, review\n")
+		buf.WriteString("                 : and action columns. groups are specified as group.name and\n")
+		buf.WriteString("                 : the action is either add (default) or delete\n")
+		buf.WriteString("   --concurrency : maximum number of concurrent requests (default 5)\n")
+		buf.WriteString("   --retry-file  : file where the failed rows are stored (default file.csv.retry)\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   adds or deletes the role and group members listed in the csv file. the header\n")
+		buf.WriteString("   line and the expiration, review and action columns are optional. the changes\n")
+		buf.WriteString("   use the audit reference given with the -a option. the result of each row is\n")
+		buf.WriteString("   reported and the failed rows are written to the retry file which can be\n")
+		buf.WriteString("   imported again once the problems are addressed\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   -a TICKET-1234 import-members members.csv --concurrency 10\n")
+		buf.WriteString("   import-members members.csv.retry\n")
+	case "export-members":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   export-members domain [file.csv] - no file means stdout\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   domain   : name of the domain to be exported\n")
+		buf.WriteString("   file.csv : filename where the members are stored\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   exports all the role and group members of the domain in the csv format\n")
+		buf.WriteString("   accepted by the import-members command. pending members and the members\n")
+		buf.WriteString("   of delegated roles are not exported\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   export-members coretech /tmp/coretech-members.csv\n")
+	case "rollback-replace-member":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   rollback-replace-member rollback_file\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   rollback_file : rollback file generated by the replace-member command\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   restores the old member with its original expiration and review dates in all\n")
+		buf.WriteString("   the replaced memberships and deletes the new member unless it was a member\n")
+		buf.WriteString("   before the replacement. the rollback file is updated so that only failed\n")
+		buf.WriteString("   memberships are processed if the command is executed again\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   rollback-replace-member replace-member-rollback-20260101T120000.json\n")
+	case "report-expiring-members":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [-o json|csv] report-expiring-members [--days N] [--prefix prefix] [--tag key=value]\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   --days   : report memberships expiring or due for review within the\n")
+		buf.WriteString("            : given number of days (default 30)\n")
+		buf.WriteString("   --prefix : only process domains with the given name prefix\n")
+		buf.WriteString("   --tag    : only process domains with the given tag key and optional value\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   walks all matching domains and lists every role and group membership that\n")
+		buf.WriteString("   expires or is due for review within the given number of days grouped by\n")
+		buf.WriteString("   domain and role along with the roles notified about the membership.\n")
+		buf.WriteString("   memberships that are overdue for review are included as well.\n")
+		buf.WriteString("   use -v to display the domains as they're processed\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   report-expiring-members\n")
+		buf.WriteString("   -o csv report-expiring-members --days 14 --prefix coretech\n")
+		buf.WriteString("   -o json report-expiring-members --tag env=prod\n")
+	case "list-meta-store-values", "list-meta-store-value":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   list-meta-store-values attribute [user]\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   attribute : domain meta attribute name. supported values are\n")
+		buf.WriteString("             : businessService, awsAccount, azureSubscription and productId\n")
+		buf.WriteString("   user      : optional user name to restrict the values to the ones\n")
+		buf.WriteString("             : the user is allowed to use\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   lists the valid values for the attribute in the server's domain meta store.\n")
+		buf.WriteString("   the set-business-service, set-aws-account, set-azure-subscription and\n")
+		buf.WriteString("   set-product-id commands only accept values from this list\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   list-meta-store-values businessService\n")
+		buf.WriteString("   list-meta-store-values awsAccount " + cli.UserDomain + ".john\n")
+	case "list-user-authority-attributes", "list-user-authority-attribute":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   list-user-authority-attributes\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   lists the user authority attributes supported by the server grouped by type.\n")
+		buf.WriteString("   bool attributes can be used as user authority filters while date attributes\n")
+		buf.WriteString("   can be used as user authority expiration attributes for domains, roles and groups\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   list-user-authority-attributes\n")
 	case "get-stats", "stats":
 		buf.WriteString(" syntax:\n")
 		buf.WriteString("   [-o json] get-stats [domain]\n")
@@ -2898,6 +3923,18 @@ func (cli Zms) HelpSpecificCommand(interactive bool, cmd string) string {
 		}
 		buf.WriteString(" examples:\n")
 		buf.WriteString("   " + domainExample + " get-dependent-service-list\n")
+	case "get-dependent-service-resource-groups":
+		buf.WriteString(" syntax:\n")
+		buf.WriteString("   [-o json] get-dependent-service-resource-groups domain\n")
+		buf.WriteString("   [-o json] " + domainParam + " get-dependent-service-resource-groups\n")
+		buf.WriteString(" parameters:\n")
+		buf.WriteString("   domain : name of the domain\n")
+		buf.WriteString(" description:\n")
+		buf.WriteString("   lists the provider services the domain depends on along with the\n")
+		buf.WriteString("   resource groups the domain has with each provider\n")
+		buf.WriteString(" examples:\n")
+		buf.WriteString("   get-dependent-service-resource-groups coretech\n")
+		buf.WriteString("   " + domainExample + " get-dependent-service-resource-groups\n")
 	case "get-dependent-domain-list":
 		buf.WriteString(" syntax:\n")
 		buf.WriteString("    get-dependent-domain-list service\n")
@@ -2949,8 +3986,19 @@ func (cli Zms) HelpListCommand() string {
 	buf.WriteString("   set-domain-user-authority-filter filter\n")
 	buf.WriteString("   import-domain domain [file.yaml [admin ...]] - no file means stdin\n")
 	buf.WriteString("   export-domain domain [file.yaml] - no file means stdout\n")
-	buf.WriteString("   delete-domain domain\n")
-	buf.WriteString("   get-signed-domains [matching_tag]\n")
+	buf.WriteString("   import-members file.csv [--concurrency N] [--retry-file file.csv]\n")
+	buf.WriteString("   export-members domain [file.csv] - no file means stdout\n")
+	buf.WriteString("   diff-domain file.yaml [other-file.yaml]\n")
+	buf.WriteString("   plan-domain file.yaml\n")
+	buf.WriteString("   apply-domain file.yaml\n")
+	buf.WriteString("   clone-domain src_domain dst_domain [--include roles,policies,services,groups] [--rewrite-domain]\n")
+	buf.WriteString("   graph-domain domain [--depth N] [--format dot|mermaid|json]\n")
+	buf.WriteString("   lint-domain file.yaml [--allowed-domains domain[,domain...]]\n")
+	buf.WriteString("   simulate-access file.yaml matrix-file\n")
+	buf.WriteString("   delete-domain domain [--force]\n")
+	buf.WriteString("   get-signed-domains [matching_tag] [--verify]\n")
+	buf.WriteString("   watch-domains [--prefix prefix] [--interval seconds] [--exec command] [--webhook url]\n")
+	buf.WriteString("   get-jws-domain domain [--verify]\n")
 	buf.WriteString("   use-domain [domain]\n")
 	buf.WriteString("   check-domain [domain]\n")
 	buf.WriteString("   add-domain-tag tag_key tag_value [tag_value ...]\n")
@@ -2958,11 +4006,13 @@ func (cli Zms) HelpListCommand() string {
 	buf.WriteString("   get-quota\n")
 	buf.WriteString("   set-quota [attrs ...]\n")
 	buf.WriteString("   delete-quota\n")
+	buf.WriteString("   quota-report [domain|--all] [--threshold percent]\n")
 	buf.WriteString("   overdue-review [domain]\n")
 	buf.WriteString("   get-stats [domain]\n")
 	buf.WriteString("\n")
 	buf.WriteString(" Dependency commands:\n")
 	buf.WriteString("   get-dependent-service-list\n")
+	buf.WriteString("   get-dependent-service-resource-groups [domain]\n")
 	buf.WriteString("   get-dependent-domain-list service\n")
 	buf.WriteString("   put-domain-dependency service\n")
 	buf.WriteString("   delete-domain-dependency service\n")
@@ -2978,11 +4028,18 @@ func (cli Zms) HelpListCommand() string {
 	buf.WriteString("   add-assertion-policy-version policy version assertion [is_case_sensitive]\n")
 	buf.WriteString("   delete-assertion policy assertion\n")
 	buf.WriteString("   delete-assertion-policy-version policy assertion\n")
+	buf.WriteString("   list-assertion-conditions policy assertion-id|assertion\n")
+	buf.WriteString("   add-assertion-condition policy assertion-id|assertion key operator value [key operator value ...]\n")
+	buf.WriteString("   delete-assertion-condition policy assertion-id|assertion condition-id\n")
+	buf.WriteString("   delete-assertion-conditions policy assertion-id|assertion\n")
 	buf.WriteString("   delete-policy policy\n")
 	buf.WriteString("   delete-policy-version policy version\n")
+	buf.WriteString("   diff-policy-version policy version1 version2\n")
+	buf.WriteString("   promote-policy-version policy version [--check matrix-file]\n")
 	buf.WriteString("   set-active-policy-version policy version\n")
 	buf.WriteString("   show-access action resource [alt_identity [trust_domain]]\n")
 	buf.WriteString("   show-access-ext action resource [alt_identity [trust_domain]]\n")
+	buf.WriteString("   explain-access action resource [principal]\n")
 	buf.WriteString("   show-resource principal action\n")
 	buf.WriteString("\n")
 	buf.WriteString(" Role commands:\n")
@@ -2998,11 +4055,13 @@ func (cli Zms) HelpListCommand() string {
 	buf.WriteString("   check-member regular_role user_or_service [user_or_service ...]\n")
 	buf.WriteString("   check-active-member regular_role user_or_service\n")
 	buf.WriteString("   delete-member regular_role user_or_service [user_or_service ...]\n")
+	buf.WriteString("   review-role regular_role [review-file.yaml | extend|extend-all days [member ...]]\n")
 	buf.WriteString("   add-provider-role-member provider_service resource_group provider_role user_or_service [user_or_service ...]\n")
 	buf.WriteString("   show-provider-role-member provider_service resource_group provider_role\n")
 	buf.WriteString("   delete-provider-role-member provider_service resource_group provider_role user_or_service [user_or_service ...]\n")
 	buf.WriteString("   list-domain-role-members\n")
 	buf.WriteString("   delete-domain-role-member member\n")
+	buf.WriteString("   copy-role src_domain role dst_domain [--rewrite-domain]\n")
 	buf.WriteString("   delete-role role\n")
 	buf.WriteString("   set-role-audit-enabled regular_role audit-enabled\n")
 	buf.WriteString("   set-role-review-enabled regular_role review-enabled\n")
@@ -3022,6 +4081,7 @@ func (cli Zms) HelpListCommand() string {
 	buf.WriteString("   add-role-tag regular_role tag_key tag_value [tag_value ...]\n")
 	buf.WriteString("   delete-role-tag regular_role tag_key [tag_value]\n")
 	buf.WriteString("   put-membership-decision regular_role user_or_service [expiration] decision\n")
+	buf.WriteString("   delete-pending-member regular_role user_or_service\n")
 	buf.WriteString("\n")
 	buf.WriteString(" Group commands:\n")
 	buf.WriteString("   list-group\n")
@@ -3033,6 +4093,7 @@ func (cli Zms) HelpListCommand() string {
 	buf.WriteString("   check-group-member group user_or_service [user_or_service ...]\n")
 	buf.WriteString("   check-active-group-member group user_or_service\n")
 	buf.WriteString("   delete-group-member group user_or_service [user_or_service ...]\n")
+	buf.WriteString("   review-group group [review-file.yaml | extend|extend-all days [member ...]]\n")
 	buf.WriteString("   list-domain-group-members\n")
 	buf.WriteString("   delete-group group\n")
 	buf.WriteString("   set-group-audit-enabled group audit-enabled\n")
@@ -3046,6 +4107,7 @@ func (cli Zms) HelpListCommand() string {
 	buf.WriteString("   add-group-tag group tag_key tag_value [tag_value ...]\n")
 	buf.WriteString("   delete-group-tag group tag_key [tag_value]\n")
 	buf.WriteString("   put-group-membership-decision group user_or_service [expiration] decision\n")
+	buf.WriteString("   delete-pending-group-member group user_or_service\n")
 	buf.WriteString("\n")
 	buf.WriteString(" Service commands:\n")
 	buf.WriteString("   list-service\n")
@@ -3071,7 +4133,7 @@ func (cli Zms) HelpListCommand() string {
 	buf.WriteString("   add-tenant provider_service tenant_domain\n")
 	buf.WriteString("   delete-tenant provider_service tenant_domain\n")
 	buf.WriteString("   add-tenancy provider [create_admin_role]\n")
-	buf.WriteString("   delete-tenancy provider\n")
+	buf.WriteString("   delete-tenancy provider [--force]\n")
 	buf.WriteString("   show-tenant-resource-group-roles service tenant_domain resource_group\n")
 	buf.WriteString("   add-tenant-resource-group-roles service tenant_domain resource_group role=action [role=action ...]\n")
 	buf.WriteString("   delete-tenant-resource-group-roles service tenant_domain resource_group\n")
@@ -3083,6 +4145,9 @@ func (cli Zms) HelpListCommand() string {
 	buf.WriteString("   list-server-template\n")
 	buf.WriteString("   list-domain-template\n")
 	buf.WriteString("   show-server-template template\n")
+	buf.WriteString("   list-domain-template-details\n")
+	buf.WriteString("   list-server-template-details\n")
+	buf.WriteString("   preview-domain-template template [param-key=param-value ...] [--dry-run]\n")
 	buf.WriteString("   set-domain-template template [template ...] [param-key=param-value ...]\n")
 	buf.WriteString("   delete-domain-template template\n")
 	buf.WriteString("\n")
@@ -3090,8 +4155,12 @@ func (cli Zms) HelpListCommand() string {
 	buf.WriteString("   set-default-admins domain admin [admin ...]\n")
 	buf.WriteString("   list-user [domain]\n")
 	buf.WriteString("   delete-user user\n")
+	buf.WriteString("   replace-member old_member new_member [--domains domain] [--keep-expiry] [--dry-run]\n")
+	buf.WriteString("   rollback-replace-member rollback_file\n")
 	buf.WriteString("   disable-domain [domain]\n")
 	buf.WriteString("   enable-domain [domain]\n")
+	buf.WriteString("   system-backup dir\n")
+	buf.WriteString("   system-restore dir [checkpoint-file]\n")
 	buf.WriteString("\n")
 	buf.WriteString(" Other commands:\n")
 	buf.WriteString("   get-user-token [authorized_service]\n")
@@ -3099,6 +4168,13 @@ func (cli Zms) HelpListCommand() string {
 	buf.WriteString("   list-pending-domain-role-members\n")
 	buf.WriteString("   list-pending-group-members\n")
 	buf.WriteString("   list-pending-domain-group-members\n")
+	buf.WriteString("   approve-pending [--domain domain] [--role name] [--member-pattern pattern] [--older-than days] [--dry-run]\n")
+	buf.WriteString("   reject-pending [--domain domain] [--role name] [--member-pattern pattern] [--older-than days] [--dry-run]\n")
+	buf.WriteString("   purge-pending-members days [principal-pattern]\n")
+	buf.WriteString("   report-expiring-members [--days N] [--prefix prefix] [--tag key=value]\n")
+	buf.WriteString("   list-meta-store-values attribute [user]\n")
+	buf.WriteString("   list-user-authority-attributes\n")
+	buf.WriteString("   shell\n")
 	buf.WriteString("   version\n")
 	buf.WriteString("\n")
 	return buf.String()
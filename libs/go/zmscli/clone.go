@@ -0,0 +1,396 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+const (
+	cloneRoles    = "roles"
+	clonePolicies = "policies"
+	cloneServices = "services"
+	cloneGroups   = "groups"
+)
+
+var cloneObjectTypes = []string{cloneRoles, clonePolicies, cloneServices, cloneGroups}
+
+// domainCloner copies the objects of the source domain into the
+// destination domain rewriting all names that are qualified with
+// the source domain name
+type domainCloner struct {
+	src      string
+	dst      string
+	rewrite  bool
+	withMeta bool
+}
+
+// rewriteName replaces the source domain in the domain:entity names
+// such as role names and assertion resources with the destination domain
+func (c *domainCloner) rewriteName(name string) string {
+	if strings.HasPrefix(name, c.src+":") {
+		return c.dst + name[len(c.src):]
+	}
+	return name
+}
+
+// rewriteMember replaces the source domain in the service and group
+// member names only if the rewrite option is enabled. Members from
+// sub domains of the source domain are left as is.
+func (c *domainCloner) rewriteMember(member string) string {
+	if !c.rewrite {
+		return member
+	}
+	if strings.Contains(member, ":group.") {
+		return c.rewriteName(member)
+	}
+	if principalDomain(member) == c.src {
+		return c.dst + member[len(c.src):]
+	}
+	return member
+}
+
+func (c *domainCloner) cloneRole(role *zms.Role) *zms.Role {
+	clone := zms.Role{
+		Name:        zms.ResourceName(c.rewriteName(fullRoleName(c.src, string(role.Name)))),
+		Trust:       role.Trust,
+		RoleMembers: make([]*zms.RoleMember, 0),
+	}
+	if c.withMeta {
+		meta := getRoleMetaObject(role)
+		clone.MemberExpiryDays = meta.MemberExpiryDays
+		clone.TokenExpiryMins = meta.TokenExpiryMins
+		clone.SelfServe = meta.SelfServe
+		clone.CertExpiryMins = meta.CertExpiryMins
+		clone.SignAlgorithm = meta.SignAlgorithm
+		clone.ReviewEnabled = meta.ReviewEnabled
+		clone.NotifyRoles = meta.NotifyRoles
+		clone.ServiceExpiryDays = meta.ServiceExpiryDays
+		clone.GroupExpiryDays = meta.GroupExpiryDays
+		clone.MemberReviewDays = meta.MemberReviewDays
+		clone.ServiceReviewDays = meta.ServiceReviewDays
+		clone.GroupReviewDays = role.GroupReviewDays
+		clone.UserAuthorityExpiration = meta.UserAuthorityExpiration
+		clone.UserAuthorityFilter = meta.UserAuthorityFilter
+		clone.Tags = meta.Tags
+	}
+	if c.rewrite && string(role.Trust) == c.src {
+		clone.Trust = zms.DomainName(c.dst)
+	}
+	for _, member := range role.RoleMembers {
+		if isPendingMember(member.Approved) {
+			continue
+		}
+		clone.RoleMembers = append(clone.RoleMembers, &zms.RoleMember{
+			MemberName:     zms.MemberName(c.rewriteMember(string(member.MemberName))),
+			Expiration:     member.Expiration,
+			ReviewReminder: member.ReviewReminder,
+		})
+	}
+	return &clone
+}
+
+func (c *domainCloner) cloneGroup(group *zms.Group) *zms.Group {
+	clone := zms.Group{
+		Name:         zms.ResourceName(c.rewriteName(string(group.Name))),
+		GroupMembers: make([]*zms.GroupMember, 0),
+	}
+	if c.withMeta {
+		meta := getGroupMetaObject(group)
+		clone.SelfServe = meta.SelfServe
+		clone.ReviewEnabled = meta.ReviewEnabled
+		clone.NotifyRoles = meta.NotifyRoles
+		clone.UserAuthorityExpiration = meta.UserAuthorityExpiration
+		clone.UserAuthorityFilter = meta.UserAuthorityFilter
+		clone.MemberExpiryDays = meta.MemberExpiryDays
+		clone.ServiceExpiryDays = meta.ServiceExpiryDays
+		clone.Tags = meta.Tags
+	}
+	for _, member := range group.GroupMembers {
+		if isPendingMember(member.Approved) {
+			continue
+		}
+		clone.GroupMembers = append(clone.GroupMembers, &zms.GroupMember{
+			MemberName: zms.GroupMemberName(c.rewriteMember(string(member.MemberName))),
+			Expiration: member.Expiration,
+		})
+	}
+	return &clone
+}
+
+func (c *domainCloner) clonePolicy(policy *zms.Policy) *zms.Policy {
+	clone := zms.Policy{
+		Name:       zms.ResourceName(c.rewriteName(fullPolicyName(c.src, string(policy.Name)))),
+		Assertions: make([]*zms.Assertion, 0),
+	}
+	for _, assertion := range policy.Assertions {
+		clone.Assertions = append(clone.Assertions, &zms.Assertion{
+			Role:          c.rewriteName(fullRoleName(c.src, assertion.Role)),
+			Resource:      c.rewriteName(fullResourceName(c.src, assertion.Resource)),
+			Action:        assertion.Action,
+			Effect:        assertion.Effect,
+			CaseSensitive: assertion.CaseSensitive,
+			Conditions:    assertion.Conditions,
+		})
+	}
+	return &clone
+}
+
+func (c *domainCloner) cloneService(service *zms.ServiceIdentity) *zms.ServiceIdentity {
+	clone := zms.ServiceIdentity{
+		Name:             zms.ServiceName(c.dst + "." + shortname(c.src, string(service.Name))),
+		PublicKeys:       service.PublicKeys,
+		ProviderEndpoint: service.ProviderEndpoint,
+		Executable:       service.Executable,
+		User:             service.User,
+		Group:            service.Group,
+		Hosts:            service.Hosts,
+	}
+	return &clone
+}
+
+// cloneDomainData returns the domain data for the destination domain
+// with copies of the requested object types. The admin role and policy
+// are not cloned since the destination domain already has its own.
+func (c *domainCloner) cloneDomainData(src *zms.DomainData, include []string) *zms.DomainData {
+	clone := &zms.DomainData{
+		Name:     zms.DomainName(c.dst),
+		Roles:    make([]*zms.Role, 0),
+		Groups:   make([]*zms.Group, 0),
+		Services: make([]*zms.ServiceIdentity, 0),
+		Policies: &zms.SignedPolicies{
+			Contents: &zms.DomainPolicies{
+				Domain:   zms.DomainName(c.dst),
+				Policies: make([]*zms.Policy, 0),
+			},
+		},
+	}
+	if indexOfString(include, cloneRoles) >= 0 {
+		for _, role := range src.Roles {
+			if localName(string(role.Name), ":role.") != "admin" {
+				clone.Roles = append(clone.Roles, c.cloneRole(role))
+			}
+		}
+	}
+	if indexOfString(include, cloneGroups) >= 0 {
+		for _, group := range src.Groups {
+			clone.Groups = append(clone.Groups, c.cloneGroup(group))
+		}
+	}
+	if indexOfString(include, cloneServices) >= 0 {
+		for _, service := range src.Services {
+			clone.Services = append(clone.Services, c.cloneService(service))
+		}
+	}
+	if indexOfString(include, clonePolicies) >= 0 {
+		for _, policy := range domainPolicies(src) {
+			if localName(string(policy.Name), ":policy.") != "admin" {
+				clone.Policies.Contents.Policies = append(clone.Policies.Contents.Policies, c.clonePolicy(policy))
+			}
+		}
+	}
+	return clone
+}
+
+// parseCloneInclude validates the comma separated list of object types
+func parseCloneInclude(value string) ([]string, error) {
+	include := strings.Split(value, ",")
+	for _, objectType := range include {
+		if indexOfString(cloneObjectTypes, objectType) < 0 {
+			return nil, invalidValueError("object type", objectType, cloneObjectTypes)
+		}
+	}
+	return include, nil
+}
+
+// splitNewRoles splits the roles into the ones that are not in the given
+// list of existing role names and the ones that already exist
+func splitNewRoles(roles []*zms.Role, existing []zms.EntityName) ([]*zms.Role, []*zms.Role) {
+	existingRoles := make(map[string]bool)
+	for _, name := range existing {
+		existingRoles[string(name)] = true
+	}
+	created := make([]*zms.Role, 0)
+	skipped := make([]*zms.Role, 0)
+	for _, role := range roles {
+		if existingRoles[localName(string(role.Name), ":role.")] {
+			skipped = append(skipped, role)
+		} else {
+			created = append(created, role)
+		}
+	}
+	return created, skipped
+}
+
+// importNewRoles creates the roles that don't exist in the destination domain
+// and returns them so that only the created roles are updated with the meta
+// attributes. Existing roles are skipped and left unchanged.
+func (cli Zms) importNewRoles(dn string, roles []*zms.Role, skipErrors bool) ([]*zms.Role, error) {
+	existing, err := cli.Zms.GetRoleList(zms.DomainName(dn), nil, "")
+	if err != nil {
+		return nil, err
+	}
+	created, skipped := splitNewRoles(roles, existing.Names)
+	for _, role := range skipped {
+		err = fmt.Errorf("role already exists: %s", role.Name)
+		if shouldReportError(skipErrors, cli.SkipErrors, err) {
+			return nil, err
+		}
+	}
+	return created, cli.importRoles(dn, created, nil, skipErrors)
+}
+
+// importRoleMeta sets the meta attributes and tags of the cloned roles
+// since the import helpers only create the roles with their members
+func (cli Zms) importRoleMeta(dn string, roles []*zms.Role, skipErrors bool) error {
+	for _, role := range roles {
+		rn := localName(string(role.Name), ":role.")
+		meta := getRoleMetaObject(role)
+		meta.GroupReviewDays = role.GroupReviewDays
+		err := cli.Zms.PutRoleMeta(zms.DomainName(dn), zms.EntityName(rn), cli.AuditRef, &meta)
+		if shouldReportError(skipErrors, cli.SkipErrors, err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// importGroups creates the groups that don't exist in the destination
+// domain and returns them. Review enabled groups cannot be created with
+// members so the meta attributes are set separately with importGroupMeta.
+func (cli Zms) importGroups(dn string, groups []*zms.Group, skipErrors bool) ([]*zms.Group, error) {
+	created := make([]*zms.Group, 0)
+	for _, group := range groups {
+		gn := localName(string(group.Name), ":group.")
+		_, _ = fmt.Fprintf(os.Stdout, "Processing group "+gn+"...\n")
+		_, err := cli.Zms.GetGroup(zms.DomainName(dn), zms.EntityName(gn), nil, nil)
+		if err == nil {
+			err = fmt.Errorf("group already exists: %s", group.Name)
+		} else {
+			newGroup := *group
+			newGroup.ReviewEnabled = nil
+			err = cli.Zms.PutGroup(zms.DomainName(dn), zms.EntityName(gn), cli.AuditRef, &newGroup)
+			if err == nil {
+				created = append(created, group)
+			}
+		}
+		if shouldReportError(skipErrors, cli.SkipErrors, err) {
+			return nil, err
+		}
+	}
+	return created, nil
+}
+
+// importGroupMeta sets the meta attributes and tags of the cloned groups
+func (cli Zms) importGroupMeta(dn string, groups []*zms.Group, skipErrors bool) error {
+	for _, group := range groups {
+		gn := localName(string(group.Name), ":group.")
+		meta := getGroupMetaObject(group)
+		err := cli.Zms.PutGroupMeta(zms.DomainName(dn), zms.EntityName(gn), cli.AuditRef, &meta)
+		if shouldReportError(skipErrors, cli.SkipErrors, err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// importClonedObjects creates the cloned objects in the destination domain.
+// Groups are created before roles since they can be role members and services
+// before policies so that any assume_role or tenancy references are valid.
+func (cli Zms) importClonedObjects(clone *zms.DomainData, withMeta bool, skipErrors bool) error {
+	dn := string(clone.Name)
+	// skip displaying each object after it's created
+	cli.Bulkmode = true
+
+	groups, err := cli.importGroups(dn, clone.Groups, skipErrors)
+	if err != nil {
+		return err
+	}
+	if withMeta {
+		err = cli.importGroupMeta(dn, groups, skipErrors)
+		if err != nil {
+			return err
+		}
+	}
+	err = cli.importServices(dn, clone.Services, skipErrors)
+	if err != nil {
+		return err
+	}
+	roles, err := cli.importNewRoles(dn, clone.Roles, skipErrors)
+	if err != nil {
+		return err
+	}
+	if withMeta {
+		err = cli.importRoleMeta(dn, roles, skipErrors)
+		if err != nil {
+			return err
+		}
+	}
+	return cli.importPolicies(dn, clone.Policies.Contents.Policies, skipErrors)
+}
+
+// CloneDomain copies the roles, policies, services and groups of the source
+// domain into an existing destination domain. Names qualified with the source
+// domain are rewritten to use the destination domain and if requested, so are
+// the service and group members from the source domain. Objects that already
+// exist in the destination domain are skipped.
+func (cli Zms) CloneDomain(src, dst string, include []string, rewrite, withMeta bool) (*string, error) {
+	if src == dst {
+		return nil, fmt.Errorf("source and destination domains must be different")
+	}
+	_, err := cli.Zms.GetDomain(zms.DomainName(dst))
+	if err != nil {
+		return nil, fmt.Errorf("unable to get destination domain %s: %v", dst, err)
+	}
+	domainData, err := cli.liveDomainData(src)
+	if err != nil {
+		return nil, err
+	}
+	cloner := domainCloner{src: src, dst: dst, rewrite: rewrite, withMeta: withMeta}
+	clone := cloner.cloneDomainData(domainData, include)
+	err = cli.importClonedObjects(clone, withMeta, true)
+	if err != nil {
+		return nil, err
+	}
+	message := SuccessMessage{
+		Status:  200,
+		Message: "[cloned " + strings.Join(include, ",") + " from domain '" + src + "' to '" + dst + "' successfully]",
+	}
+	return cli.dumpByFormat(message, cli.buildYAMLOutput)
+}
+
+// CopyRole copies the role with its members into the destination domain
+func (cli Zms) CopyRole(src, rn, dst string, rewrite, withMeta bool) (*string, error) {
+	if src == dst {
+		return nil, fmt.Errorf("source and destination domains must be different")
+	}
+	if rn == "admin" {
+		return nil, fmt.Errorf("cannot copy reserved 'admin' role")
+	}
+	role, err := cli.Zms.GetRole(zms.DomainName(src), zms.EntityName(rn), nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	cloner := domainCloner{src: src, dst: dst, rewrite: rewrite, withMeta: withMeta}
+	cli.Bulkmode = true
+	roles, err := cli.importNewRoles(dst, []*zms.Role{cloner.cloneRole(role)}, false)
+	if err != nil {
+		return nil, err
+	}
+	if withMeta {
+		err = cli.importRoleMeta(dst, roles, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	message := SuccessMessage{
+		Status:  200,
+		Message: "[copied role " + rn + " from domain '" + src + "' to '" + dst + "' successfully]",
+	}
+	return cli.dumpByFormat(message, cli.buildYAMLOutput)
+}
@@ -0,0 +1,146 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+func testCloneDomain() *zms.DomainData {
+	allow := zms.ALLOW
+	approved := false
+	days := int32(30)
+	return &zms.DomainData{
+		Name: "coretech.prod",
+		Roles: []*zms.Role{
+			{Name: "coretech.prod:role.admin", RoleMembers: []*zms.RoleMember{{MemberName: "user.jane"}}},
+			{
+				Name:             "coretech.prod:role.readers",
+				MemberExpiryDays: &days,
+				RoleMembers: []*zms.RoleMember{
+					{MemberName: "user.john"},
+					{MemberName: "coretech.prod.api"},
+					{MemberName: "coretech.prod.sub.api"},
+					{MemberName: "coretech.prod:group.devs"},
+					{MemberName: "user.joe", Approved: &approved},
+				},
+			},
+			{Name: "coretech.prod:role.local", Trust: "coretech.prod"},
+		},
+		Groups: []*zms.Group{
+			{Name: "coretech.prod:group.devs", GroupMembers: []*zms.GroupMember{{MemberName: "coretech.prod.backend"}}},
+		},
+		Services: []*zms.ServiceIdentity{
+			{Name: "coretech.prod.api", ProviderEndpoint: "https://localhost:4443"},
+		},
+		Policies: &zms.SignedPolicies{
+			Contents: &zms.DomainPolicies{
+				Domain: "coretech.prod",
+				Policies: []*zms.Policy{
+					{
+						Name: "coretech.prod:policy.admin",
+						Assertions: []*zms.Assertion{
+							{Role: "coretech.prod:role.admin", Action: "*", Resource: "coretech.prod:*", Effect: &allow},
+						},
+					},
+					{
+						Name: "coretech.prod:policy.readers",
+						Assertions: []*zms.Assertion{
+							{Role: "coretech.prod:role.readers", Action: "read", Resource: "coretech.prod:articles.*", Effect: &allow},
+							{Role: "coretech.prod:role.readers", Action: "assume_role", Resource: "sports:role.readers", Effect: &allow},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestCloneDomainData(t *testing.T) {
+	cloner := domainCloner{src: "coretech.prod", dst: "coretech.stage"}
+	clone := cloner.cloneDomainData(testCloneDomain(), cloneObjectTypes)
+
+	if clone.Name != "coretech.stage" || len(clone.Roles) != 2 || len(clone.Groups) != 1 || len(clone.Services) != 1 {
+		t.Fatalf("unexpected cloned domain: %d roles %d groups %d services", len(clone.Roles), len(clone.Groups), len(clone.Services))
+	}
+	role := clone.Roles[0]
+	if role.Name != "coretech.stage:role.readers" || role.MemberExpiryDays != nil {
+		t.Errorf("unexpected cloned role: %s", role.Name)
+	}
+	// pending members are not copied and without the rewrite option
+	// the members are not modified
+	if len(role.RoleMembers) != 4 || role.RoleMembers[1].MemberName != "coretech.prod.api" || role.RoleMembers[3].MemberName != "coretech.prod:group.devs" {
+		t.Errorf("unexpected cloned role members: %v", role.RoleMembers)
+	}
+	if clone.Roles[1].Trust != "coretech.prod" {
+		t.Errorf("unexpected trust domain: %s", clone.Roles[1].Trust)
+	}
+	if clone.Services[0].Name != "coretech.stage.api" || clone.Services[0].ProviderEndpoint != "https://localhost:4443" {
+		t.Errorf("unexpected cloned service: %v", clone.Services[0])
+	}
+	policies := clone.Policies.Contents.Policies
+	if len(policies) != 1 || policies[0].Name != "coretech.stage:policy.readers" {
+		t.Fatalf("unexpected cloned policies: %v", policies)
+	}
+	assertion := policies[0].Assertions[0]
+	if assertion.Role != "coretech.stage:role.readers" || assertion.Resource != "coretech.stage:articles.*" {
+		t.Errorf("unexpected cloned assertion: %s", assertionString("coretech.stage", assertion))
+	}
+	if policies[0].Assertions[1].Resource != "sports:role.readers" {
+		t.Errorf("unexpected cloned assume_role resource: %s", policies[0].Assertions[1].Resource)
+	}
+}
+
+func TestCloneDomainDataRewrite(t *testing.T) {
+	cloner := domainCloner{src: "coretech.prod", dst: "coretech.stage", rewrite: true, withMeta: true}
+	clone := cloner.cloneDomainData(testCloneDomain(), []string{cloneRoles, cloneGroups})
+
+	if len(clone.Services) != 0 || len(clone.Policies.Contents.Policies) != 0 {
+		t.Errorf("only roles and groups must be cloned")
+	}
+	role := clone.Roles[0]
+	if role.MemberExpiryDays == nil || *role.MemberExpiryDays != 30 {
+		t.Error("role meta must be copied")
+	}
+	expected := []string{"user.john", "coretech.stage.api", "coretech.prod.sub.api", "coretech.stage:group.devs"}
+	for i, member := range role.RoleMembers {
+		if string(member.MemberName) != expected[i] {
+			t.Errorf("unexpected member %d: %s", i, member.MemberName)
+		}
+	}
+	if clone.Roles[1].Trust != "coretech.stage" {
+		t.Errorf("unexpected trust domain: %s", clone.Roles[1].Trust)
+	}
+	group := clone.Groups[0]
+	if group.Name != "coretech.stage:group.devs" || group.GroupMembers[0].MemberName != "coretech.stage.backend" {
+		t.Errorf("unexpected cloned group: %v", group)
+	}
+}
+
+func TestParseCloneInclude(t *testing.T) {
+	include, err := parseCloneInclude("roles,groups")
+	if err != nil || len(include) != 2 {
+		t.Errorf("unexpected include result: %v %v", include, err)
+	}
+	if _, err = parseCloneInclude("roles,polices"); err == nil {
+		t.Error("expected error for invalid object type")
+	}
+}
+
+func TestSplitNewRoles(t *testing.T) {
+	roles := []*zms.Role{
+		{Name: "weather:role.readers"},
+		{Name: "weather:role.writers"},
+		{Name: "weather:role.deployers"},
+	}
+	created, skipped := splitNewRoles(roles, []zms.EntityName{"admin", "writers"})
+	if len(created) != 2 || created[0].Name != "weather:role.readers" || created[1].Name != "weather:role.deployers" {
+		t.Errorf("unexpected created roles: %v", created)
+	}
+	if len(skipped) != 1 || skipped[0].Name != "weather:role.writers" {
+		t.Errorf("unexpected skipped roles: %v", skipped)
+	}
+}
@@ -0,0 +1,679 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+const (
+	diffActionAdd    = "add"
+	diffActionUpdate = "update"
+	diffActionDelete = "delete"
+
+	diffObjectDomain  = "domain"
+	diffObjectService = "service"
+	diffObjectGroup   = "group"
+	diffObjectRole    = "role"
+	diffObjectPolicy  = "policy"
+)
+
+// DomainAttributeDiff describes a single attribute change within an object.
+type DomainAttributeDiff struct {
+	Action    string `json:"action"`
+	Attribute string `json:"attribute"`
+	Value     string `json:"value,omitempty"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+}
+
+// DomainObjectDiff describes an object that must be added, updated
+// or deleted along with its individual attribute changes.
+type DomainObjectDiff struct {
+	Action     string                 `json:"action"`
+	ObjectType string                 `json:"type"`
+	Name       string                 `json:"name"`
+	Changes    []*DomainAttributeDiff `json:"changes,omitempty"`
+}
+
+// DomainDiff is the list of changes required to transform one
+// domain into another.
+type DomainDiff struct {
+	Domain  string              `json:"domain"`
+	Objects []*DomainObjectDiff `json:"objects"`
+}
+
+func (diff *DomainDiff) count(action string) int {
+	count := 0
+	for _, object := range diff.Objects {
+		if object.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+func (object *DomainObjectDiff) hasChange(attribute string) bool {
+	for _, change := range object.Changes {
+		if change.Attribute == attribute {
+			return true
+		}
+	}
+	return false
+}
+
+// hasChanges returns true if any of the given attributes has changed
+func (object *DomainObjectDiff) hasChanges(attributes []string) bool {
+	for _, attribute := range attributes {
+		if object.hasChange(attribute) {
+			return true
+		}
+	}
+	return false
+}
+
+func (object *DomainObjectDiff) addChange(action, attribute, value, from, to string) {
+	object.Changes = append(object.Changes, &DomainAttributeDiff{
+		Action:    action,
+		Attribute: attribute,
+		Value:     value,
+		From:      from,
+		To:        to,
+	})
+}
+
+func (object *DomainObjectDiff) diffString(attribute, from, to string) {
+	if from != to {
+		object.addChange(diffActionUpdate, attribute, "", from, to)
+	}
+}
+
+func boolString(value *bool) string {
+	if value == nil {
+		return "false"
+	}
+	return strconv.FormatBool(*value)
+}
+
+func int32String(value *int32) string {
+	if value == nil {
+		return "0"
+	}
+	return strconv.Itoa(int(*value))
+}
+
+func tagValuesString(tagValues *zms.TagValueList) string {
+	if tagValues == nil {
+		return ""
+	}
+	values := make([]string, 0)
+	for _, value := range tagValues.List {
+		values = append(values, string(value))
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+func (object *DomainObjectDiff) diffTags(current, desired map[zms.CompoundName]*zms.TagValueList) {
+	keys := make([]string, 0)
+	for key := range current {
+		keys = append(keys, string(key))
+	}
+	for key := range desired {
+		if _, ok := current[key]; !ok {
+			keys = append(keys, string(key))
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		currentValues, inCurrent := current[zms.CompoundName(key)]
+		desiredValues, inDesired := desired[zms.CompoundName(key)]
+		switch {
+		case !inDesired:
+			object.addChange(diffActionDelete, "tag", key, tagValuesString(currentValues), "")
+		case !inCurrent:
+			object.addChange(diffActionAdd, "tag", key, "", tagValuesString(desiredValues))
+		default:
+			from := tagValuesString(currentValues)
+			to := tagValuesString(desiredValues)
+			if from != to {
+				object.addChange(diffActionUpdate, "tag", key, from, to)
+			}
+		}
+	}
+}
+
+// diffStringSets reports the entries that were added to or removed
+// from the given list of values regardless of their order.
+func (object *DomainObjectDiff) diffStringSets(attribute string, current, desired []string) {
+	currentSet := make(map[string]bool)
+	for _, value := range current {
+		currentSet[value] = true
+	}
+	desiredSet := make(map[string]bool)
+	for _, value := range desired {
+		desiredSet[value] = true
+	}
+	for _, value := range sortedKeys(currentSet) {
+		if !desiredSet[value] {
+			object.addChange(diffActionDelete, attribute, value, "", "")
+		}
+	}
+	for _, value := range sortedKeys(desiredSet) {
+		if !currentSet[value] {
+			object.addChange(diffActionAdd, attribute, value, "", "")
+		}
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// memberDueDates returns the expiration and review dates of a member
+// in a single string so that members can be compared and displayed.
+func memberDueDates(expiration, review string) string {
+	dates := make([]string, 0)
+	if expiration != "" {
+		dates = append(dates, "expiration="+expiration)
+	}
+	if review != "" {
+		dates = append(dates, "review="+review)
+	}
+	return strings.Join(dates, " ")
+}
+
+func roleMemberDueDates(member *zms.RoleMember) string {
+	expiration := ""
+	if member.Expiration != nil {
+		expiration = member.Expiration.String()
+	}
+	review := ""
+	if member.ReviewReminder != nil {
+		review = member.ReviewReminder.String()
+	}
+	return memberDueDates(expiration, review)
+}
+
+func groupMemberDueDates(member *zms.GroupMember) string {
+	expiration := ""
+	if member.Expiration != nil {
+		expiration = member.Expiration.String()
+	}
+	return memberDueDates(expiration, "")
+}
+
+// diffMembers compares two member maps where the key is the member
+// name and the value is the member due dates string.
+func (object *DomainObjectDiff) diffMembers(current, desired map[string]string) {
+	names := make(map[string]bool)
+	for name := range current {
+		names[name] = true
+	}
+	for name := range desired {
+		names[name] = true
+	}
+	for _, name := range sortedKeys(names) {
+		currentDates, inCurrent := current[name]
+		desiredDates, inDesired := desired[name]
+		switch {
+		case !inDesired:
+			object.addChange(diffActionDelete, "member", name, currentDates, "")
+		case !inCurrent:
+			object.addChange(diffActionAdd, "member", name, "", desiredDates)
+		case currentDates != desiredDates:
+			object.addChange(diffActionUpdate, "member", name, currentDates, desiredDates)
+		}
+	}
+}
+
+func isPendingMember(approved *bool) bool {
+	return approved != nil && !*approved
+}
+
+func roleMemberMap(role *zms.Role) map[string]string {
+	members := make(map[string]string)
+	for _, member := range role.RoleMembers {
+		if !isPendingMember(member.Approved) {
+			members[string(member.MemberName)] = roleMemberDueDates(member)
+		}
+	}
+	return members
+}
+
+func groupMemberMap(group *zms.Group) map[string]string {
+	members := make(map[string]string)
+	for _, member := range group.GroupMembers {
+		if !isPendingMember(member.Approved) {
+			members[string(member.MemberName)] = groupMemberDueDates(member)
+		}
+	}
+	return members
+}
+
+// assertionString returns the assertion in the same format as it's
+// displayed in show-policy so it can be used to match assertions.
+func assertionString(dn string, assertion *zms.Assertion) string {
+	var buf strings.Builder
+	effect := "grant"
+	if assertion.Effect != nil && assertion.Effect.String() == "DENY" {
+		effect = "deny"
+	}
+	action := assertion.Action
+	resource := assertion.Resource
+	if assertion.CaseSensitive == nil || !*assertion.CaseSensitive {
+		action = strings.ToLower(action)
+		resource = strings.ToLower(resource)
+	}
+	buf.WriteString(effect + " " + action + " to ")
+	buf.WriteString(localName(assertion.Role, ":role."))
+	buf.WriteString(" on ")
+	prefix := dn + ":"
+	if strings.HasPrefix(resource, prefix) {
+		resource = resource[len(prefix):]
+	}
+	buf.WriteString(resource)
+	return buf.String()
+}
+
+// assertionConditionsString returns the assertion conditions in a
+// canonical format so they can be compared regardless of their order
+func assertionConditionsString(conditions *zms.AssertionConditions) string {
+	if conditions == nil {
+		return ""
+	}
+	lstConditions := make([]string, 0)
+	for _, condition := range conditions.ConditionsList {
+		keys := make([]string, 0)
+		for key, data := range condition.ConditionsMap {
+			keys = append(keys, string(key)+" "+data.Operator.String()+" "+string(data.Value))
+		}
+		sort.Strings(keys)
+		lstConditions = append(lstConditions, "("+strings.Join(keys, " and ")+")")
+	}
+	sort.Strings(lstConditions)
+	return strings.Join(lstConditions, " or ")
+}
+
+// assertionDiffString returns the assertion string along with its
+// conditions which is used to match assertions between domains
+func assertionDiffString(dn string, assertion *zms.Assertion) string {
+	conditions := assertionConditionsString(assertion.Conditions)
+	if conditions == "" {
+		return assertionString(dn, assertion)
+	}
+	return assertionString(dn, assertion) + " if " + conditions
+}
+
+func assertionStrings(dn string, policy *zms.Policy) []string {
+	assertions := make([]string, 0)
+	for _, assertion := range policy.Assertions {
+		assertions = append(assertions, assertionDiffString(dn, assertion))
+	}
+	return assertions
+}
+
+func publicKeyMap(service *zms.ServiceIdentity) map[string]string {
+	keys := make(map[string]string)
+	for _, publicKey := range service.PublicKeys {
+		keys[publicKey.Id] = strings.TrimSpace(publicKey.Key)
+	}
+	return keys
+}
+
+func (object *DomainObjectDiff) diffPublicKeys(current, desired map[string]string) {
+	ids := make(map[string]bool)
+	for id := range current {
+		ids[id] = true
+	}
+	for id := range desired {
+		ids[id] = true
+	}
+	for _, id := range sortedKeys(ids) {
+		currentKey, inCurrent := current[id]
+		desiredKey, inDesired := desired[id]
+		switch {
+		case !inDesired:
+			object.addChange(diffActionDelete, "public-key", id, "", "")
+		case !inCurrent:
+			object.addChange(diffActionAdd, "public-key", id, "", "")
+		case currentKey != desiredKey:
+			object.addChange(diffActionUpdate, "public-key", id, "", "")
+		}
+	}
+}
+
+// domainPolicies returns the active policies of the domain. Backups include
+// the non-active policy versions which are marked with the active flag set
+// to false and are not taken into account when comparing or evaluating policies.
+func domainPolicies(domainData *zms.DomainData) []*zms.Policy {
+	if domainData.Policies == nil || domainData.Policies.Contents == nil {
+		return nil
+	}
+	policies := make([]*zms.Policy, 0, len(domainData.Policies.Contents.Policies))
+	for _, policy := range domainData.Policies.Contents.Policies {
+		if policy.Active == nil || *policy.Active {
+			policies = append(policies, policy)
+		}
+	}
+	return policies
+}
+
+// nonActivePolicies returns the non-active policy versions of the domain
+func nonActivePolicies(domainData *zms.DomainData) []*zms.Policy {
+	policies := make([]*zms.Policy, 0)
+	if domainData.Policies == nil || domainData.Policies.Contents == nil {
+		return policies
+	}
+	for _, policy := range domainData.Policies.Contents.Policies {
+		if policy.Active != nil && !*policy.Active {
+			policies = append(policies, policy)
+		}
+	}
+	return policies
+}
+
+// domainSystemMetaAttributes maps the domain diff attributes that can
+// only be updated with the system meta api to their system attribute names
+var domainSystemMetaAttributes = map[string]string{
+	"org":                   "org",
+	"audit-enabled":         "auditenabled",
+	"account":               "account",
+	"azure-subscription":    "azuresubscription",
+	"product-id":            "productid",
+	"cert-dns-domain":       "certdnsdomain",
+	"user-authority-filter": "userauthorityfilter",
+}
+
+// diffDomainMeta compares all the domain meta attributes except for the
+// enabled flag which is managed by the system administrators only.
+func diffDomainMeta(current, desired *zms.DomainData) *DomainObjectDiff {
+	object := &DomainObjectDiff{
+		Action:     diffActionUpdate,
+		ObjectType: diffObjectDomain,
+		Name:       string(desired.Name),
+	}
+	object.diffString("description", current.Description, desired.Description)
+	object.diffString("org", string(current.Org), string(desired.Org))
+	object.diffString("audit-enabled", boolString(current.AuditEnabled), boolString(desired.AuditEnabled))
+	object.diffString("application-id", current.ApplicationId, desired.ApplicationId)
+	object.diffString("business-service", current.BusinessService, desired.BusinessService)
+	object.diffString("account", current.Account, desired.Account)
+	object.diffString("azure-subscription", current.AzureSubscription, desired.AzureSubscription)
+	object.diffString("product-id", int32String(current.YpmId), int32String(desired.YpmId))
+	object.diffString("cert-dns-domain", current.CertDnsDomain, desired.CertDnsDomain)
+	object.diffString("user-authority-filter", current.UserAuthorityFilter, desired.UserAuthorityFilter)
+	object.diffString("member-expiry-days", int32String(current.MemberExpiryDays), int32String(desired.MemberExpiryDays))
+	object.diffString("service-expiry-days", int32String(current.ServiceExpiryDays), int32String(desired.ServiceExpiryDays))
+	object.diffString("group-expiry-days", int32String(current.GroupExpiryDays), int32String(desired.GroupExpiryDays))
+	object.diffString("token-expiry-mins", int32String(current.TokenExpiryMins), int32String(desired.TokenExpiryMins))
+	object.diffString("role-cert-expiry-mins", int32String(current.RoleCertExpiryMins), int32String(desired.RoleCertExpiryMins))
+	object.diffString("service-cert-expiry-mins", int32String(current.ServiceCertExpiryMins), int32String(desired.ServiceCertExpiryMins))
+	object.diffString("sign-algorithm", string(current.SignAlgorithm), string(desired.SignAlgorithm))
+	object.diffTags(current.Tags, desired.Tags)
+	return object
+}
+
+// roleMetaAttributes are the role diff attributes that are
+// updated with the role meta api
+var roleMetaAttributes = []string{
+	"self-serve", "review-enabled", "member-expiry-days", "service-expiry-days", "group-expiry-days",
+	"member-review-days", "service-review-days", "group-review-days", "token-expiry-mins", "cert-expiry-mins",
+	"sign-algorithm", "notify-roles", "user-authority-filter", "user-authority-expiration", "tag",
+}
+
+func diffRole(current, desired *zms.Role) *DomainObjectDiff {
+	object := &DomainObjectDiff{
+		Action:     diffActionUpdate,
+		ObjectType: diffObjectRole,
+		Name:       localName(string(desired.Name), ":role."),
+	}
+	object.diffString("trust", string(current.Trust), string(desired.Trust))
+	object.diffMembers(roleMemberMap(current), roleMemberMap(desired))
+	object.diffString("audit-enabled", boolString(current.AuditEnabled), boolString(desired.AuditEnabled))
+	object.diffString("self-serve", boolString(current.SelfServe), boolString(desired.SelfServe))
+	object.diffString("review-enabled", boolString(current.ReviewEnabled), boolString(desired.ReviewEnabled))
+	object.diffString("member-expiry-days", int32String(current.MemberExpiryDays), int32String(desired.MemberExpiryDays))
+	object.diffString("service-expiry-days", int32String(current.ServiceExpiryDays), int32String(desired.ServiceExpiryDays))
+	object.diffString("group-expiry-days", int32String(current.GroupExpiryDays), int32String(desired.GroupExpiryDays))
+	object.diffString("member-review-days", int32String(current.MemberReviewDays), int32String(desired.MemberReviewDays))
+	object.diffString("service-review-days", int32String(current.ServiceReviewDays), int32String(desired.ServiceReviewDays))
+	object.diffString("group-review-days", int32String(current.GroupReviewDays), int32String(desired.GroupReviewDays))
+	object.diffString("token-expiry-mins", int32String(current.TokenExpiryMins), int32String(desired.TokenExpiryMins))
+	object.diffString("cert-expiry-mins", int32String(current.CertExpiryMins), int32String(desired.CertExpiryMins))
+	object.diffString("sign-algorithm", string(current.SignAlgorithm), string(desired.SignAlgorithm))
+	object.diffString("notify-roles", current.NotifyRoles, desired.NotifyRoles)
+	object.diffString("user-authority-filter", current.UserAuthorityFilter, desired.UserAuthorityFilter)
+	object.diffString("user-authority-expiration", current.UserAuthorityExpiration, desired.UserAuthorityExpiration)
+	object.diffTags(current.Tags, desired.Tags)
+	return object
+}
+
+// groupMetaAttributes are the group diff attributes that are
+// updated with the group meta api
+var groupMetaAttributes = []string{
+	"self-serve", "review-enabled", "member-expiry-days", "service-expiry-days",
+	"notify-roles", "user-authority-filter", "user-authority-expiration", "tag",
+}
+
+func diffGroup(current, desired *zms.Group) *DomainObjectDiff {
+	object := &DomainObjectDiff{
+		Action:     diffActionUpdate,
+		ObjectType: diffObjectGroup,
+		Name:       localName(string(desired.Name), ":group."),
+	}
+	object.diffMembers(groupMemberMap(current), groupMemberMap(desired))
+	object.diffString("audit-enabled", boolString(current.AuditEnabled), boolString(desired.AuditEnabled))
+	object.diffString("self-serve", boolString(current.SelfServe), boolString(desired.SelfServe))
+	object.diffString("review-enabled", boolString(current.ReviewEnabled), boolString(desired.ReviewEnabled))
+	object.diffString("member-expiry-days", int32String(current.MemberExpiryDays), int32String(desired.MemberExpiryDays))
+	object.diffString("service-expiry-days", int32String(current.ServiceExpiryDays), int32String(desired.ServiceExpiryDays))
+	object.diffString("notify-roles", current.NotifyRoles, desired.NotifyRoles)
+	object.diffString("user-authority-filter", current.UserAuthorityFilter, desired.UserAuthorityFilter)
+	object.diffString("user-authority-expiration", current.UserAuthorityExpiration, desired.UserAuthorityExpiration)
+	object.diffTags(current.Tags, desired.Tags)
+	return object
+}
+
+func diffPolicy(dn string, current, desired *zms.Policy) *DomainObjectDiff {
+	object := &DomainObjectDiff{
+		Action:     diffActionUpdate,
+		ObjectType: diffObjectPolicy,
+		Name:       localName(string(desired.Name), ":policy."),
+	}
+	object.diffStringSets("assertion", assertionStrings(dn, current), assertionStrings(dn, desired))
+	return object
+}
+
+func diffService(dn string, current, desired *zms.ServiceIdentity) *DomainObjectDiff {
+	object := &DomainObjectDiff{
+		Action:     diffActionUpdate,
+		ObjectType: diffObjectService,
+		Name:       shortname(dn, string(desired.Name)),
+	}
+	object.diffPublicKeys(publicKeyMap(current), publicKeyMap(desired))
+	object.diffStringSets("host", current.Hosts, desired.Hosts)
+	object.diffString("provider-endpoint", current.ProviderEndpoint, desired.ProviderEndpoint)
+	object.diffString("executable", current.Executable, desired.Executable)
+	object.diffString("user", current.User, desired.User)
+	object.diffString("group", current.Group, desired.Group)
+	return object
+}
+
+// diffDomainData computes the list of changes required to transform
+// the current domain into the desired domain.
+func diffDomainData(current, desired *zms.DomainData) *DomainDiff {
+	dn := string(desired.Name)
+	diff := &DomainDiff{
+		Domain:  dn,
+		Objects: make([]*DomainObjectDiff, 0),
+	}
+	appendObject := func(object *DomainObjectDiff) {
+		if object.Action != diffActionUpdate || len(object.Changes) != 0 {
+			diff.Objects = append(diff.Objects, object)
+		}
+	}
+	appendObject(diffDomainMeta(current, desired))
+
+	currentServices := make(map[string]*zms.ServiceIdentity)
+	for _, service := range current.Services {
+		currentServices[shortname(dn, string(service.Name))] = service
+	}
+	desiredServices := make(map[string]*zms.ServiceIdentity)
+	for _, service := range desired.Services {
+		desiredServices[shortname(dn, string(service.Name))] = service
+	}
+	for _, name := range sortedObjectNames(currentServices, desiredServices) {
+		appendObject(diffObject(diffObjectService, name, currentServices[name], desiredServices[name], func() *DomainObjectDiff {
+			return diffService(dn, currentServices[name], desiredServices[name])
+		}))
+	}
+
+	currentGroups := make(map[string]*zms.Group)
+	for _, group := range current.Groups {
+		currentGroups[localName(string(group.Name), ":group.")] = group
+	}
+	desiredGroups := make(map[string]*zms.Group)
+	for _, group := range desired.Groups {
+		desiredGroups[localName(string(group.Name), ":group.")] = group
+	}
+	for _, name := range sortedObjectNames(currentGroups, desiredGroups) {
+		appendObject(diffObject(diffObjectGroup, name, currentGroups[name], desiredGroups[name], func() *DomainObjectDiff {
+			return diffGroup(currentGroups[name], desiredGroups[name])
+		}))
+	}
+
+	currentRoles := make(map[string]*zms.Role)
+	for _, role := range current.Roles {
+		currentRoles[localName(string(role.Name), ":role.")] = role
+	}
+	desiredRoles := make(map[string]*zms.Role)
+	for _, role := range desired.Roles {
+		desiredRoles[localName(string(role.Name), ":role.")] = role
+	}
+	for _, name := range sortedObjectNames(currentRoles, desiredRoles) {
+		appendObject(diffObject(diffObjectRole, name, currentRoles[name], desiredRoles[name], func() *DomainObjectDiff {
+			return diffRole(currentRoles[name], desiredRoles[name])
+		}))
+	}
+
+	currentPolicies := make(map[string]*zms.Policy)
+	for _, policy := range domainPolicies(current) {
+		currentPolicies[localName(string(policy.Name), ":policy.")] = policy
+	}
+	desiredPolicies := make(map[string]*zms.Policy)
+	for _, policy := range domainPolicies(desired) {
+		desiredPolicies[localName(string(policy.Name), ":policy.")] = policy
+	}
+	for _, name := range sortedObjectNames(currentPolicies, desiredPolicies) {
+		appendObject(diffObject(diffObjectPolicy, name, currentPolicies[name], desiredPolicies[name], func() *DomainObjectDiff {
+			return diffPolicy(dn, currentPolicies[name], desiredPolicies[name])
+		}))
+	}
+	return diff
+}
+
+// diffObject returns an add or delete object diff if the object exists
+// in only one of the domains, otherwise it calls the given function
+// to compare the object attributes.
+func diffObject(objectType, name string, current, desired interface{}, diffAttributes func() *DomainObjectDiff) *DomainObjectDiff {
+	inCurrent := !isNilObject(current)
+	inDesired := !isNilObject(desired)
+	switch {
+	case !inDesired:
+		return &DomainObjectDiff{Action: diffActionDelete, ObjectType: objectType, Name: name}
+	case !inCurrent:
+		return &DomainObjectDiff{Action: diffActionAdd, ObjectType: objectType, Name: name}
+	}
+	return diffAttributes()
+}
+
+func isNilObject(object interface{}) bool {
+	switch v := object.(type) {
+	case *zms.Role:
+		return v == nil
+	case *zms.Group:
+		return v == nil
+	case *zms.Policy:
+		return v == nil
+	case *zms.ServiceIdentity:
+		return v == nil
+	}
+	return object == nil
+}
+
+func sortedObjectNames(current, desired interface{}) []string {
+	names := make(map[string]bool)
+	for _, objects := range []interface{}{current, desired} {
+		switch v := objects.(type) {
+		case map[string]*zms.Role:
+			for name := range v {
+				names[name] = true
+			}
+		case map[string]*zms.Group:
+			for name := range v {
+				names[name] = true
+			}
+		case map[string]*zms.Policy:
+			for name := range v {
+				names[name] = true
+			}
+		case map[string]*zms.ServiceIdentity:
+			for name := range v {
+				names[name] = true
+			}
+		}
+	}
+	return sortedKeys(names)
+}
+
+// DiffDomain reports the semantic differences between two domain files
+// or, if only one file is given, between the live domain and the file.
+func (cli Zms) DiffDomain(dn string, filename1 string, filename2 string) (*string, error) {
+	domain1, err := loadDomainFile(filename1)
+	if err != nil {
+		return nil, err
+	}
+	var domain2 *zms.DomainData
+	if filename2 != "" {
+		domain2, err = loadDomainFile(filename2)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if dn != "" && dn != string(domain1.Name) {
+			return nil, fmt.Errorf("Domain name mismatch. Expected " + dn + ", encountered " + string(domain1.Name))
+		}
+		domain2 = domain1
+		domain1, err = cli.liveDomainData(string(domain2.Name))
+		if err != nil {
+			return nil, err
+		}
+	}
+	diff := diffDomainData(domain1, domain2)
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		if len(diff.Objects) == 0 {
+			buf.WriteString("[no differences found for domain " + diff.Domain + "]\n")
+		} else {
+			cli.dumpDomainDiff(&buf, diff)
+		}
+		s := buf.String()
+		return &s, nil
+	}
+
+	return cli.dumpByFormat(diff, oldYamlConverter)
+}
@@ -0,0 +1,286 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+const currentDomainYaml = `
+domain:
+  name: coretech
+  description: core tech domain
+  roles:
+    - name: admin
+      members:
+        - name: user.john
+    - name: readers
+      members:
+        - name: user.jane
+          expiration: 2030-01-01T00:00:00.000Z
+        - name: user.joe
+  policies:
+    - name: admin
+      assertions:
+        - grant * to admin on *
+    - name: readers
+      assertions:
+        - grant read to readers on articles
+        - grant write to readers on articles
+  services:
+    - name: api
+      hosts:
+        - host1.example.com
+`
+
+const desiredDomainYaml = `
+domain:
+  name: coretech
+  description: core tech domain updated
+  roles:
+    - name: admin
+      members:
+        - name: user.john
+    - name: readers
+      members:
+        - name: user.jane
+          expiration: 2031-01-01T00:00:00.000Z
+        - name: user.jack
+    - name: writers
+  policies:
+    - name: admin
+      assertions:
+        - grant * to admin on *
+    - name: readers
+      assertions:
+        - grant READ to readers on articles
+`
+
+func TestDiffDomainData(t *testing.T) {
+	current, err := parseDomainDataOld([]byte(currentDomainYaml))
+	if err != nil {
+		t.Fatalf("unable to parse current domain: %v", err)
+	}
+	desired, err := parseDomainDataOld([]byte(desiredDomainYaml))
+	if err != nil {
+		t.Fatalf("unable to parse desired domain: %v", err)
+	}
+	diff := diffDomainData(current, desired)
+	if diff.count(diffActionAdd) != 1 {
+		t.Error("Expected 1 object to add")
+	}
+	if diff.count(diffActionUpdate) != 3 {
+		t.Error("Expected 3 objects to update")
+	}
+	if diff.count(diffActionDelete) != 1 {
+		t.Error("Expected 1 object to delete")
+	}
+	changes := make(map[string]*DomainObjectDiff)
+	for _, object := range diff.Objects {
+		changes[object.ObjectType+":"+object.Name] = object
+	}
+	if object := changes["domain:coretech"]; object == nil || len(object.Changes) != 1 || object.Changes[0].Attribute != "description" {
+		t.Error("Expected domain description change")
+	}
+	if object := changes["service:api"]; object == nil || object.Action != diffActionDelete {
+		t.Error("Expected api service to be deleted")
+	}
+	if object := changes["role:writers"]; object == nil || object.Action != diffActionAdd {
+		t.Error("Expected writers role to be added")
+	}
+	object := changes["role:readers"]
+	if object == nil || len(object.Changes) != 3 {
+		t.Fatal("Expected 3 member changes in readers role")
+	}
+	if object.Changes[0].Action != diffActionAdd || object.Changes[0].Value != "user.jack" {
+		t.Error("Expected user.jack to be added")
+	}
+	if object.Changes[1].Action != diffActionUpdate || object.Changes[1].Value != "user.jane" {
+		t.Error("Expected user.jane expiration to be updated")
+	}
+	if object.Changes[2].Action != diffActionDelete || object.Changes[2].Value != "user.joe" {
+		t.Error("Expected user.joe to be deleted")
+	}
+	object = changes["policy:readers"]
+	if object == nil || len(object.Changes) != 1 {
+		t.Fatal("Expected 1 assertion change in readers policy")
+	}
+	if object.Changes[0].Action != diffActionDelete || object.Changes[0].Value != "grant write to readers on articles" {
+		t.Error("Expected write assertion to be deleted")
+	}
+	if _, ok := changes["role:admin"]; ok {
+		t.Error("Admin role should not have any changes")
+	}
+}
+
+func TestDiffDomainDataNoChanges(t *testing.T) {
+	current, err := parseDomainDataOld([]byte(currentDomainYaml))
+	if err != nil {
+		t.Fatalf("unable to parse current domain: %v", err)
+	}
+	desired, err := parseDomainDataOld([]byte(currentDomainYaml))
+	if err != nil {
+		t.Fatalf("unable to parse desired domain: %v", err)
+	}
+	diff := diffDomainData(current, desired)
+	if len(diff.Objects) != 0 {
+		t.Error("Expected no changes between identical domains")
+	}
+}
+
+func TestDiffDomainMeta(t *testing.T) {
+	days := int32(30)
+	mins := int32(60)
+	current := &zms.DomainData{Name: "coretech", Account: "1234", MemberExpiryDays: &days}
+	desired := &zms.DomainData{Name: "coretech", Account: "5678", TokenExpiryMins: &mins, UserAuthorityFilter: "contractor"}
+	object := diffDomainMeta(current, desired)
+	expected := []string{"account: 1234 => 5678", "user-authority-filter:  => contractor", "member-expiry-days: 30 => 0", "token-expiry-mins: 0 => 60"}
+	if len(object.Changes) != len(expected) {
+		t.Fatalf("unexpected domain changes: %d", len(object.Changes))
+	}
+	for i, change := range object.Changes {
+		if change.Attribute+": "+change.From+" => "+change.To != expected[i] {
+			t.Errorf("unexpected domain change: %+v", change)
+		}
+	}
+}
+
+func TestDiffRoleMeta(t *testing.T) {
+	days := int32(90)
+	selfServe := true
+	current := &zms.Role{Name: "coretech:role.readers", SelfServe: &selfServe}
+	desired := &zms.Role{Name: "coretech:role.readers", MemberReviewDays: &days, NotifyRoles: "admin"}
+	object := diffRole(current, desired)
+	expected := []string{"self-serve: true => false", "member-review-days: 0 => 90", "notify-roles:  => admin"}
+	if len(object.Changes) != len(expected) {
+		t.Fatalf("unexpected role changes: %d", len(object.Changes))
+	}
+	for i, change := range object.Changes {
+		if change.Attribute+": "+change.From+" => "+change.To != expected[i] {
+			t.Errorf("unexpected role change: %+v", change)
+		}
+	}
+	if !object.hasChanges(roleMetaAttributes) || object.hasChange("audit-enabled") {
+		t.Error("expected only role meta changes")
+	}
+}
+
+func TestExportedDomainNoChanges(t *testing.T) {
+	days := int32(90)
+	mins := int32(60)
+	ypmID := int32(1001)
+	enabled := true
+	disabled := false
+	tags := map[zms.CompoundName]*zms.TagValueList{"env": {List: []zms.TagCompoundValue{"prod"}}}
+	expiration := rdl.Timestamp{Time: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	domain := &zms.Domain{
+		Name:                  "coretech",
+		Description:           "core tech domain",
+		Org:                   "sports",
+		AuditEnabled:          &enabled,
+		Account:               "123456789012",
+		AzureSubscription:     "azure-sub",
+		YpmId:                 &ypmID,
+		ApplicationId:         "app-id",
+		BusinessService:       "service",
+		CertDnsDomain:         "athenz.cloud",
+		UserAuthorityFilter:   "employee",
+		MemberExpiryDays:      &days,
+		ServiceExpiryDays:     &days,
+		GroupExpiryDays:       &days,
+		TokenExpiryMins:       &mins,
+		RoleCertExpiryMins:    &mins,
+		ServiceCertExpiryMins: &mins,
+		SignAlgorithm:         "rsa",
+		Tags:                  tags,
+	}
+	role := &zms.Role{
+		Name:                    "coretech:role.readers",
+		MemberExpiryDays:        &days,
+		ServiceExpiryDays:       &days,
+		GroupExpiryDays:         &days,
+		TokenExpiryMins:         &mins,
+		CertExpiryMins:          &mins,
+		MemberReviewDays:        &days,
+		ServiceReviewDays:       &days,
+		GroupReviewDays:         &days,
+		AuditEnabled:            &enabled,
+		ReviewEnabled:           &enabled,
+		SelfServe:               &disabled,
+		SignAlgorithm:           "ec",
+		NotifyRoles:             "admin",
+		UserAuthorityFilter:     "employee",
+		UserAuthorityExpiration: "elevated-clearance",
+		Tags:                    tags,
+		RoleMembers: []*zms.RoleMember{
+			{MemberName: "user.jane", Expiration: &expiration, ReviewReminder: &expiration},
+			{MemberName: "user.joe", Approved: &disabled},
+		},
+	}
+	group := &zms.Group{
+		Name:                    "coretech:group.devs",
+		MemberExpiryDays:        &days,
+		ServiceExpiryDays:       &days,
+		AuditEnabled:            &enabled,
+		ReviewEnabled:           &enabled,
+		SelfServe:               &enabled,
+		NotifyRoles:             "admin",
+		UserAuthorityFilter:     "employee",
+		UserAuthorityExpiration: "elevated-clearance",
+		Tags:                    tags,
+		GroupMembers: []*zms.GroupMember{
+			{MemberName: "user.john", Expiration: &expiration},
+			{MemberName: "user.jack", Approved: &disabled},
+		},
+	}
+
+	// generate the manual yaml file the same way as export-domain does
+	var buf bytes.Buffer
+	cli := Zms{}
+	cli.dumpDomain(&buf, domain)
+	cli.dumpTags(&buf, true, "  ", indentLevel1, domain.Tags)
+	buf.WriteString(indentLevel1 + "roles:\n")
+	cli.dumpRole(&buf, *role, false, indentLevel2Dash, indentLevel2DashLvl)
+	buf.WriteString(indentLevel1 + "groups:\n")
+	cli.dumpGroup(&buf, *group, false, indentLevel2Dash, indentLevel2DashLvl)
+	exported, err := parseDomainDataOld(buf.Bytes())
+	if err != nil {
+		t.Fatalf("unable to parse exported domain: %v\n%s", err, buf.String())
+	}
+
+	current := &zms.DomainData{
+		Name:                  domain.Name,
+		Description:           domain.Description,
+		Org:                   domain.Org,
+		AuditEnabled:          domain.AuditEnabled,
+		Account:               domain.Account,
+		AzureSubscription:     domain.AzureSubscription,
+		YpmId:                 domain.YpmId,
+		ApplicationId:         domain.ApplicationId,
+		BusinessService:       domain.BusinessService,
+		CertDnsDomain:         domain.CertDnsDomain,
+		UserAuthorityFilter:   domain.UserAuthorityFilter,
+		MemberExpiryDays:      domain.MemberExpiryDays,
+		ServiceExpiryDays:     domain.ServiceExpiryDays,
+		GroupExpiryDays:       domain.GroupExpiryDays,
+		TokenExpiryMins:       domain.TokenExpiryMins,
+		RoleCertExpiryMins:    domain.RoleCertExpiryMins,
+		ServiceCertExpiryMins: domain.ServiceCertExpiryMins,
+		SignAlgorithm:         domain.SignAlgorithm,
+		Tags:                  domain.Tags,
+		Roles:                 []*zms.Role{role},
+		Groups:                []*zms.Group{group},
+	}
+	diff := diffDomainData(current, exported)
+	for _, object := range diff.Objects {
+		for _, change := range object.Changes {
+			t.Errorf("unexpected %s %s change: %s %s => %s", object.ObjectType, object.Name, change.Attribute, change.From, change.To)
+		}
+	}
+}
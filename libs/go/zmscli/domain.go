@@ -66,8 +66,20 @@ func (cli Zms) SetDomainState(dn string, enabled bool) (*string, error) {
 	return cli.dumpByFormat(message, cli.buildYAMLOutput)
 }
 
+// checkDomainFileFormat rejects the table and csv output formats for the
+// commands where the output format also selects the domain file format
+func (cli Zms) checkDomainFileFormat(command string) error {
+	if cli.OutputFormat == TableOutputFormat || cli.OutputFormat == CSVOutputFormat {
+		return fmt.Errorf("%s does not support the %s output format - use json or yaml for domain files in that format", command, cli.OutputFormat)
+	}
+	return nil
+}
+
 func (cli Zms) ImportDomain(dn string, filename string, admins []string) (*string, error) {
 
+	if err := cli.checkDomainFileFormat("import-domain"); err != nil {
+		return nil, err
+	}
 	if cli.OutputFormat == JSONOutputFormat || cli.OutputFormat == YAMLOutputFormat {
 		return cli.ImportDomainNew(dn, filename, admins, true)
 	} else {
@@ -221,6 +233,9 @@ func (cli Zms) ImportDomainOld(dn string, filename string, admins []string) (*st
 }
 
 func (cli Zms) UpdateDomain(dn string, filename string) (*string, error) {
+	if err := cli.checkDomainFileFormat("update-domain"); err != nil {
+		return nil, err
+	}
 	if cli.OutputFormat == JSONOutputFormat || cli.OutputFormat == YAMLOutputFormat {
 		return cli.ImportDomainNew(dn, filename, nil, false)
 	} else {
@@ -314,18 +329,13 @@ func (cli Zms) SystemBackup(dir string) (*string, error) {
 	if err != nil {
 		return nil, err
 	}
-	verbose := cli.Verbose
-	cli.Verbose = false
 	for _, name := range res.Names {
 		_, _ = fmt.Fprintf(os.Stdout, "Processing domain "+string(name)+"...\n")
-		filename := dir + "/" + string(name)
-		data, err := cli.showDomain(string(name))
-		if err == nil && data != nil {
-			s := *data
-			err = ioutil.WriteFile(filename, []byte(s), 0644)
+		err = cli.backupDomain(dir, string(name))
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stdout, "Unable to backup domain %s: %v\n", name, err)
 		}
 	}
-	cli.Verbose = verbose
 	s := "[exported " + strconv.Itoa(len(res.Names)) + " domains to " + dir + " directory]"
 	message := SuccessMessage{
 		Status:  200,
@@ -455,6 +465,18 @@ func (cli Zms) LookupDomainByTag(tagKey string, tagValue string) (*string, error
 	return cli.dumpDomainListByFormat(res)
 }
 
+func (cli Zms) domainNames() ([]string, error) {
+	names := make([]string, 0)
+	res, err := cli.Zms.GetDomainList(nil, "", "", nil, "", nil, "", "", "", "", "", "", "")
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range res.Names {
+		names = append(names, string(n))
+	}
+	return names, nil
+}
+
 func (cli Zms) ListDomains(limit *int32, skip string, prefix string, depth *int32) (*string, error) {
 	res, err := cli.Zms.GetDomainList(limit, skip, prefix, depth, "", nil, "", "", "", "", "", "", "")
 	if err != nil {
@@ -464,7 +486,7 @@ func (cli Zms) ListDomains(limit *int32, skip string, prefix string, depth *int3
 	return cli.dumpDomainListByFormat(res)
 }
 
-func (cli Zms) GetSignedDomains(dn string, matchingTag string) (*string, error) {
+func (cli Zms) GetSignedDomains(dn string, matchingTag string, verify bool) (*string, error) {
 	master := true
 	conditions := true
 	signedDomains, etag, err := cli.Zms.GetSignedDomains(zms.DomainName(dn), "false", "", &master, &conditions, matchingTag)
@@ -472,11 +494,23 @@ func (cli Zms) GetSignedDomains(dn string, matchingTag string) (*string, error)
 		return nil, err
 	}
 
+	if verify {
+		for _, domain := range signedDomains.Domains {
+			err = cli.verifySignedDomain(domain)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	oldYamlConverter := func(res interface{}) (*string, error) {
 		var buf bytes.Buffer
 		buf.WriteString("ETag: " + etag + "\n")
 		for _, domain := range signedDomains.Domains {
 			cli.dumpSignedDomain(&buf, domain, false)
+			if verify {
+				buf.WriteString(indentLevel1 + "verified: true\n")
+			}
 		}
 		s := buf.String()
 		return &s, nil
@@ -485,6 +519,40 @@ func (cli Zms) GetSignedDomains(dn string, matchingTag string) (*string, error)
 	return cli.dumpByFormat(signedDomains, oldYamlConverter)
 }
 
+func (cli Zms) GetJWSDomain(dn string, verify bool) (*string, error) {
+	signatureP1363Format := true
+	jwsDomain, _, err := cli.Zms.GetJWSDomain(zms.DomainName(dn), &signatureP1363Format, "")
+	if err != nil {
+		return nil, err
+	}
+	domainData, keyId, err := cli.decodeJWSDomain(jwsDomain, verify)
+	if err != nil {
+		return nil, err
+	}
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		if domainData.Policies == nil || domainData.Policies.Contents == nil {
+			domainData.Policies = &zms.SignedPolicies{
+				Contents: &zms.DomainPolicies{},
+			}
+		}
+		signedDomain := zms.SignedDomain{
+			Domain:    domainData,
+			Signature: jwsDomain.Signature,
+			KeyId:     keyId,
+		}
+		cli.dumpSignedDomain(&buf, &signedDomain, false)
+		if verify {
+			buf.WriteString(indentLevel1 + "verified: true\n")
+		}
+		s := buf.String()
+		return &s, nil
+	}
+
+	return cli.dumpByFormat(domainData, oldYamlConverter)
+}
+
 func (cli Zms) ShowOverdueReview(dn string) (*string, error) {
 
 	domainRoleMembers, err := cli.Zms.GetOverdueReview(zms.DomainName(dn))
@@ -592,9 +660,10 @@ func (cli Zms) showDomainNew(dn string) (*string, error) {
 
 func (cli Zms) showDomain(dn string) (*string, error) {
 
-	if cli.OutputFormat == JSONOutputFormat || cli.OutputFormat == YAMLOutputFormat {
+	switch cli.OutputFormat {
+	case JSONOutputFormat, YAMLOutputFormat, TableOutputFormat, CSVOutputFormat:
 		return cli.showDomainNew(dn)
-	} else {
+	default:
 		return cli.showDomainOld(dn)
 	}
 }
@@ -682,10 +751,14 @@ func (cli Zms) SetDomainAuditEnabled(dn string, auditEnabled bool) (*string, err
 }
 
 func (cli Zms) SetDomainUserAuthorityFilter(dn, filter string) (*string, error) {
+	err := cli.validateUserAuthorityAttributes(userAuthorityTypeBool, filter)
+	if err != nil {
+		return nil, err
+	}
 	meta := zms.DomainMeta{
 		UserAuthorityFilter: filter,
 	}
-	err := cli.Zms.PutDomainSystemMeta(zms.DomainName(dn), "userauthorityfilter", cli.AuditRef, &meta)
+	err = cli.Zms.PutDomainSystemMeta(zms.DomainName(dn), "userauthorityfilter", cli.AuditRef, &meta)
 	if err != nil {
 		return nil, err
 	}
@@ -932,10 +1005,14 @@ func (cli Zms) DeleteDomainTags(dn string, tagKey string, tagValue string) (*str
 }
 
 func (cli Zms) SetDomainAccount(dn string, account string) (*string, error) {
+	err := cli.validateMetaStoreValue(metaAttrAwsAccount, account)
+	if err != nil {
+		return nil, err
+	}
 	meta := zms.DomainMeta{
 		Account: account,
 	}
-	err := cli.Zms.PutDomainSystemMeta(zms.DomainName(dn), "account", cli.AuditRef, &meta)
+	err = cli.Zms.PutDomainSystemMeta(zms.DomainName(dn), "account", cli.AuditRef, &meta)
 	if err != nil {
 		return nil, err
 	}
@@ -949,10 +1026,14 @@ func (cli Zms) SetDomainAccount(dn string, account string) (*string, error) {
 }
 
 func (cli Zms) SetDomainSubscription(dn string, subscription string) (*string, error) {
+	err := cli.validateMetaStoreValue(metaAttrAzureSubscription, subscription)
+	if err != nil {
+		return nil, err
+	}
 	meta := zms.DomainMeta{
 		AzureSubscription: subscription,
 	}
-	err := cli.Zms.PutDomainSystemMeta(zms.DomainName(dn), "azuresubscription", cli.AuditRef, &meta)
+	err = cli.Zms.PutDomainSystemMeta(zms.DomainName(dn), "azuresubscription", cli.AuditRef, &meta)
 	if err != nil {
 		return nil, err
 	}
@@ -983,10 +1064,14 @@ func (cli Zms) SetDomainOrgName(dn string, org string) (*string, error) {
 }
 
 func (cli Zms) SetDomainProductId(dn string, productID int32) (*string, error) {
+	err := cli.validateMetaStoreValue(metaAttrProductId, strconv.Itoa(int(productID)))
+	if err != nil {
+		return nil, err
+	}
 	meta := zms.DomainMeta{
 		YpmId: &productID,
 	}
-	err := cli.Zms.PutDomainSystemMeta(zms.DomainName(dn), "productid", cli.AuditRef, &meta)
+	err = cli.Zms.PutDomainSystemMeta(zms.DomainName(dn), "productid", cli.AuditRef, &meta)
 	if err != nil {
 		return nil, err
 	}
@@ -1018,6 +1103,10 @@ func (cli Zms) SetDomainApplicationId(dn string, applicationID string) (*string,
 }
 
 func (cli Zms) SetDomainBusinessService(dn string, businessService string) (*string, error) {
+	err := cli.validateMetaStoreValue(metaAttrBusinessService, businessService)
+	if err != nil {
+		return nil, err
+	}
 	domain, err := cli.Zms.GetDomain(zms.DomainName(dn))
 	if err != nil {
 		return nil, err
@@ -1160,3 +1249,102 @@ func (cli Zms) GetDependentDomainList(service string) (*string, error) {
 	return cli.dumpByFormat(dependentDomains, oldYamlConverter)
 }
 
+func dumpDependentServiceResourceGroups(buf *bytes.Buffer, dependencies []*zms.DependentServiceResourceGroup) {
+	for _, dependency := range dependencies {
+		dumpStringValue(buf, indentLevel1Dash, "service", string(dependency.Service))
+		dumpStringValue(buf, indentLevel1DashLvl, "domain", string(dependency.Domain))
+		if len(dependency.ResourceGroups) != 0 {
+			buf.WriteString(indentLevel1DashLvl + "resource-groups:\n")
+			for _, resourceGroup := range dependency.ResourceGroups {
+				buf.WriteString(indentLevel2Dash + string(resourceGroup) + "\n")
+			}
+		}
+	}
+}
+
+func (cli Zms) GetDependentServiceResourceGroups(dn string) (*string, error) {
+	dependencyList, err := cli.Zms.GetDependentServiceResourceGroupList(zms.DomainName(dn))
+	if err != nil {
+		return nil, err
+	}
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		buf.WriteString("dependent-services:\n")
+		dumpDependentServiceResourceGroups(&buf, dependencyList.ServiceAndResourceGroups)
+		s := buf.String()
+		return &s, nil
+	}
+
+	return cli.dumpByFormat(dependencyList, oldYamlConverter)
+}
+
+// dependencyBlockers returns the provider services that the domain depends on
+// along with their resource groups. Services without resource groups are only
+// included in the dependent service list so both lists are combined. If the
+// provider is given, only its resource groups are returned since the provider
+// dependency itself is removed along with the tenancy.
+func dependencyBlockers(dn string, dependencies []*zms.DependentServiceResourceGroup, services []zms.EntityName, provider string) []*zms.DependentServiceResourceGroup {
+	blockers := make([]*zms.DependentServiceResourceGroup, 0)
+	found := make(map[string]bool)
+	for _, dependency := range dependencies {
+		found[string(dependency.Service)] = true
+		if provider == "" || (string(dependency.Service) == provider && len(dependency.ResourceGroups) != 0) {
+			blockers = append(blockers, dependency)
+		}
+	}
+	if provider != "" {
+		return blockers
+	}
+	for _, service := range services {
+		if !found[string(service)] {
+			blockers = append(blockers, &zms.DependentServiceResourceGroup{
+				Service: zms.ServiceName(service),
+				Domain:  zms.DomainName(dn),
+			})
+		}
+	}
+	return blockers
+}
+
+// CheckDomainDependencies verifies that no provider services depend on the
+// domain before it's deleted or, if the provider is given, that the domain
+// has no resource groups with the provider before the tenancy is deleted.
+// The blocking providers and resource groups are reported in the error.
+func (cli Zms) CheckDomainDependencies(dn string, provider string) error {
+	dependencyList, err := cli.Zms.GetDependentServiceResourceGroupList(zms.DomainName(dn))
+	if err != nil {
+		return err
+	}
+	var services []zms.EntityName
+	if provider == "" {
+		serviceList, err := cli.Zms.GetDependentServiceList(zms.DomainName(dn))
+		if err != nil {
+			return err
+		}
+		services = serviceList.Names
+	}
+	blockers := dependencyBlockers(dn, dependencyList.ServiceAndResourceGroups, services, provider)
+	if len(blockers) == 0 {
+		return nil
+	}
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		buf.WriteString("blocking-dependencies:\n")
+		dumpDependentServiceResourceGroups(&buf, blockers)
+		s := buf.String()
+		return &s, nil
+	}
+	output, err := cli.dumpByFormat(blockers, oldYamlConverter)
+	if err != nil {
+		return err
+	}
+	reason := "domain " + dn + " has " + strconv.Itoa(len(blockers)) + " dependent provider services"
+	if provider != "" {
+		reason = "domain " + dn + " has resource groups with provider " + provider
+	}
+	return &CommandFailedError{
+		Output: *output,
+		Reason: reason + " - use --force to delete anyway",
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+func TestDependencyBlockers(t *testing.T) {
+	dependencies := []*zms.DependentServiceResourceGroup{
+		{Service: "weather.storage", Domain: "coretech", ResourceGroups: []zms.EntityName{"forecast"}},
+		{Service: "sports.api", Domain: "coretech"},
+	}
+	services := []zms.EntityName{"weather.storage", "sports.api", "media.cdn"}
+
+	blockers := dependencyBlockers("coretech", dependencies, services, "")
+	if len(blockers) != 3 {
+		t.Fatalf("expected 3 blocking services, got %d", len(blockers))
+	}
+	if blockers[2].Service != "media.cdn" || blockers[2].Domain != "coretech" || len(blockers[2].ResourceGroups) != 0 {
+		t.Errorf("unexpected dependent service without resource groups: %+v", blockers[2])
+	}
+
+	blockers = dependencyBlockers("coretech", dependencies, nil, "weather.storage")
+	if len(blockers) != 1 || blockers[0].ResourceGroups[0] != "forecast" {
+		t.Errorf("expected weather.storage resource groups to block tenancy deletion, got %v", blockers)
+	}
+	blockers = dependencyBlockers("coretech", dependencies, nil, "sports.api")
+	if len(blockers) != 0 {
+		t.Errorf("expected no blockers for provider without resource groups, got %v", blockers)
+	}
+}
+
+func TestDomainFileFormatTabular(t *testing.T) {
+	for _, format := range []string{TableOutputFormat, CSVOutputFormat} {
+		cli := Zms{OutputFormat: format}
+		if _, err := cli.ImportDomain("coretech", "coretech.yaml", nil); err == nil {
+			t.Errorf("import-domain accepted the %s output format", format)
+		}
+		if _, err := cli.UpdateDomain("coretech", "coretech.yaml"); err == nil {
+			t.Errorf("update-domain accepted the %s output format", format)
+		}
+	}
+	cli := Zms{OutputFormat: TableOutputFormat}
+	output, err := cli.dumpByFormat(testAccessDomains()["coretech"], nil)
+	if err != nil || output == nil || len(*output) == 0 {
+		t.Errorf("unable to display domain in table format: %v", err)
+	}
+}
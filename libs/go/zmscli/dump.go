@@ -6,6 +6,7 @@ package zmscli
 import (
 	"bytes"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -30,12 +31,14 @@ func (cli Zms) dumpDomain(buf *bytes.Buffer, domain *zms.Domain) {
 	dumpStringValue(buf, indentLevel1, "azure_subscription", domain.AzureSubscription)
 	dumpStringValue(buf, indentLevel1, "application_id", domain.ApplicationId)
 	dumpStringValue(buf, indentLevel1, "business_service", domain.BusinessService)
+	dumpStringValue(buf, indentLevel1, "cert_dns_domain", domain.CertDnsDomain)
 	dumpInt32Value(buf, indentLevel1, "product_id", domain.YpmId)
 	dumpStringValue(buf, indentLevel1, "org", string(domain.Org))
 	dumpBoolValue(buf, indentLevel1, "audit_enabled", domain.AuditEnabled)
 	dumpStringValue(buf, indentLevel1, "user_authority_filter", domain.UserAuthorityFilter)
 	dumpInt32Value(buf, indentLevel1, "member_expiry_days", domain.MemberExpiryDays)
 	dumpInt32Value(buf, indentLevel1, "service_expiry_days", domain.ServiceExpiryDays)
+	dumpInt32Value(buf, indentLevel1, "group_expiry_days", domain.GroupExpiryDays)
 	dumpInt32Value(buf, indentLevel1, "token_expiry_mins", domain.TokenExpiryMins)
 	dumpInt32Value(buf, indentLevel1, "service_cert_expiry_mins", domain.ServiceCertExpiryMins)
 	dumpInt32Value(buf, indentLevel1, "role_cert_expiry_mins", domain.RoleCertExpiryMins)
@@ -285,8 +288,60 @@ func localName(fullResourceName string, prefix string) string {
 }
 
 func (cli Zms) dumpAssertion(buf *bytes.Buffer, assertion *zms.Assertion, dn string, indent1 string) {
-	showFullResourceName := cli.Verbose
+	if assertion.Conditions == nil || len(assertion.Conditions.ConditionsList) == 0 {
+		buf.WriteString(indent1)
+		cli.dumpAssertionString(buf, assertion, dn)
+		return
+	}
+	// assertions with conditions are displayed as an object
+	// with the assertion string and its list of conditions
+	indent2 := strings.Repeat(" ", len(indent1))
 	buf.WriteString(indent1)
+	buf.WriteString("assertion: ")
+	cli.dumpAssertionString(buf, assertion, dn)
+	if assertion.Id != nil {
+		buf.WriteString(indent2)
+		buf.WriteString("id: ")
+		buf.WriteString(strconv.FormatInt(*assertion.Id, 10))
+		buf.WriteString("\n")
+	}
+	buf.WriteString(indent2)
+	buf.WriteString("conditions:\n")
+	for _, condition := range assertion.Conditions.ConditionsList {
+		dumpAssertionCondition(buf, condition, indent2+"  - ")
+	}
+}
+
+func dumpAssertionCondition(buf *bytes.Buffer, condition *zms.AssertionCondition, indent1 string) {
+	indent2 := strings.Repeat(" ", len(indent1))
+	indent := indent1
+	if condition.Id != nil {
+		buf.WriteString(indent)
+		buf.WriteString("id: ")
+		buf.WriteString(strconv.Itoa(int(*condition.Id)))
+		buf.WriteString("\n")
+		indent = indent2
+	}
+	keys := make([]string, 0)
+	for key := range condition.ConditionsMap {
+		keys = append(keys, string(key))
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		data := condition.ConditionsMap[zms.AssertionConditionKey(key)]
+		buf.WriteString(indent)
+		buf.WriteString(key)
+		buf.WriteString(": ")
+		buf.WriteString(data.Operator.String())
+		buf.WriteString(" ")
+		buf.WriteString(string(data.Value))
+		buf.WriteString("\n")
+		indent = indent2
+	}
+}
+
+func (cli Zms) dumpAssertionString(buf *bytes.Buffer, assertion *zms.Assertion, dn string) {
+	showFullResourceName := cli.Verbose
 	effect := "grant"
 	if assertion.Effect != nil {
 		effect = strings.ToLower(assertion.Effect.String())
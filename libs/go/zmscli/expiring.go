@@ -0,0 +1,226 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+const (
+	expiringDueExpiration = "expiration"
+	expiringDueReview     = "review"
+
+	defaultExpiringDays = 30
+)
+
+// ExpiringMember is a role or group membership that expires or
+// is due for review within the requested number of days
+type ExpiringMember struct {
+	Domain      string `json:"domain"`
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Member      string `json:"member"`
+	Due         string `json:"due"`
+	Date        string `json:"date"`
+	Days        int    `json:"days"`
+	NotifyRoles string `json:"notifyRoles,omitempty"`
+}
+
+// ExpiringMembersReport lists all expiring memberships across domains
+type ExpiringMembersReport struct {
+	Days    int               `json:"days"`
+	Members []*ExpiringMember `json:"members"`
+	Errors  []string          `json:"errors,omitempty"`
+}
+
+// expiringMemberDates returns the membership due dates that fall within
+// the cutoff time. Memberships that already expired are no longer active
+// so they're skipped while overdue reviews are always included.
+func expiringMemberDates(expiration, reviewReminder *rdl.Timestamp, now, cutoff time.Time) map[string]time.Time {
+	dates := make(map[string]time.Time)
+	if expiration != nil && expiration.Time.After(now) && !expiration.Time.After(cutoff) {
+		dates[expiringDueExpiration] = expiration.Time
+	}
+	if reviewReminder != nil && !reviewReminder.Time.After(cutoff) {
+		dates[expiringDueReview] = reviewReminder.Time
+	}
+	return dates
+}
+
+func newExpiringMembers(dn, objectType, name, member, notifyRoles string, dates map[string]time.Time, now time.Time) []*ExpiringMember {
+	members := make([]*ExpiringMember, 0)
+	for _, due := range []string{expiringDueExpiration, expiringDueReview} {
+		date, ok := dates[due]
+		if !ok {
+			continue
+		}
+		members = append(members, &ExpiringMember{
+			Domain:      dn,
+			Type:        objectType,
+			Name:        name,
+			Member:      member,
+			Due:         due,
+			Date:        rdl.Timestamp{Time: date}.String(),
+			Days:        int(date.Sub(now).Hours() / 24),
+			NotifyRoles: notifyRoles,
+		})
+	}
+	return members
+}
+
+// expiringMembers returns all the role and group memberships in the domain
+// that expire or are due for review before the cutoff time
+func expiringMembers(dn string, roles []*zms.Role, groups []*zms.Group, now, cutoff time.Time) []*ExpiringMember {
+	members := make([]*ExpiringMember, 0)
+	for _, role := range roles {
+		rn := localName(string(role.Name), ":role.")
+		for _, member := range role.RoleMembers {
+			if isPendingMember(member.Approved) {
+				continue
+			}
+			dates := expiringMemberDates(member.Expiration, member.ReviewReminder, now, cutoff)
+			members = append(members, newExpiringMembers(dn, "role", rn, string(member.MemberName), role.NotifyRoles, dates, now)...)
+		}
+	}
+	for _, group := range groups {
+		gn := localName(string(group.Name), ":group.")
+		for _, member := range group.GroupMembers {
+			if isPendingMember(member.Approved) {
+				continue
+			}
+			dates := expiringMemberDates(member.Expiration, nil, now, cutoff)
+			members = append(members, newExpiringMembers(dn, "group", gn, string(member.MemberName), group.NotifyRoles, dates, now)...)
+		}
+	}
+	return members
+}
+
+// sortExpiringMembers sorts the members by domain, role or group name
+// and due date so the report is grouped by domain and role
+func sortExpiringMembers(members []*ExpiringMember) {
+	sort.SliceStable(members, func(i, j int) bool {
+		m1 := members[i]
+		m2 := members[j]
+		if m1.Domain != m2.Domain {
+			return m1.Domain < m2.Domain
+		}
+		if m1.Type != m2.Type {
+			return m1.Type > m2.Type
+		}
+		if m1.Name != m2.Name {
+			return m1.Name < m2.Name
+		}
+		if m1.Date != m2.Date {
+			return m1.Date < m2.Date
+		}
+		return m1.Member < m2.Member
+	})
+}
+
+// reportDomainNames returns all the domains that match the given
+// prefix and tag processing all pages of the domain list
+func (cli Zms) reportDomainNames(prefix, tagKey, tagValue string) ([]string, error) {
+	names := make([]string, 0)
+	skip := ""
+	for {
+		res, err := cli.Zms.GetDomainList(nil, skip, prefix, nil, "", nil, "", "", "", zms.CompoundName(tagKey), zms.CompoundName(tagValue), "", "")
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range res.Names {
+			names = append(names, string(name))
+		}
+		if res.Next == "" {
+			return names, nil
+		}
+		skip = res.Next
+	}
+}
+
+func (cli Zms) dumpExpiringMembers(buf *bytes.Buffer, report *ExpiringMembersReport) {
+	buf.WriteString("expiring members (within " + strconv.Itoa(report.Days) + " days):\n")
+	domain := ""
+	object := ""
+	for _, member := range report.Members {
+		if member.Domain != domain {
+			buf.WriteString(indentLevel1Dash + "domain: " + member.Domain + "\n")
+			domain = member.Domain
+			object = ""
+		}
+		if member.Type+member.Name != object {
+			buf.WriteString(indentLevel1DashLvl + member.Type + " " + member.Name + ":\n")
+			dumpStringValue(buf, indentLevel1DashLvl+"    ", "notify-roles", member.NotifyRoles)
+			object = member.Type + member.Name
+		}
+		buf.WriteString(indentLevel1DashLvl + "  - " + member.Member + " " + member.Due + " " + member.Date +
+			" (" + strconv.Itoa(member.Days) + " days)\n")
+	}
+	if len(report.Errors) != 0 {
+		buf.WriteString("errors:\n")
+		for _, err := range report.Errors {
+			buf.WriteString(indentLevel1Dash + err + "\n")
+		}
+	}
+}
+
+// ReportExpiringMembers walks all the domains matching the prefix and tag
+// and reports every role and group membership that expires or is due for
+// review within the given number of days
+func (cli Zms) ReportExpiringMembers(days int, prefix, tagKey, tagValue string) (*string, error) {
+	names, err := cli.reportDomainNames(prefix, tagKey, tagValue)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, days)
+	report := ExpiringMembersReport{
+		Days:    days,
+		Members: make([]*ExpiringMember, 0),
+	}
+	members := true
+	for _, dn := range names {
+		if cli.Verbose {
+			_, _ = fmt.Fprintf(os.Stderr, "Processing domain "+dn+"...\n")
+		}
+		roles, err := cli.Zms.GetRoles(zms.DomainName(dn), &members, "", "")
+		if err != nil {
+			report.Errors = append(report.Errors, dn+": "+err.Error())
+			continue
+		}
+		groups, err := cli.Zms.GetGroups(zms.DomainName(dn), &members, "", "")
+		if err != nil {
+			report.Errors = append(report.Errors, dn+": "+err.Error())
+			continue
+		}
+		report.Members = append(report.Members, expiringMembers(dn, roles.List, groups.List, now, cutoff)...)
+	}
+	sortExpiringMembers(report.Members)
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		cli.dumpExpiringMembers(&buf, &report)
+		s := buf.String()
+		return &s, nil
+	}
+
+	return cli.dumpByFormat(report, oldYamlConverter)
+}
+
+// parseTagFilter splits the key=value tag filter into its key and value
+func parseTagFilter(filter string) (string, string) {
+	idx := strings.Index(filter, "=")
+	if idx < 0 {
+		return filter, ""
+	}
+	return filter[:idx], filter[idx+1:]
+}
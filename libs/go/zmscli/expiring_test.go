@@ -0,0 +1,73 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+func TestExpiringMembers(t *testing.T) {
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, 30)
+	timestamp := func(days int) *rdl.Timestamp {
+		return &rdl.Timestamp{Time: now.Add(time.Duration(days)*24*time.Hour + time.Hour)}
+	}
+	pending := false
+	roles := []*zms.Role{
+		{
+			Name:        "coretech:role.readers",
+			NotifyRoles: "coretech:role.admin",
+			RoleMembers: []*zms.RoleMember{
+				{MemberName: "user.john", Expiration: timestamp(10)},
+				{MemberName: "user.jane", Expiration: timestamp(40), ReviewReminder: timestamp(-2)},
+				{MemberName: "user.joe", Expiration: timestamp(-1)},
+				{MemberName: "user.pending", Expiration: timestamp(5), Approved: &pending},
+				{MemberName: "user.bob"},
+			},
+		},
+	}
+	groups := []*zms.Group{
+		{
+			Name:         "coretech:group.devs",
+			GroupMembers: []*zms.GroupMember{{MemberName: "user.john", Expiration: timestamp(20)}},
+		},
+	}
+	members := expiringMembers("coretech", roles, groups, now, cutoff)
+	sortExpiringMembers(members)
+	if len(members) != 3 {
+		t.Fatalf("expected 3 expiring members, got %d", len(members))
+	}
+	expected := []struct {
+		objectType string
+		member     string
+		due        string
+		days       int
+	}{
+		{"role", "user.jane", expiringDueReview, -1},
+		{"role", "user.john", expiringDueExpiration, 10},
+		{"group", "user.john", expiringDueExpiration, 20},
+	}
+	for i, test := range expected {
+		member := members[i]
+		if member.Type != test.objectType || member.Member != test.member || member.Due != test.due || member.Days != test.days {
+			t.Errorf("member %d: unexpected value %+v", i, member)
+		}
+	}
+	if members[0].NotifyRoles != "coretech:role.admin" || members[0].Name != "readers" {
+		t.Errorf("unexpected role details: %+v", members[0])
+	}
+}
+
+func TestParseTagFilter(t *testing.T) {
+	if key, value := parseTagFilter("env=prod"); key != "env" || value != "prod" {
+		t.Errorf("unexpected tag filter: %s=%s", key, value)
+	}
+	if key, value := parseTagFilter("env"); key != "env" || value != "" {
+		t.Errorf("unexpected tag filter: %s=%s", key, value)
+	}
+}
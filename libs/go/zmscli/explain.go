@@ -0,0 +1,380 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+const (
+	accessDecisionAllow    = "ALLOW"
+	accessDecisionDeny     = "DENY"
+	accessDecisionNoMatch  = "NO_MATCH"
+	assumeRoleAction       = "assume_role"
+	maxTrustDelegationHops = 1
+)
+
+// AssertionMatch is an assertion that matched the access check
+// along with the chain of memberships that matched its role
+type AssertionMatch struct {
+	Policy     string   `json:"policy"`
+	Assertion  string   `json:"assertion"`
+	Effect     string   `json:"effect"`
+	Membership []string `json:"membership"`
+	Conditions string   `json:"conditions,omitempty"`
+}
+
+// AccessExplanation is the result of evaluating an access check
+// locally with all the assertions and memberships that were used
+type AccessExplanation struct {
+	Principal string            `json:"principal"`
+	Action    string            `json:"action"`
+	Resource  string            `json:"resource"`
+	Granted   bool              `json:"granted"`
+	Decision  string            `json:"decision"`
+	Matches   []*AssertionMatch `json:"matches"`
+	Ignored   []string          `json:"ignored,omitempty"`
+}
+
+// domainDataLoader returns the domain data object for the given domain
+type domainDataLoader func(dn string) (*zms.DomainData, error)
+
+// accessEvaluator evaluates access checks against domain data objects
+// using the same rules as the server: deny assertions take precedence
+// over allow assertions, role members can be wildcards or groups and
+// delegated roles are resolved using the trusted domain's policies.
+// Domains are loaded only once and cached for all evaluations.
+type accessEvaluator struct {
+	load    domainDataLoader
+	domains map[string]*zms.DomainData
+	now     time.Time
+}
+
+func newAccessEvaluator(load domainDataLoader) *accessEvaluator {
+	return &accessEvaluator{
+		load:    load,
+		domains: make(map[string]*zms.DomainData),
+		now:     time.Now(),
+	}
+}
+
+func (e *accessEvaluator) domainData(dn string) (*zms.DomainData, error) {
+	if domainData, ok := e.domains[dn]; ok {
+		return domainData, nil
+	}
+	domainData, err := e.load(dn)
+	if err != nil {
+		return nil, err
+	}
+	e.domains[dn] = domainData
+	return domainData, nil
+}
+
+// globMatch matches the value against the athenz assertion pattern where
+// '*' matches any number of characters and '?' matches a single character
+func globMatch(pattern, value string) bool {
+	if !strings.ContainsAny(pattern, "*?") {
+		return pattern == value
+	}
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.Replace(expr, "\\*", ".*", -1)
+	expr = strings.Replace(expr, "\\?", ".", -1)
+	matched, err := regexp.MatchString("^"+expr+"$", value)
+	return err == nil && matched
+}
+
+// fullRoleName returns the role name in the domain:role.name format
+// since domain files might include only the local role names
+func fullRoleName(dn, name string) string {
+	if strings.Contains(name, ":") {
+		return name
+	}
+	return dn + ":role." + name
+}
+
+func fullResourceName(dn, name string) string {
+	if strings.Contains(name, ":") {
+		return name
+	}
+	return dn + ":" + name
+}
+
+func activePolicies(domainData *zms.DomainData) []*zms.Policy {
+	policies := make([]*zms.Policy, 0)
+	for _, policy := range domainPolicies(domainData) {
+		if policy.Active == nil || *policy.Active {
+			policies = append(policies, policy)
+		}
+	}
+	return policies
+}
+
+func memberNameMatch(memberName, principal string) bool {
+	if memberName == principal || memberName == "*" {
+		return true
+	}
+	return strings.HasSuffix(memberName, "*") && strings.HasPrefix(principal, memberName[:len(memberName)-1])
+}
+
+// memberState returns why the membership must be ignored or an empty
+// string if the membership is active
+func (e *accessEvaluator) memberState(expiration *rdl.Timestamp, approved *bool, systemDisabled *int32) string {
+	switch {
+	case isPendingMember(approved):
+		return "pending approval"
+	case systemDisabled != nil && *systemDisabled != 0:
+		return "disabled by the system"
+	case expiration != nil && expiration.Time.Before(e.now):
+		return "expired on " + expiration.String()
+	}
+	return ""
+}
+
+func (e *accessEvaluator) groupMembership(groupName, principal string, ignored *[]string) (bool, error) {
+	idx := strings.Index(groupName, ":group.")
+	domainData, err := e.domainData(groupName[:idx])
+	if err != nil {
+		return false, err
+	}
+	for _, group := range domainData.Groups {
+		if localName(string(group.Name), ":group.") != groupName[idx+len(":group."):] {
+			continue
+		}
+		for _, member := range group.GroupMembers {
+			if string(member.MemberName) != principal {
+				continue
+			}
+			if state := e.memberState(member.Expiration, member.Approved, member.SystemDisabled); state != "" {
+				*ignored = append(*ignored, principal+" membership in "+groupName+" "+state)
+				continue
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// roleMembership returns the chain of memberships from the principal to
+// the role or nil if the principal is not an active member of the role
+func (e *accessEvaluator) roleMembership(dn string, role *zms.Role, principal string, hops int, ignored *[]string) ([]string, error) {
+	roleName := fullRoleName(dn, string(role.Name))
+	if role.Trust != "" {
+		if hops >= maxTrustDelegationHops {
+			return nil, nil
+		}
+		return e.trustMembership(string(role.Trust), roleName, principal, hops+1, ignored)
+	}
+	for _, member := range role.RoleMembers {
+		memberName := string(member.MemberName)
+		isGroup := strings.Contains(memberName, ":group.")
+		if !isGroup && !memberNameMatch(memberName, principal) {
+			continue
+		}
+		if state := e.memberState(member.Expiration, member.Approved, member.SystemDisabled); state != "" {
+			*ignored = append(*ignored, memberName+" membership in "+roleName+" "+state)
+			continue
+		}
+		if !isGroup {
+			if memberName == principal {
+				return []string{principal, roleName}, nil
+			}
+			return []string{principal, memberName, roleName}, nil
+		}
+		member, err := e.groupMembership(memberName, principal, ignored)
+		if err != nil {
+			return nil, err
+		}
+		if member {
+			return []string{principal, memberName, roleName}, nil
+		}
+	}
+	return nil, nil
+}
+
+// trustMembership checks if the principal is a member of the delegated
+// role based on the assume_role assertions in the trusted domain
+func (e *accessEvaluator) trustMembership(trustDomain, roleName, principal string, hops int, ignored *[]string) ([]string, error) {
+	domainData, err := e.domainData(trustDomain)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := e.matchAssertions(domainData, principal, assumeRoleAction, roleName, hops, ignored)
+	if err != nil {
+		return nil, err
+	}
+	var membership []string
+	for _, match := range matches {
+		if match.Effect == accessDecisionDeny {
+			return nil, nil
+		}
+		if membership == nil {
+			membership = append([]string{}, match.Membership...)
+			membership = append(membership, roleName+" (delegated by "+match.Policy+")")
+		}
+	}
+	return membership, nil
+}
+
+// matchAssertions returns all the assertions in the domain that match
+// the action and resource and whose role the principal is a member of
+func (e *accessEvaluator) matchAssertions(domainData *zms.DomainData, principal, action, resource string, hops int, ignored *[]string) ([]*AssertionMatch, error) {
+	dn := string(domainData.Name)
+	matches := make([]*AssertionMatch, 0)
+	for _, policy := range activePolicies(domainData) {
+		for _, assertion := range policy.Assertions {
+			if !globMatch(strings.ToLower(assertion.Action), strings.ToLower(action)) {
+				continue
+			}
+			if !globMatch(strings.ToLower(fullResourceName(dn, assertion.Resource)), strings.ToLower(resource)) {
+				continue
+			}
+			effect := accessDecisionAllow
+			if assertion.Effect != nil && assertion.Effect.String() == accessDecisionDeny {
+				effect = accessDecisionDeny
+			}
+			assertionRole := fullRoleName(dn, assertion.Role)
+			for _, role := range domainData.Roles {
+				if !globMatch(assertionRole, fullRoleName(dn, string(role.Name))) {
+					continue
+				}
+				membership, err := e.roleMembership(dn, role, principal, hops, ignored)
+				if err != nil {
+					return nil, err
+				}
+				if membership == nil {
+					continue
+				}
+				matches = append(matches, &AssertionMatch{
+					Policy:     fullPolicyName(dn, string(policy.Name)),
+					Assertion:  assertionString(dn, assertion),
+					Effect:     effect,
+					Membership: membership,
+					Conditions: assertionConditionsString(assertion.Conditions),
+				})
+				break
+			}
+		}
+	}
+	return matches, nil
+}
+
+func fullPolicyName(dn, name string) string {
+	if strings.Contains(name, ":") {
+		return name
+	}
+	return dn + ":policy." + name
+}
+
+// explain evaluates the access check and returns the decision along
+// with all the matching assertions and ignored memberships
+func (e *accessEvaluator) explain(principal, action, resource string) (*AccessExplanation, error) {
+	explanation := &AccessExplanation{
+		Principal: principal,
+		Action:    action,
+		Resource:  resource,
+		Decision:  accessDecisionNoMatch,
+		Matches:   make([]*AssertionMatch, 0),
+	}
+	idx := strings.Index(resource, ":")
+	if idx < 0 {
+		return explanation, nil
+	}
+	domainData, err := e.domainData(resource[:idx])
+	if err != nil {
+		return nil, err
+	}
+	ignored := make([]string, 0)
+	explanation.Matches, err = e.matchAssertions(domainData, principal, action, resource, 0, &ignored)
+	if err != nil {
+		return nil, err
+	}
+	for _, match := range explanation.Matches {
+		if match.Effect == accessDecisionDeny {
+			explanation.Decision = accessDecisionDeny
+			break
+		}
+		explanation.Decision = accessDecisionAllow
+	}
+	explanation.Granted = explanation.Decision == accessDecisionAllow
+	explanation.Ignored = uniqueStrings(ignored)
+	return explanation, nil
+}
+
+func uniqueStrings(values []string) []string {
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if indexOfString(unique, value) == -1 {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+func (cli Zms) dumpAccessExplanation(buf *bytes.Buffer, explanation *AccessExplanation) {
+	dumpStringValue(buf, "", "principal", explanation.Principal)
+	dumpStringValue(buf, "", "action", explanation.Action)
+	dumpStringValue(buf, "", "resource", explanation.Resource)
+	if explanation.Granted {
+		buf.WriteString("access: granted\n")
+	} else {
+		buf.WriteString("access: denied\n")
+	}
+	switch explanation.Decision {
+	case accessDecisionAllow:
+		buf.WriteString("decision: allowed by a matching assertion and no matching deny assertions\n")
+	case accessDecisionDeny:
+		buf.WriteString("decision: denied by a matching deny assertion\n")
+	default:
+		buf.WriteString("decision: denied since no assertion matched the request\n")
+	}
+	if len(explanation.Matches) != 0 {
+		buf.WriteString("assertions:\n")
+		for _, match := range explanation.Matches {
+			dumpStringValue(buf, indentLevel1Dash, "policy", match.Policy)
+			dumpStringValue(buf, indentLevel1DashLvl, "assertion", match.Assertion)
+			dumpStringValue(buf, indentLevel1DashLvl, "effect", match.Effect)
+			dumpStringValue(buf, indentLevel1DashLvl, "membership", strings.Join(match.Membership, " -> "))
+			dumpStringValue(buf, indentLevel1DashLvl, "conditions", match.Conditions)
+		}
+	}
+	if len(explanation.Ignored) != 0 {
+		buf.WriteString("ignored memberships:\n")
+		for _, ignored := range explanation.Ignored {
+			buf.WriteString(indentLevel1Dash + ignored + "\n")
+		}
+	}
+}
+
+// ExplainAccess evaluates the access check locally using the domain data
+// retrieved from the server and displays the assertions and memberships
+// that determined the decision
+func (cli Zms) ExplainAccess(dn string, action string, resource string, altIdent *string) (*string, error) {
+	fullResourceName, _, principal, err := cli.getAccessParameters(dn, action, resource, altIdent, nil)
+	if err != nil {
+		return nil, err
+	}
+	if principal == "" {
+		principal = cli.validatedUser(cli.Identity)
+	}
+	evaluator := newAccessEvaluator(cli.liveDomainData)
+	explanation, err := evaluator.explain(principal, action, fullResourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		cli.dumpAccessExplanation(&buf, explanation)
+		s := buf.String()
+		return &s, nil
+	}
+
+	return cli.dumpByFormat(explanation, oldYamlConverter)
+}
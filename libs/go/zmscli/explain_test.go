@@ -0,0 +1,146 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+func testAccessDomains() map[string]*zms.DomainData {
+	expired := rdl.Timestamp{Time: time.Now().Add(-time.Hour)}
+	allow := zms.ALLOW
+	deny := zms.DENY
+	return map[string]*zms.DomainData{
+		"coretech": {
+			Name: "coretech",
+			Roles: []*zms.Role{
+				{
+					Name: "coretech:role.readers",
+					RoleMembers: []*zms.RoleMember{
+						{MemberName: "user.jane", Expiration: &expired},
+						{MemberName: "coretech:group.devs"},
+					},
+				},
+				{
+					Name:        "coretech:role.blocked",
+					RoleMembers: []*zms.RoleMember{{MemberName: "user.joe"}},
+				},
+				{Name: "coretech:role.partners", Trust: "sports"},
+			},
+			Groups: []*zms.Group{
+				{
+					Name:         "coretech:group.devs",
+					GroupMembers: []*zms.GroupMember{{MemberName: "user.john"}, {MemberName: "user.joe"}},
+				},
+			},
+			Policies: &zms.SignedPolicies{
+				Contents: &zms.DomainPolicies{
+					Domain: "coretech",
+					Policies: []*zms.Policy{
+						{
+							Name: "coretech:policy.readers",
+							Assertions: []*zms.Assertion{
+								{Role: "coretech:role.readers", Action: "read", Resource: "coretech:articles.*", Effect: &allow},
+								{Role: "coretech:role.partners", Action: "read", Resource: "coretech:articles.public", Effect: &allow},
+							},
+						},
+						{
+							Name: "coretech:policy.blocked",
+							Assertions: []*zms.Assertion{
+								{Role: "coretech:role.blocked", Action: "*", Resource: "coretech:*", Effect: &deny},
+							},
+						},
+					},
+				},
+			},
+		},
+		"sports": {
+			Name: "sports",
+			Roles: []*zms.Role{
+				{Name: "sports:role.api", RoleMembers: []*zms.RoleMember{{MemberName: "sports.*"}}},
+			},
+			Policies: &zms.SignedPolicies{
+				Contents: &zms.DomainPolicies{
+					Domain: "sports",
+					Policies: []*zms.Policy{
+						{
+							Name: "sports:policy.trust",
+							Assertions: []*zms.Assertion{
+								{Role: "sports:role.api", Action: "assume_role", Resource: "coretech:role.partners", Effect: &allow},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestExplainAccess(t *testing.T) {
+	domains := testAccessDomains()
+	evaluator := newAccessEvaluator(func(dn string) (*zms.DomainData, error) {
+		if domainData, ok := domains[dn]; ok {
+			return domainData, nil
+		}
+		return nil, fmt.Errorf("unknown domain %s", dn)
+	})
+
+	tests := []struct {
+		principal  string
+		action     string
+		resource   string
+		decision   string
+		membership string
+		ignored    int
+	}{
+		{"user.john", "read", "coretech:articles.sports", accessDecisionAllow, "user.john coretech:group.devs coretech:role.readers", 0},
+		{"user.jane", "read", "coretech:articles.sports", accessDecisionNoMatch, "", 1},
+		{"user.joe", "read", "coretech:articles.sports", accessDecisionDeny, "user.joe coretech:role.blocked", 0},
+		{"sports.api", "read", "coretech:articles.public", accessDecisionAllow, "sports.api sports.* sports:role.api coretech:role.partners (delegated by sports:policy.trust)", 0},
+		{"user.john", "write", "coretech:articles.sports", accessDecisionNoMatch, "", 0},
+	}
+	for _, test := range tests {
+		explanation, err := evaluator.explain(test.principal, test.action, test.resource)
+		if err != nil {
+			t.Fatalf("unable to explain access: %v", err)
+		}
+		if explanation.Decision != test.decision || explanation.Granted != (test.decision == accessDecisionAllow) {
+			t.Errorf("%s %s %s: expected %s, got %s", test.principal, test.action, test.resource, test.decision, explanation.Decision)
+			continue
+		}
+		if len(explanation.Ignored) != test.ignored {
+			t.Errorf("%s: unexpected ignored memberships: %v", test.principal, explanation.Ignored)
+		}
+		if test.membership == "" {
+			continue
+		}
+		membership := ""
+		for _, match := range explanation.Matches {
+			if match.Effect == test.decision {
+				membership = fmt.Sprint(match.Membership)
+				break
+			}
+		}
+		if membership != "["+test.membership+"]" {
+			t.Errorf("%s: unexpected membership %s", test.principal, membership)
+		}
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	if !globMatch("coretech:articles.*", "coretech:articles.sports") {
+		t.Error("wildcard pattern did not match")
+	}
+	if !globMatch("node?", "node1") || globMatch("node?", "node12") {
+		t.Error("single character pattern did not match correctly")
+	}
+	if globMatch("coretech:(articles)", "coretech:articles") {
+		t.Error("regex characters must not be interpreted")
+	}
+}
@@ -0,0 +1,480 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+const (
+	graphNodeDomain    = "domain"
+	graphNodeRole      = "role"
+	graphNodeGroup     = "group"
+	graphNodePolicy    = "policy"
+	graphNodeService   = "service"
+	graphNodePrincipal = "principal"
+
+	graphEdgeMember     = "member"
+	graphEdgeTrust      = "trust"
+	graphEdgeAssertion  = "assertion"
+	graphEdgeAssumeRole = "assume_role"
+	graphEdgeTenancy    = "tenancy"
+	graphEdgeDependency = "dependency"
+
+	graphFormatDot     = "dot"
+	graphFormatMermaid = "mermaid"
+	graphFormatJSON    = "json"
+)
+
+// GraphNode is a role, group, policy, service, principal or domain
+type GraphNode struct {
+	Id     string `json:"id"`
+	Type   string `json:"type"`
+	Label  string `json:"label"`
+	Domain string `json:"domain"`
+}
+
+// GraphEdge is a relationship between two nodes in the graph
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Type  string `json:"type"`
+	Label string `json:"label,omitempty"`
+}
+
+// DomainGraph is the authorization graph of a domain and all
+// the domains it references up to the requested depth
+type DomainGraph struct {
+	Domain string       `json:"domain"`
+	Depth  int          `json:"depth"`
+	Nodes  []*GraphNode `json:"nodes"`
+	Edges  []*GraphEdge `json:"edges"`
+}
+
+type graphBuilder struct {
+	cli   Zms
+	graph *DomainGraph
+	nodes map[string]*GraphNode
+	edges map[string]*GraphEdge
+}
+
+func newGraphBuilder(cli Zms, dn string, depth int) *graphBuilder {
+	return &graphBuilder{
+		cli: cli,
+		graph: &DomainGraph{
+			Domain: dn,
+			Depth:  depth,
+			Nodes:  make([]*GraphNode, 0),
+			Edges:  make([]*GraphEdge, 0),
+		},
+		nodes: make(map[string]*GraphNode),
+		edges: make(map[string]*GraphEdge),
+	}
+}
+
+func (g *graphBuilder) addNode(id, nodeType, label, dn string) *GraphNode {
+	if node, ok := g.nodes[id]; ok {
+		return node
+	}
+	node := &GraphNode{Id: id, Type: nodeType, Label: label, Domain: dn}
+	g.nodes[id] = node
+	g.graph.Nodes = append(g.graph.Nodes, node)
+	return node
+}
+
+// addEdge adds the edge between the nodes. If the edge already exists,
+// the label is appended to the existing edge's list of labels.
+func (g *graphBuilder) addEdge(from, to, edgeType, label string) {
+	key := from + "|" + to + "|" + edgeType
+	if edge, ok := g.edges[key]; ok {
+		if label != "" && indexOfString(strings.Split(edge.Label, ", "), label) == -1 {
+			edge.Label += ", " + label
+		}
+		return
+	}
+	edge := &GraphEdge{From: from, To: to, Type: edgeType, Label: label}
+	g.edges[key] = edge
+	g.graph.Edges = append(g.graph.Edges, edge)
+}
+
+func (g *graphBuilder) addDomainNode(dn string) string {
+	g.addNode(dn, graphNodeDomain, dn, dn)
+	return dn
+}
+
+func (g *graphBuilder) addRoleNode(roleName string) string {
+	idx := strings.Index(roleName, ":role.")
+	if idx < 0 {
+		return g.addDomainNode(roleName)
+	}
+	g.addNode(roleName, graphNodeRole, roleName[idx+len(":role."):], roleName[:idx])
+	return roleName
+}
+
+func (g *graphBuilder) addGroupNode(groupName string) string {
+	idx := strings.Index(groupName, ":group.")
+	g.addNode(groupName, graphNodeGroup, groupName[idx+len(":group."):], groupName[:idx])
+	return groupName
+}
+
+func (g *graphBuilder) addServiceNode(serviceName string) string {
+	idx := strings.LastIndex(serviceName, ".")
+	dn := ""
+	if idx > 0 {
+		dn = serviceName[:idx]
+	}
+	g.addNode(serviceName, graphNodeService, serviceName[idx+1:], dn)
+	return serviceName
+}
+
+// isUserPrincipal returns true for user and home domain principals and
+// wildcards which are not displayed as separate nodes in the graph
+func (g *graphBuilder) isUserPrincipal(memberName string) bool {
+	return strings.Contains(memberName, "*") ||
+		strings.HasPrefix(memberName, g.cli.UserDomain+".") ||
+		(g.cli.HomeDomain != "" && strings.HasPrefix(memberName, g.cli.HomeDomain+"."))
+}
+
+// addMember adds the membership edge and returns the domain of the
+// member if it's a group or service from another domain
+func (g *graphBuilder) addMember(dn, memberName, target string) string {
+	if strings.Contains(memberName, ":group.") {
+		g.addEdge(g.addGroupNode(memberName), target, graphEdgeMember, "")
+		return principalDomain(memberName)
+	}
+	if g.isUserPrincipal(memberName) {
+		return ""
+	}
+	id := memberName
+	if _, ok := g.nodes[id]; !ok {
+		g.addNode(id, graphNodePrincipal, memberName, principalDomain(memberName))
+	}
+	g.addEdge(id, target, graphEdgeMember, "")
+	if domain := principalDomain(memberName); domain != dn {
+		return domain
+	}
+	return ""
+}
+
+func userCountLabel(label string, users int) string {
+	if users == 0 {
+		return label
+	}
+	return label + " (" + strconv.Itoa(users) + " users)"
+}
+
+func (g *graphBuilder) addRoles(dn string, roles []*zms.Role, referenced map[string]bool) {
+	for _, role := range roles {
+		roleName := g.addRoleNode(fullRoleName(dn, string(role.Name)))
+		if role.Trust != "" {
+			g.addEdge(roleName, g.addDomainNode(string(role.Trust)), graphEdgeTrust, "")
+			referenced[string(role.Trust)] = true
+		}
+		users := 0
+		for _, member := range role.RoleMembers {
+			memberName := string(member.MemberName)
+			if g.isUserPrincipal(memberName) {
+				users++
+				continue
+			}
+			if domain := g.addMember(dn, memberName, roleName); domain != "" {
+				referenced[domain] = true
+			}
+		}
+		node := g.nodes[roleName]
+		node.Label = userCountLabel(node.Label, users)
+	}
+}
+
+func (g *graphBuilder) addGroups(dn string, groups []*zms.Group, referenced map[string]bool) {
+	for _, group := range groups {
+		groupName := g.addGroupNode(string(group.Name))
+		users := 0
+		for _, member := range group.GroupMembers {
+			memberName := string(member.MemberName)
+			if g.isUserPrincipal(memberName) {
+				users++
+				continue
+			}
+			if domain := g.addMember(dn, memberName, groupName); domain != "" {
+				referenced[domain] = true
+			}
+		}
+		node := g.nodes[groupName]
+		node.Label = userCountLabel(node.Label, users)
+	}
+}
+
+// tenancyProvider returns the provider service of the tenancy policy. The
+// policies are named tenancy.<provider>.<action> for the tenant domain and
+// tenancy.<provider>.res_group.<resource-group>.<action> for its resource
+// groups. Returns an empty string for any other policy name.
+func tenancyProvider(pn string) string {
+	if !strings.HasPrefix(pn, "tenancy.") {
+		return ""
+	}
+	provider := strings.TrimPrefix(pn, "tenancy.")
+	idx := strings.LastIndex(provider, ".")
+	if idx < 0 {
+		return ""
+	}
+	provider = provider[:idx]
+	if idx = strings.Index(provider, ".res_group."); idx >= 0 {
+		provider = provider[:idx]
+	}
+	// the provider is a service so it must include its domain name
+	if !strings.Contains(provider, ".") {
+		return ""
+	}
+	return provider
+}
+
+func (g *graphBuilder) addPolicies(dn string, policies []*zms.Policy, referenced map[string]bool) {
+	for _, policy := range policies {
+		policyName := fullPolicyName(dn, string(policy.Name))
+		pn := localName(policyName, ":policy.")
+		g.addNode(policyName, graphNodePolicy, pn, dn)
+		if provider := tenancyProvider(pn); provider != "" {
+			g.addEdge(g.addDomainNode(dn), g.addServiceNode(provider), graphEdgeTenancy, "")
+			referenced[principalDomain(provider)] = true
+		}
+		for _, assertion := range policy.Assertions {
+			roleName := fullRoleName(dn, assertion.Role)
+			if strings.ContainsAny(roleName, "*?") {
+				continue
+			}
+			g.addEdge(policyName, g.addRoleNode(roleName), graphEdgeAssertion, strings.ToLower(assertion.Action))
+			resource := fullResourceName(dn, assertion.Resource)
+			resourceDomain := resource[:strings.Index(resource, ":")]
+			if strings.ToLower(assertion.Action) != assumeRoleAction || resourceDomain == dn || strings.ContainsAny(resourceDomain, "*?") {
+				continue
+			}
+			// assume_role assertions for roles in other domains are the
+			// trust side of delegated roles and tenancy relationships
+			target := g.addDomainNode(resourceDomain)
+			if strings.Contains(resource, ":role.") && !strings.ContainsAny(resource, "*?") {
+				target = g.addRoleNode(resource)
+			}
+			g.addEdge(roleName, target, graphEdgeAssumeRole, "")
+			referenced[resourceDomain] = true
+		}
+	}
+}
+
+func (g *graphBuilder) addDependencies(dn string, referenced map[string]bool) {
+	dependentServices, err := g.cli.Zms.GetDependentServiceList(zms.DomainName(dn))
+	if err == nil {
+		for _, service := range dependentServices.Names {
+			g.addEdge(g.addDomainNode(dn), g.addServiceNode(string(service)), graphEdgeDependency, "")
+			referenced[principalDomain(string(service))] = true
+		}
+	}
+	services, err := g.cli.serviceNames(dn)
+	if err != nil {
+		return
+	}
+	for _, service := range services {
+		serviceName := g.addServiceNode(dn + "." + service)
+		dependentDomains, err := g.cli.Zms.GetDependentDomainList(zms.ServiceName(serviceName))
+		if err != nil {
+			continue
+		}
+		for _, domain := range dependentDomains.Names {
+			g.addEdge(g.addDomainNode(string(domain)), serviceName, graphEdgeDependency, "")
+			referenced[string(domain)] = true
+		}
+	}
+}
+
+// addDomain adds all the objects of the domain to the graph and
+// returns the list of other domains referenced by those objects
+func (g *graphBuilder) addDomain(dn string) ([]string, error) {
+	members := true
+	roles, err := g.cli.Zms.GetRoles(zms.DomainName(dn), &members, "", "")
+	if err != nil {
+		return nil, err
+	}
+	groups, err := g.cli.Zms.GetGroups(zms.DomainName(dn), &members, "", "")
+	if err != nil {
+		return nil, err
+	}
+	assertions := true
+	includeNonActive := false
+	policies, err := g.cli.Zms.GetPolicies(zms.DomainName(dn), &assertions, &includeNonActive)
+	if err != nil {
+		return nil, err
+	}
+	g.addDomainNode(dn)
+	referenced := make(map[string]bool)
+	g.addRoles(dn, roles.List, referenced)
+	g.addGroups(dn, groups.List, referenced)
+	g.addPolicies(dn, policies.List, referenced)
+	g.addDependencies(dn, referenced)
+
+	domains := make([]string, 0)
+	for domain := range referenced {
+		if domain != "" && domain != dn {
+			domains = append(domains, domain)
+		}
+	}
+	sort.Strings(domains)
+	return domains, nil
+}
+
+// build adds the domain and then expands into the referenced domains
+// level by level until the requested depth is reached
+func (g *graphBuilder) build() error {
+	visited := map[string]bool{g.graph.Domain: true}
+	level := []string{g.graph.Domain}
+	for depth := 0; len(level) != 0 && depth <= g.graph.Depth; depth++ {
+		next := make([]string, 0)
+		for _, dn := range level {
+			domains, err := g.addDomain(dn)
+			if err != nil {
+				// referenced domains might not be accessible so
+				// only the requested domain must be processed
+				if dn == g.graph.Domain {
+					return err
+				}
+				continue
+			}
+			for _, domain := range domains {
+				if !visited[domain] {
+					visited[domain] = true
+					next = append(next, domain)
+				}
+			}
+		}
+		level = next
+	}
+	return nil
+}
+
+func graphNodeShape(nodeType string) string {
+	switch nodeType {
+	case graphNodeDomain:
+		return "folder"
+	case graphNodeGroup:
+		return "ellipse"
+	case graphNodePolicy:
+		return "note"
+	case graphNodeService, graphNodePrincipal:
+		return "component"
+	}
+	return "box"
+}
+
+func dotQuote(value string) string {
+	return strconv.Quote(value)
+}
+
+// graphDomains returns the nodes grouped by domain so they can be
+// displayed as clusters with the domain names sorted
+func graphDomains(graph *DomainGraph) ([]string, map[string][]*GraphNode) {
+	nodes := make(map[string][]*GraphNode)
+	domains := make([]string, 0)
+	for _, node := range graph.Nodes {
+		if _, ok := nodes[node.Domain]; !ok {
+			domains = append(domains, node.Domain)
+		}
+		nodes[node.Domain] = append(nodes[node.Domain], node)
+	}
+	sort.Strings(domains)
+	return domains, nodes
+}
+
+func dotGraph(graph *DomainGraph) string {
+	var buf bytes.Buffer
+	buf.WriteString("digraph " + dotQuote(graph.Domain) + " {\n")
+	buf.WriteString("    rankdir=LR;\n")
+	domains, nodes := graphDomains(graph)
+	for i, dn := range domains {
+		buf.WriteString("    subgraph cluster_" + strconv.Itoa(i) + " {\n")
+		buf.WriteString("        label=" + dotQuote(dn) + ";\n")
+		for _, node := range nodes[dn] {
+			buf.WriteString("        " + dotQuote(node.Id) + " [label=" + dotQuote(node.Type+": "+node.Label) +
+				", shape=" + graphNodeShape(node.Type) + "];\n")
+		}
+		buf.WriteString("    }\n")
+	}
+	for _, edge := range graph.Edges {
+		label := edge.Type
+		if edge.Label != "" {
+			label += ": " + edge.Label
+		}
+		style := ""
+		if edge.Type == graphEdgeTrust || edge.Type == graphEdgeAssumeRole || edge.Type == graphEdgeTenancy || edge.Type == graphEdgeDependency {
+			style = ", style=dashed"
+		}
+		buf.WriteString("    " + dotQuote(edge.From) + " -> " + dotQuote(edge.To) + " [label=" + dotQuote(label) + style + "];\n")
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+func mermaidQuote(value string) string {
+	return "\"" + strings.Replace(value, "\"", "#quot;", -1) + "\""
+}
+
+func mermaidGraph(graph *DomainGraph) string {
+	var buf bytes.Buffer
+	buf.WriteString("flowchart LR\n")
+	// mermaid node ids can't include the special characters used
+	// in athenz names so the nodes are assigned generated ids
+	ids := make(map[string]string)
+	for i, node := range graph.Nodes {
+		ids[node.Id] = "n" + strconv.Itoa(i)
+	}
+	domains, nodes := graphDomains(graph)
+	for i, dn := range domains {
+		buf.WriteString("    subgraph d" + strconv.Itoa(i) + " [" + mermaidQuote(dn) + "]\n")
+		for _, node := range nodes[dn] {
+			buf.WriteString("        " + ids[node.Id] + "[" + mermaidQuote(node.Type+": "+node.Label) + "]\n")
+		}
+		buf.WriteString("    end\n")
+	}
+	for _, edge := range graph.Edges {
+		label := edge.Type
+		if edge.Label != "" {
+			label += ": " + edge.Label
+		}
+		arrow := " -->|"
+		if edge.Type == graphEdgeTrust || edge.Type == graphEdgeAssumeRole || edge.Type == graphEdgeTenancy || edge.Type == graphEdgeDependency {
+			arrow = " -.->|"
+		}
+		buf.WriteString("    " + ids[edge.From] + arrow + mermaidQuote(label) + "| " + ids[edge.To] + "\n")
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// GraphDomain generates the authorization graph of the domain with its
+// roles, groups, policies and services along with membership, delegation,
+// tenancy and dependency relationships. Referenced domains are expanded
+// up to the given depth.
+func (cli Zms) GraphDomain(dn string, depth int, format string) (*string, error) {
+	if format != graphFormatDot && format != graphFormatMermaid && format != graphFormatJSON {
+		return nil, fmt.Errorf("unsupported graph format %s", format)
+	}
+	builder := newGraphBuilder(cli, dn, depth)
+	err := builder.build()
+	if err != nil {
+		return nil, err
+	}
+	var output string
+	switch format {
+	case graphFormatMermaid:
+		output = mermaidGraph(builder.graph)
+	case graphFormatJSON:
+		return cli.buildJSONOutput(builder.graph)
+	default:
+		output = dotGraph(builder.graph)
+	}
+	return &output, nil
+}
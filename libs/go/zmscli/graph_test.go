@@ -0,0 +1,156 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+func testGraph() *graphBuilder {
+	cli := Zms{UserDomain: "user"}
+	builder := newGraphBuilder(cli, "coretech", 1)
+	for _, dn := range []string{"coretech", "sports"} {
+		domainData := testAccessDomains()[dn]
+		referenced := make(map[string]bool)
+		builder.addDomainNode(dn)
+		builder.addRoles(dn, domainData.Roles, referenced)
+		builder.addGroups(dn, domainData.Groups, referenced)
+		builder.addPolicies(dn, domainPolicies(domainData), referenced)
+	}
+	return builder
+}
+
+func findGraphEdge(graph *DomainGraph, from, to, edgeType string) *GraphEdge {
+	for _, edge := range graph.Edges {
+		if edge.From == from && edge.To == to && edge.Type == edgeType {
+			return edge
+		}
+	}
+	return nil
+}
+
+func TestGraphDomainObjects(t *testing.T) {
+	builder := testGraph()
+	graph := builder.graph
+
+	node := builder.nodes["coretech:role.readers"]
+	if node == nil || node.Type != graphNodeRole || node.Label != "readers (1 users)" || node.Domain != "coretech" {
+		t.Errorf("unexpected readers role node: %v", node)
+	}
+	node = builder.nodes["coretech:group.devs"]
+	if node == nil || node.Type != graphNodeGroup || node.Label != "devs (2 users)" {
+		t.Errorf("unexpected devs group node: %v", node)
+	}
+	// user members and wildcards are not separate nodes
+	for _, name := range []string{"user.jane", "user.john", "sports.*"} {
+		if builder.nodes[name] != nil {
+			t.Errorf("unexpected node for member %s", name)
+		}
+	}
+
+	if findGraphEdge(graph, "coretech:group.devs", "coretech:role.readers", graphEdgeMember) == nil {
+		t.Error("missing group membership edge")
+	}
+	if findGraphEdge(graph, "coretech:role.partners", "sports", graphEdgeTrust) == nil {
+		t.Error("missing trust edge")
+	}
+	if findGraphEdge(graph, "sports:role.api", "coretech:role.partners", graphEdgeAssumeRole) == nil {
+		t.Error("missing assume_role edge")
+	}
+	edge := findGraphEdge(graph, "coretech:policy.blocked", "coretech:role.blocked", graphEdgeAssertion)
+	if edge == nil || edge.Label != "*" {
+		t.Errorf("unexpected assertion edge: %v", edge)
+	}
+}
+
+func TestGraphEdgeLabels(t *testing.T) {
+	builder := newGraphBuilder(Zms{UserDomain: "user"}, "coretech", 0)
+	builder.addEdge("a", "b", graphEdgeAssertion, "read")
+	builder.addEdge("a", "b", graphEdgeAssertion, "update")
+	builder.addEdge("a", "b", graphEdgeAssertion, "read")
+	builder.addEdge("a", "b", graphEdgeMember, "")
+	if len(builder.graph.Edges) != 2 {
+		t.Fatalf("expected 2 edges, got %d", len(builder.graph.Edges))
+	}
+	if builder.graph.Edges[0].Label != "read, update" {
+		t.Errorf("unexpected edge label: %s", builder.graph.Edges[0].Label)
+	}
+}
+
+func TestGraphMembers(t *testing.T) {
+	builder := newGraphBuilder(Zms{UserDomain: "user", HomeDomain: "home"}, "coretech", 1)
+	referenced := make(map[string]bool)
+	target := builder.addRoleNode("coretech:role.readers")
+	for _, member := range []string{"home.jane.api", "user.john", "sports.api", "coretech.backend", "weather:group.ops"} {
+		if domain := builder.addMember("coretech", member, target); domain != "" {
+			referenced[domain] = true
+		}
+	}
+	if len(referenced) != 2 || !referenced["sports"] || !referenced["weather"] {
+		t.Errorf("unexpected referenced domains: %v", referenced)
+	}
+	if builder.nodes["home.jane.api"] != nil {
+		t.Error("home domain principals must not be displayed")
+	}
+	if node := builder.nodes["sports.api"]; node == nil || node.Type != graphNodePrincipal || node.Domain != "sports" {
+		t.Errorf("unexpected service member node: %v", node)
+	}
+}
+
+func TestTenancyProvider(t *testing.T) {
+	tests := map[string]string{
+		"tenancy.sports.storage.reader":                      "sports.storage",
+		"tenancy.sports.sub.storage.res_group.forecast.read": "sports.sub.storage",
+		"tenancy.storage.admin":                              "",
+		"readers":                                            "",
+	}
+	for pn, provider := range tests {
+		if value := tenancyProvider(pn); value != provider {
+			t.Errorf("%s: expected provider %q, got %q", pn, provider, value)
+		}
+	}
+
+	builder := newGraphBuilder(Zms{UserDomain: "user"}, "coretech", 0)
+	referenced := make(map[string]bool)
+	builder.addPolicies("coretech", []*zms.Policy{{Name: "coretech:policy.tenancy.sports.storage.res_group.forecast.writer"}}, referenced)
+	if findGraphEdge(builder.graph, "coretech", "sports.storage", graphEdgeTenancy) == nil || !referenced["sports"] {
+		t.Error("missing tenancy edge for the resource group policy")
+	}
+}
+
+func TestGraphFormats(t *testing.T) {
+	graph := testGraph().graph
+
+	dot := dotGraph(graph)
+	if !strings.HasPrefix(dot, "digraph \"coretech\" {") || !strings.HasSuffix(dot, "}") {
+		t.Errorf("unexpected dot graph: %s", dot)
+	}
+	if !strings.Contains(dot, "\"coretech:role.partners\" -> \"sports\" [label=\"trust\", style=dashed];") {
+		t.Errorf("missing trust edge in dot graph: %s", dot)
+	}
+	if !strings.Contains(dot, "label=\"sports\";") {
+		t.Errorf("missing sports cluster in dot graph: %s", dot)
+	}
+
+	mermaid := mermaidGraph(graph)
+	if !strings.HasPrefix(mermaid, "flowchart LR\n") {
+		t.Errorf("unexpected mermaid graph: %s", mermaid)
+	}
+	if !strings.Contains(mermaid, "    subgraph d0 [\"coretech\"]\n        n0[\"domain: coretech\"]\n") {
+		t.Errorf("unexpected coretech subgraph in mermaid graph: %s", mermaid)
+	}
+	if !strings.Contains(mermaid, " -.->|\"trust\"| ") {
+		t.Errorf("missing trust edge in mermaid graph: %s", mermaid)
+	}
+}
+
+func TestGraphDomainInvalidFormat(t *testing.T) {
+	cli := Zms{UserDomain: "user"}
+	if _, err := cli.GraphDomain("coretech", 1, "png"); err == nil {
+		t.Error("expected error for unsupported graph format")
+	}
+}
@@ -346,6 +346,10 @@ func (cli Zms) SetGroupSelfServe(dn string, gn string, selfServe bool) (*string,
 }
 
 func (cli Zms) SetGroupUserAuthorityFilter(dn string, gn, filter string) (*string, error) {
+	err := cli.validateUserAuthorityAttributes(userAuthorityTypeBool, filter)
+	if err != nil {
+		return nil, err
+	}
 	group, err := cli.Zms.GetGroup(zms.DomainName(dn), zms.EntityName(gn), nil, nil)
 	if err != nil {
 		return nil, err
@@ -367,6 +371,10 @@ func (cli Zms) SetGroupUserAuthorityFilter(dn string, gn, filter string) (*strin
 }
 
 func (cli Zms) SetGroupUserAuthorityExpiration(dn string, gn, filter string) (*string, error) {
+	err := cli.validateUserAuthorityAttributes(userAuthorityTypeDate, filter)
+	if err != nil {
+		return nil, err
+	}
 	group, err := cli.Zms.GetGroup(zms.DomainName(dn), zms.EntityName(gn), nil, nil)
 	if err != nil {
 		return nil, err
@@ -433,6 +441,21 @@ func (cli Zms) PutGroupMembershipDecision(dn string, group string, mbr string, a
 	return cli.dumpByFormat(message, cli.buildYAMLOutput)
 }
 
+func (cli Zms) DeletePendingGroupMembership(dn string, group string, mbr string) (*string, error) {
+	validatedUser := cli.validatedUser(mbr)
+	err := cli.Zms.DeletePendingGroupMembership(zms.DomainName(dn), zms.EntityName(group), zms.GroupMemberName(validatedUser), cli.AuditRef)
+	if err != nil {
+		return nil, err
+	}
+	s := "[domain " + dn + " group " + group + " pending member " + validatedUser + " successfully deleted]\n"
+	message := SuccessMessage{
+		Status:  200,
+		Message: s,
+	}
+
+	return cli.dumpByFormat(message, cli.buildYAMLOutput)
+}
+
 func (cli Zms) ListPendingDomainGroupMembers(principal, domainName string) (*string, error) {
 	domainMembership, err := cli.Zms.GetPendingDomainGroupMembersList(zms.EntityName(principal), domainName)
 	if err != nil {
@@ -530,7 +553,7 @@ func (cli Zms) DeleteGroupTags(dn string, gn, tagKey string, tagValue string) (*
 }
 
 func (cli Zms) ShowGroups(dn string, tagKey string, tagValue string) (*string, error) {
-	if cli.OutputFormat == JSONOutputFormat || cli.OutputFormat == YAMLOutputFormat {
+	if cli.OutputFormat != DefaultOutputFormat {
 		members := true
 		groups, err := cli.Zms.GetGroups(zms.DomainName(dn), &members, zms.CompoundName(tagKey), zms.CompoundName(tagValue))
 		if err != nil {
@@ -4,12 +4,18 @@
 package zmscli
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
+	"gopkg.in/yaml.v2"
 )
 
 func parseRoleMember(memberStruct map[interface{}]interface{}) *zms.RoleMember {
@@ -34,6 +40,11 @@ func parseRoleMember(memberStruct map[interface{}]interface{}) *zms.RoleMember {
 		roleMember.ReviewReminder = &reviewTimeStamp
 	}
 
+	if pending, ok := memberStruct["pending"].(bool); ok && pending {
+		approved := false
+		roleMember.Approved = &approved
+	}
+
 	return roleMember
 }
 
@@ -201,6 +212,12 @@ func (cli Zms) importPolicies(dn string, lstPolicies []*zms.Policy, skipErrors b
 			if shouldReportError(skipErrors, cli.SkipErrors, err) {
 				return err
 			}
+			if err == nil {
+				err = cli.importAssertionConditions(dn, name, assertions)
+				if shouldReportError(skipErrors, cli.SkipErrors, err) {
+					return err
+				}
+			}
 		}
 	}
 	return nil
@@ -220,11 +237,10 @@ func (cli Zms) importPoliciesOld(dn string, lstPolicies []interface{}, skipError
 			lst := val.([]interface{})
 			if len(lst) > 0 {
 				for _, a := range lst {
-					if name == "admin" && a.(string) == "grant * to admin on *" {
+					if name == "admin" && a == "grant * to admin on *" {
 						continue
 					}
-					assertion := strings.Split(a.(string), " ")
-					newAssertion, err := parseAssertion(dn, assertion)
+					newAssertion, err := parseAssertionSpec(dn, a)
 					if shouldReportError(skipErrors, cli.SkipErrors, err) {
 						return err
 					}
@@ -237,6 +253,117 @@ func (cli Zms) importPoliciesOld(dn string, lstPolicies []interface{}, skipError
 			if shouldReportError(skipErrors, cli.SkipErrors, err) {
 				return err
 			}
+			if err == nil {
+				err = cli.importAssertionConditions(dn, name, assertions)
+				if shouldReportError(skipErrors, cli.SkipErrors, err) {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// parseAssertionSpec parses an assertion from the domain file which is either
+// the assertion string or an object with the assertion and its conditions
+func parseAssertionSpec(dn string, spec interface{}) (*zms.Assertion, error) {
+	assertionMap, ok := spec.(map[interface{}]interface{})
+	if !ok {
+		return parseAssertion(dn, strings.Fields(fmt.Sprint(spec)))
+	}
+	assertion, err := parseAssertion(dn, strings.Fields(parseSpecString(assertionMap, "assertion")))
+	if err != nil {
+		return nil, err
+	}
+	if lstConditions, ok := assertionMap["conditions"].([]interface{}); ok {
+		conditions := zms.AssertionConditions{
+			ConditionsList: make([]*zms.AssertionCondition, 0),
+		}
+		for _, c := range lstConditions {
+			conditionMap, ok := c.(map[interface{}]interface{})
+			if !ok {
+				return nil, fmt.Errorf("bad assertion condition syntax: %v", c)
+			}
+			condition := zms.AssertionCondition{
+				ConditionsMap: make(map[zms.AssertionConditionKey]*zms.AssertionConditionData),
+			}
+			for key, value := range conditionMap {
+				// condition ids are generated by the server
+				if key == "id" {
+					continue
+				}
+				fields := strings.SplitN(fmt.Sprint(value), " ", 2)
+				if len(fields) != 2 {
+					return nil, fmt.Errorf("bad assertion condition syntax. should be '<key>: <operator> <value>'")
+				}
+				err := addAssertionConditionData(&condition, fmt.Sprint(key), fields[0], fields[1])
+				if err != nil {
+					return nil, err
+				}
+			}
+			conditions.ConditionsList = append(conditions.ConditionsList, &condition)
+		}
+		assertion.Conditions = &conditions
+	}
+	return assertion, nil
+}
+
+// copyAssertionConditions returns a copy of the given conditions without
+// the condition ids since those are generated by the server
+func copyAssertionConditions(assertionConditions *zms.AssertionConditions) *zms.AssertionConditions {
+	conditions := zms.AssertionConditions{
+		ConditionsList: make([]*zms.AssertionCondition, 0),
+	}
+	for _, condition := range assertionConditions.ConditionsList {
+		conditions.ConditionsList = append(conditions.ConditionsList, &zms.AssertionCondition{
+			ConditionsMap: condition.ConditionsMap,
+		})
+	}
+	return &conditions
+}
+
+// importAssertionConditions adds the conditions of the given assertions to
+// the matching assertions of the policy that was just created
+func (cli Zms) importAssertionConditions(dn string, pn string, assertions []*zms.Assertion) error {
+	hasConditions := false
+	for _, assertion := range assertions {
+		if assertion.Conditions != nil && len(assertion.Conditions.ConditionsList) != 0 {
+			hasConditions = true
+		}
+	}
+	if !hasConditions {
+		return nil
+	}
+	policy, err := cli.Zms.GetPolicy(zms.DomainName(dn), zms.EntityName(pn))
+	if err != nil {
+		// due to mysql read after write issue it's possible that
+		// we'll get 404 after writing our object so in that
+		// case we're going to do a quick sleep and retry request
+		time.Sleep(500 * time.Millisecond)
+		policy, err = cli.Zms.GetPolicy(zms.DomainName(dn), zms.EntityName(pn))
+		if err != nil {
+			return err
+		}
+	}
+	for _, assertion := range assertions {
+		if assertion.Conditions == nil || len(assertion.Conditions.ConditionsList) == 0 {
+			continue
+		}
+		for _, policyAssertion := range policy.Assertions {
+			if policyAssertion.Id == nil || assertionString(dn, policyAssertion) != assertionString(dn, assertion) {
+				continue
+			}
+			// skip the assertion if the server already
+			// processed the conditions as part of the policy
+			if policyAssertion.Conditions != nil && len(policyAssertion.Conditions.ConditionsList) != 0 {
+				break
+			}
+			conditions := copyAssertionConditions(assertion.Conditions)
+			_, err = cli.Zms.PutAssertionConditions(zms.DomainName(dn), zms.EntityName(pn), *policyAssertion.Id, cli.AuditRef, conditions)
+			if err != nil {
+				return err
+			}
+			break
 		}
 	}
 	return nil
@@ -248,8 +375,14 @@ func (cli Zms) generatePublicKeys(lstPublicKeys []interface{}) []*zms.PublicKeyE
 		publicKeyMap := pubKey.(map[interface{}]interface{})
 		// if we're using just version numbers then yaml
 		// will interpret the key id as integer
+		// export-domain writes the key id as keyId while the
+		// public key output uses keyID so we accept both forms
+		keyIDValue, ok := publicKeyMap["keyID"]
+		if !ok {
+			keyIDValue = publicKeyMap["keyId"]
+		}
 		var keyID string
-		switch v := publicKeyMap["keyID"].(type) {
+		switch v := keyIDValue.(type) {
 		case int:
 			keyID = strconv.Itoa(v)
 		case string:
@@ -353,3 +486,267 @@ func (cli Zms) importServicesOld(dn string, lstServices []interface{}, skipError
 	}
 	return nil
 }
+
+// domainDataFields has the same fields as the DomainData object without
+// its json unmarshal method which requires the modified timestamp and the
+// policy signature. Those are only set in the signed domains and not in
+// files generated from the live domain objects, e.g. by system-backup.
+type domainDataFields zms.DomainData
+
+type domainDataFile struct {
+	domainDataFields
+	Policies *struct {
+		Contents  *zms.DomainPolicies `json:"contents"`
+		Signature string              `json:"signature"`
+		KeyId     string              `json:"keyId"`
+	} `json:"policies,omitempty"`
+}
+
+// unmarshalDomainDataJSON decodes the json domain data with or
+// without the modified timestamp and the policy signature
+func unmarshalDomainDataJSON(data []byte) (*zms.DomainData, error) {
+	var file domainDataFile
+	err := json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, err
+	}
+	domainData := zms.DomainData(file.domainDataFields)
+	if file.Policies != nil {
+		domainData.Policies = &zms.SignedPolicies{
+			Contents:  file.Policies.Contents,
+			Signature: file.Policies.Signature,
+			KeyId:     file.Policies.KeyId,
+		}
+	}
+	return &domainData, nil
+}
+
+// loadDomainFile reads the given domain file and returns its contents
+// as a DomainData object. The file can be in any of the formats generated
+// by export-domain and system-backup regardless of the output format option:
+// json files are detected by their extension or contents while yaml files
+// with a top level domain key are processed as manual yaml files.
+func loadDomainFile(filename string) (*zms.DomainData, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(filename) == ".json" || bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return unmarshalDomainDataJSON(data)
+	}
+	var domainData zms.DomainData
+	var spec map[string]interface{}
+	err = yaml.Unmarshal(data, &spec)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := spec["domain"]; ok {
+		return parseDomainDataOld(data)
+	}
+	err = yaml.Unmarshal(data, &domainData)
+	if err != nil {
+		return nil, err
+	}
+	return &domainData, nil
+}
+
+func parseSpecString(spec map[interface{}]interface{}, key string) string {
+	switch v := spec[key].(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	}
+	return ""
+}
+
+func parseSpecInt32(spec map[interface{}]interface{}, key string) *int32 {
+	if val, ok := spec[key].(int); ok {
+		value := int32(val)
+		return &value
+	}
+	return nil
+}
+
+func parseSpecBool(spec map[interface{}]interface{}, key string) *bool {
+	if val, ok := spec[key].(bool); ok {
+		return &val
+	}
+	return nil
+}
+
+func parseSpecTags(spec map[interface{}]interface{}) map[zms.CompoundName]*zms.TagValueList {
+	lstTags, ok := spec["tags"].([]interface{})
+	if !ok {
+		return nil
+	}
+	tags := make(map[zms.CompoundName]*zms.TagValueList)
+	for _, tag := range lstTags {
+		tagMap := tag.(map[interface{}]interface{})
+		tagValues := make([]zms.TagCompoundValue, 0)
+		if lstValues, ok := tagMap["values"].([]interface{}); ok {
+			for _, value := range lstValues {
+				tagValues = append(tagValues, zms.TagCompoundValue(fmt.Sprint(value)))
+			}
+		}
+		tags[zms.CompoundName(parseSpecString(tagMap, "key"))] = &zms.TagValueList{List: tagValues}
+	}
+	return tags
+}
+
+// parseDomainDataOld converts the manual yaml domain file generated
+// by export-domain into a DomainData object with full object names.
+// All the domain, role and group attributes written by the dump
+// functions are parsed so an exported domain has no differences.
+func parseDomainDataOld(data []byte) (*zms.DomainData, error) {
+	var spec map[string]interface{}
+	err := yaml.Unmarshal(data, &spec)
+	if err != nil {
+		return nil, err
+	}
+	dnSpec, ok := spec["domain"].(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("domain file does not include the domain object")
+	}
+	dn := parseSpecString(dnSpec, "name")
+	if dn == "" {
+		return nil, fmt.Errorf("domain file does not include the domain name")
+	}
+	domainData := zms.DomainData{
+		Name:                  zms.DomainName(dn),
+		Description:           parseSpecString(dnSpec, "description"),
+		Org:                   zms.ResourceName(parseSpecString(dnSpec, "org")),
+		AuditEnabled:          parseSpecBool(dnSpec, "audit_enabled"),
+		Account:               parseSpecString(dnSpec, "aws_account"),
+		AzureSubscription:     parseSpecString(dnSpec, "azure_subscription"),
+		YpmId:                 parseSpecInt32(dnSpec, "product_id"),
+		ApplicationId:         parseSpecString(dnSpec, "application_id"),
+		BusinessService:       parseSpecString(dnSpec, "business_service"),
+		CertDnsDomain:         parseSpecString(dnSpec, "cert_dns_domain"),
+		UserAuthorityFilter:   parseSpecString(dnSpec, "user_authority_filter"),
+		MemberExpiryDays:      parseSpecInt32(dnSpec, "member_expiry_days"),
+		ServiceExpiryDays:     parseSpecInt32(dnSpec, "service_expiry_days"),
+		GroupExpiryDays:       parseSpecInt32(dnSpec, "group_expiry_days"),
+		TokenExpiryMins:       parseSpecInt32(dnSpec, "token_expiry_mins"),
+		ServiceCertExpiryMins: parseSpecInt32(dnSpec, "service_cert_expiry_mins"),
+		RoleCertExpiryMins:    parseSpecInt32(dnSpec, "role_cert_expiry_mins"),
+		SignAlgorithm:         zms.SimpleName(parseSpecString(dnSpec, "sign_algorithm")),
+		Tags:                  parseSpecTags(dnSpec),
+		Roles:                 make([]*zms.Role, 0),
+		Groups:                make([]*zms.Group, 0),
+		Services:              make([]*zms.ServiceIdentity, 0),
+		Policies: &zms.SignedPolicies{
+			Contents: &zms.DomainPolicies{
+				Domain:   zms.DomainName(dn),
+				Policies: make([]*zms.Policy, 0),
+			},
+		},
+	}
+	if lstRoles, ok := dnSpec["roles"].([]interface{}); ok {
+		for _, role := range lstRoles {
+			roleMap := role.(map[interface{}]interface{})
+			rn := parseSpecString(roleMap, "name")
+			newRole := zms.Role{
+				Name:                    zms.ResourceName(dn + ":role." + rn),
+				Trust:                   zms.DomainName(parseSpecString(roleMap, "trust")),
+				MemberExpiryDays:        parseSpecInt32(roleMap, "member_expiry_days"),
+				ServiceExpiryDays:       parseSpecInt32(roleMap, "service_expiry_days"),
+				GroupExpiryDays:         parseSpecInt32(roleMap, "group_expiry_days"),
+				TokenExpiryMins:         parseSpecInt32(roleMap, "token_expiry_mins"),
+				CertExpiryMins:          parseSpecInt32(roleMap, "cert_expiry_mins"),
+				MemberReviewDays:        parseSpecInt32(roleMap, "member_review_days"),
+				ServiceReviewDays:       parseSpecInt32(roleMap, "service_review_days"),
+				GroupReviewDays:         parseSpecInt32(roleMap, "group_review_days"),
+				AuditEnabled:            parseSpecBool(roleMap, "audit_enabled"),
+				ReviewEnabled:           parseSpecBool(roleMap, "review_enabled"),
+				SelfServe:               parseSpecBool(roleMap, "self_serve"),
+				SignAlgorithm:           zms.SimpleName(parseSpecString(roleMap, "sign_algorithm")),
+				NotifyRoles:             parseSpecString(roleMap, "notify_roles"),
+				UserAuthorityFilter:     parseSpecString(roleMap, "user_authority_filter"),
+				UserAuthorityExpiration: parseSpecString(roleMap, "user_authority_expiration"),
+				Tags:                    parseSpecTags(roleMap),
+				RoleMembers:             make([]*zms.RoleMember, 0),
+			}
+			if lstMembers, ok := roleMap["members"].([]interface{}); ok {
+				for _, mbr := range lstMembers {
+					newRole.RoleMembers = append(newRole.RoleMembers, parseRoleMember(mbr.(map[interface{}]interface{})))
+				}
+			}
+			domainData.Roles = append(domainData.Roles, &newRole)
+		}
+	}
+	if lstGroups, ok := dnSpec["groups"].([]interface{}); ok {
+		for _, group := range lstGroups {
+			groupMap := group.(map[interface{}]interface{})
+			gn := parseSpecString(groupMap, "name")
+			newGroup := zms.Group{
+				Name:                    zms.ResourceName(dn + ":group." + gn),
+				MemberExpiryDays:        parseSpecInt32(groupMap, "member_expiry_days"),
+				ServiceExpiryDays:       parseSpecInt32(groupMap, "service_expiry_days"),
+				AuditEnabled:            parseSpecBool(groupMap, "audit_enabled"),
+				ReviewEnabled:           parseSpecBool(groupMap, "review_enabled"),
+				SelfServe:               parseSpecBool(groupMap, "self_serve"),
+				NotifyRoles:             parseSpecString(groupMap, "notify_roles"),
+				UserAuthorityFilter:     parseSpecString(groupMap, "user_authority_filter"),
+				UserAuthorityExpiration: parseSpecString(groupMap, "user_authority_expiration"),
+				Tags:                    parseSpecTags(groupMap),
+				GroupMembers:            make([]*zms.GroupMember, 0),
+			}
+			if lstMembers, ok := groupMap["members"].([]interface{}); ok {
+				for _, mbr := range lstMembers {
+					roleMember := parseRoleMember(mbr.(map[interface{}]interface{}))
+					newGroup.GroupMembers = append(newGroup.GroupMembers, &zms.GroupMember{
+						MemberName: zms.GroupMemberName(roleMember.MemberName),
+						Expiration: roleMember.Expiration,
+						Approved:   roleMember.Approved,
+					})
+				}
+			}
+			domainData.Groups = append(domainData.Groups, &newGroup)
+		}
+	}
+	if lstPolicies, ok := dnSpec["policies"].([]interface{}); ok {
+		for _, policy := range lstPolicies {
+			policyMap := policy.(map[interface{}]interface{})
+			pn := parseSpecString(policyMap, "name")
+			newPolicy := zms.Policy{
+				Name:       zms.ResourceName(dn + ":policy." + pn),
+				Assertions: make([]*zms.Assertion, 0),
+			}
+			if lstAssertions, ok := policyMap["assertions"].([]interface{}); ok {
+				for _, a := range lstAssertions {
+					assertion, err := parseAssertionSpec(dn, a)
+					if err != nil {
+						return nil, fmt.Errorf("policy %s: %v", pn, err)
+					}
+					newPolicy.Assertions = append(newPolicy.Assertions, assertion)
+				}
+			}
+			domainData.Policies.Contents.Policies = append(domainData.Policies.Contents.Policies, &newPolicy)
+		}
+	}
+	if lstServices, ok := dnSpec["services"].([]interface{}); ok {
+		var cli Zms
+		for _, service := range lstServices {
+			serviceMap := service.(map[interface{}]interface{})
+			sn := shortname(dn, parseSpecString(serviceMap, "name"))
+			newService := zms.ServiceIdentity{
+				Name:             zms.ServiceName(dn + "." + sn),
+				ProviderEndpoint: parseSpecString(serviceMap, "providerEndpoint"),
+				Executable:       parseSpecString(serviceMap, "executable"),
+				User:             parseSpecString(serviceMap, "user"),
+				Group:            parseSpecString(serviceMap, "group"),
+			}
+			if lstPublicKeys, ok := serviceMap["publicKeys"].([]interface{}); ok {
+				newService.PublicKeys = cli.generatePublicKeys(lstPublicKeys)
+			}
+			if lstHosts, ok := serviceMap["hosts"].([]interface{}); ok {
+				for _, host := range lstHosts {
+					newService.Hosts = append(newService.Hosts, fmt.Sprint(host))
+				}
+			}
+			domainData.Services = append(domainData.Services, &newService)
+		}
+	}
+	return &domainData, nil
+}
@@ -0,0 +1,324 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+const (
+	lintSeverityError   = "error"
+	lintSeverityWarning = "warning"
+)
+
+// LintIssue is a single problem found in a domain file
+type LintIssue struct {
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity"`
+	Object   string `json:"object"`
+	Message  string `json:"message"`
+}
+
+// LintReport is the list of problems found in a domain file
+type LintReport struct {
+	File     string       `json:"file"`
+	Domain   string       `json:"domain"`
+	Errors   int          `json:"errors"`
+	Warnings int          `json:"warnings"`
+	Issues   []*LintIssue `json:"issues"`
+}
+
+// lineLocator finds the line numbers of the domain objects in the file.
+// The domain files are parsed into model objects without any position
+// details so the lines are located by searching for the object names.
+type lineLocator struct {
+	lines [][]string
+}
+
+// lineTokenCutset is the yaml and json syntax around keys and values
+const lineTokenCutset = "\"',:[]{}"
+
+func newLineLocator(data []byte) *lineLocator {
+	locator := &lineLocator{}
+	for _, line := range strings.Split(string(data), "\n") {
+		locator.lines = append(locator.lines, lineTokens(line))
+	}
+	return locator
+}
+
+// lineTokens splits the line into its keys and values without the
+// surrounding yaml and json syntax so that names can be matched exactly
+func lineTokens(line string) []string {
+	tokens := make([]string, 0)
+	for _, field := range strings.Fields(line) {
+		if token := strings.Trim(field, lineTokenCutset); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// locate returns the first line at or after the given line that has a
+// key or value matching one of the values. The values are checked in
+// order so more specific values like full resource names must be listed
+// first. Returns 0 if none of the values are found.
+func (l *lineLocator) locate(after int, values ...string) int {
+	if after < 1 {
+		after = 1
+	}
+	for _, value := range values {
+		value = strings.Trim(value, lineTokenCutset)
+		if value == "" {
+			continue
+		}
+		for i := after - 1; i < len(l.lines); i++ {
+			if indexOfString(l.lines[i], value) != -1 {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+type domainLinter struct {
+	cli            Zms
+	dn             string
+	domainData     *zms.DomainData
+	locator        *lineLocator
+	allowedDomains []string
+	now            time.Time
+	report         *LintReport
+}
+
+func (l *domainLinter) add(line int, severity, object, message string) {
+	l.report.Issues = append(l.report.Issues, &LintIssue{
+		Line:     line,
+		Severity: severity,
+		Object:   object,
+		Message:  message,
+	})
+	if severity == lintSeverityError {
+		l.report.Errors++
+	} else {
+		l.report.Warnings++
+	}
+}
+
+// principalDomain returns the domain name of the given principal
+// which is either a user/service name or a group name
+func principalDomain(principal string) string {
+	if idx := strings.Index(principal, ":group."); idx >= 0 {
+		return principal[:idx]
+	}
+	if idx := strings.LastIndex(principal, "."); idx >= 0 {
+		return principal[:idx]
+	}
+	return ""
+}
+
+// principalAllowed checks if the principal belongs to the linted domain
+// or one of the allowed domains including their sub domains
+func (l *domainLinter) principalAllowed(principal string) bool {
+	if len(l.allowedDomains) == 0 || principal == "*" {
+		return true
+	}
+	domain := principalDomain(principal)
+	for _, allowed := range append([]string{l.dn}, l.allowedDomains...) {
+		if domain == allowed || strings.HasPrefix(domain, allowed+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *domainLinter) lintMember(line int, object, memberName string, expiration *rdl.Timestamp) {
+	memberLine := l.locator.locate(line, memberName)
+	if !l.principalAllowed(memberName) {
+		l.add(memberLine, lintSeverityError, object, "member "+memberName+" is not in the list of allowed domains")
+	}
+	if expiration != nil && expiration.Time.Before(l.now) {
+		l.add(memberLine, lintSeverityWarning, object, "member "+memberName+" expired on "+expiration.String())
+	}
+}
+
+func (l *domainLinter) lintRoles(referencedRoles map[string]bool) {
+	domainAuditEnabled := l.domainData.AuditEnabled != nil && *l.domainData.AuditEnabled
+	for _, role := range l.domainData.Roles {
+		roleName := fullRoleName(l.dn, string(role.Name))
+		rn := localName(roleName, ":role.")
+		object := "role " + rn
+		line := l.locator.locate(l.locator.locate(0, "roles"), roleName, rn+":", rn)
+		if !referencedRoles[roleName] {
+			l.add(line, lintSeverityWarning, object, "role is not referenced by any policy")
+		}
+		auditEnabled := domainAuditEnabled || (role.AuditEnabled != nil && *role.AuditEnabled)
+		for _, member := range role.RoleMembers {
+			memberName := string(member.MemberName)
+			l.lintMember(line, object, memberName, member.Expiration)
+			if auditEnabled && member.AuditRef == "" && l.cli.AuditRef == "" {
+				l.add(l.locator.locate(line, memberName), lintSeverityError, object,
+					"member "+memberName+" has no audit reference and the role is audit enabled")
+			}
+		}
+	}
+	for _, group := range l.domainData.Groups {
+		gn := localName(string(group.Name), ":group.")
+		object := "group " + gn
+		line := l.locator.locate(l.locator.locate(0, "groups"), l.dn+":group."+gn, gn+":", gn)
+		for _, member := range group.GroupMembers {
+			l.lintMember(line, object, string(member.MemberName), member.Expiration)
+		}
+	}
+}
+
+// validAssertionResource checks that the resource is in the domain:entity
+// format with a valid domain name. The domain might include wildcards
+// in assume_role assertions so those are not validated.
+func validAssertionResource(resource string) bool {
+	idx := strings.Index(resource, ":")
+	if idx <= 0 || idx == len(resource)-1 || strings.ContainsAny(resource, " \t") {
+		return false
+	}
+	domain := resource[:idx]
+	if strings.ContainsAny(domain, "*?") {
+		return true
+	}
+	return rdl.Validate(zms.ZMSSchema(), "DomainName", domain).Valid
+}
+
+// roleExists checks if the assertion role matches any of the domain roles.
+// Assertions can use wildcards in the role name so all matching roles are
+// marked as referenced.
+func (l *domainLinter) roleExists(assertionRole string, referencedRoles map[string]bool) bool {
+	exists := false
+	for _, role := range l.domainData.Roles {
+		roleName := fullRoleName(l.dn, string(role.Name))
+		if globMatch(assertionRole, roleName) {
+			referencedRoles[roleName] = true
+			exists = true
+		}
+	}
+	return exists
+}
+
+func (l *domainLinter) lintPolicies() map[string]bool {
+	referencedRoles := make(map[string]bool)
+	for _, policy := range domainPolicies(l.domainData) {
+		policyName := fullPolicyName(l.dn, string(policy.Name))
+		pn := localName(policyName, ":policy.")
+		object := "policy " + pn
+		line := l.locator.locate(l.locator.locate(0, "policies"), policyName, pn+":", pn)
+		if len(policy.Assertions) == 0 {
+			l.add(line, lintSeverityWarning, object, "policy has no assertions")
+			continue
+		}
+		// assertions are listed in order so each one is searched
+		// for starting right after the previous assertion's line
+		assertionLine := line
+		for i, assertion := range policy.Assertions {
+			assertionRole := fullRoleName(l.dn, assertion.Role)
+			resource := fullResourceName(l.dn, assertion.Resource)
+			if next := l.locator.locate(assertionLine+1, resource, localName(resource, l.dn+":")); next != 0 {
+				assertionLine = next
+			}
+			description := assertionString(l.dn, assertion)
+			if strings.HasPrefix(assertionRole, l.dn+":role.") && !l.roleExists(assertionRole, referencedRoles) {
+				l.add(assertionLine, lintSeverityError, object, "assertion '"+description+"' references unknown role "+localName(assertionRole, ":role."))
+			}
+			if !validAssertionResource(resource) {
+				l.add(assertionLine, lintSeverityError, object, "assertion '"+description+"' has invalid resource name "+assertion.Resource)
+			}
+			// assume_role assertions reference roles in other domains as their resource
+			if strings.ToLower(assertion.Action) == assumeRoleAction && strings.HasPrefix(resource, l.dn+":role.") {
+				l.roleExists(resource, referencedRoles)
+			}
+			for _, previous := range policy.Assertions[:i] {
+				if l.cli.assertionMatch(previous, assertion) {
+					l.add(assertionLine, lintSeverityError, object, "duplicate assertion '"+description+"'")
+					break
+				}
+			}
+		}
+	}
+	return referencedRoles
+}
+
+// lintDomain checks the domain file contents and returns the
+// report with all the problems found in the file
+func (cli Zms) lintDomain(filename string, data []byte, domainData *zms.DomainData, allowedDomains []string) *LintReport {
+	linter := domainLinter{
+		cli:            cli,
+		dn:             string(domainData.Name),
+		domainData:     domainData,
+		locator:        newLineLocator(data),
+		allowedDomains: allowedDomains,
+		now:            time.Now(),
+		report: &LintReport{
+			File:   filename,
+			Domain: string(domainData.Name),
+			Issues: make([]*LintIssue, 0),
+		},
+	}
+	referencedRoles := linter.lintPolicies()
+	linter.lintRoles(referencedRoles)
+	return linter.report
+}
+
+func (cli Zms) dumpLintReport(buf *bytes.Buffer, report *LintReport) {
+	for _, issue := range report.Issues {
+		location := report.File
+		if issue.Line != 0 {
+			location += ":" + strconv.Itoa(issue.Line)
+		}
+		buf.WriteString(location + ": " + issue.Severity + ": " + issue.Object + ": " + issue.Message + "\n")
+	}
+	buf.WriteString("[" + strconv.Itoa(report.Errors) + " errors, " + strconv.Itoa(report.Warnings) + " warnings]\n")
+}
+
+// LintDomain checks the domain file before it's imported for dangling
+// assertions, unreferenced roles, invalid resource names, members from
+// domains that are not allowed, duplicate assertions, expired members
+// and missing audit references. If any errors are found, the command
+// fails so it can be used to validate domain files in CI pipelines.
+func (cli Zms) LintDomain(filename string, allowedDomains []string) (*string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	domainData, err := loadDomainFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if domainData.Name == "" {
+		return nil, fmt.Errorf("domain file %s does not include the domain name", filename)
+	}
+	report := cli.lintDomain(filename, data, domainData, allowedDomains)
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		cli.dumpLintReport(&buf, report)
+		s := buf.String()
+		return &s, nil
+	}
+
+	output, err := cli.dumpByFormat(report, oldYamlConverter)
+	if err != nil {
+		return nil, err
+	}
+	if report.Errors != 0 {
+		return nil, &CommandFailedError{
+			Output: *output,
+			Reason: "lint-domain found " + strconv.Itoa(report.Errors) + " errors in " + filename,
+		}
+	}
+	return output, nil
+}
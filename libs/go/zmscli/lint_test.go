@@ -0,0 +1,144 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const lintDomainYaml = `
+domain:
+  name: coretech
+  roles:
+    - name: admin
+      members:
+        - name: user.john
+    - name: readers
+      members:
+        - name: user.jane
+          expiration: 2001-01-01T00:00:00.000Z
+        - name: external.api
+    - name: unused
+  policies:
+    - name: admin
+      assertions:
+        - grant * to admin on *
+    - name: readers
+      assertions:
+        - grant read to readers on articles
+        - grant read to readers on articles
+        - grant write to writers on articles
+    - name: empty
+`
+
+func TestLintDomain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zms-lint-test")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "coretech.yaml")
+	_ = ioutil.WriteFile(filename, []byte(lintDomainYaml), 0644)
+
+	cli := Zms{OutputFormat: DefaultOutputFormat}
+	_, err = cli.LintDomain(filename, []string{"user"})
+	failure, ok := err.(*CommandFailedError)
+	if !ok {
+		t.Fatalf("expected lint failure, got %v", err)
+	}
+
+	domainData, err := loadDomainFile(filename)
+	if err != nil {
+		t.Fatalf("unable to load domain: %v", err)
+	}
+	report := cli.lintDomain(filename, []byte(lintDomainYaml), domainData, []string{"user"})
+	expected := map[string]int{
+		"warning:role unused:role is not referenced by any policy":                                            13,
+		"warning:role readers:member user.jane expired on 2001-01-01T00:00:00.000Z":                           10,
+		"error:role readers:member external.api is not in the list of allowed domains":                        12,
+		"error:policy readers:duplicate assertion 'grant read to readers on articles'":                        21,
+		"error:policy readers:assertion 'grant write to writers on articles' references unknown role writers": 22,
+		"warning:policy empty:policy has no assertions":                                                       23,
+	}
+	if len(report.Issues) != len(expected) || report.Errors != 3 || report.Warnings != 3 {
+		t.Errorf("unexpected issues: %d errors, %d warnings", report.Errors, report.Warnings)
+	}
+	for _, issue := range report.Issues {
+		key := issue.Severity + ":" + issue.Object + ":" + issue.Message
+		line, ok := expected[key]
+		if !ok {
+			t.Errorf("unexpected issue: %s", key)
+			continue
+		}
+		if issue.Line != line {
+			t.Errorf("%s: expected line %d, got %d", key, line, issue.Line)
+		}
+	}
+	if failure.Output == "" {
+		t.Error("lint report was not included in the failure")
+	}
+}
+
+func TestLintDomainMissingName(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zms-lint-test")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "coretech.yaml")
+	_ = ioutil.WriteFile(filename, []byte("description: core tech domain\n"), 0644)
+
+	cli := Zms{OutputFormat: YAMLOutputFormat}
+	_, err = cli.LintDomain(filename, nil)
+	if err == nil {
+		t.Fatal("domain file without a domain name was linted successfully")
+	}
+	if _, ok := err.(*CommandFailedError); ok {
+		t.Errorf("unexpected lint failure: %v", err)
+	}
+
+	// the file format does not depend on the output format
+	_ = ioutil.WriteFile(filename, []byte(lintDomainYaml), 0644)
+	_, err = cli.LintDomain(filename, nil)
+	if _, ok := err.(*CommandFailedError); !ok {
+		t.Errorf("expected lint failure, got %v", err)
+	}
+}
+
+func TestLineLocator(t *testing.T) {
+	locator := newLineLocator([]byte(`
+roles:
+  - name: readers
+    members:
+      - name: user.joe
+  - name: reader
+    members:
+      - name: user.jo
+policies:
+  - name: reader
+    assertions:
+      - grant read to reader on 'coretech:articles.*'
+`))
+	tests := []struct {
+		after  int
+		values []string
+		line   int
+	}{
+		{0, []string{"reader"}, 6},
+		{0, []string{"readers"}, 3},
+		{0, []string{"user.jo"}, 8},
+		{0, []string{"reader:"}, 6},
+		{9, []string{"coretech:articles", "articles"}, 0},
+		{9, []string{"coretech:articles.*"}, 12},
+		{0, []string{"writers"}, 0},
+	}
+	for _, test := range tests {
+		if line := locator.locate(test.after, test.values...); line != test.line {
+			t.Errorf("%v: expected line %d, got %d", test.values, test.line, line)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+const (
+	metaAttrBusinessService   = "businessService"
+	metaAttrAwsAccount        = "awsAccount"
+	metaAttrAzureSubscription = "azureSubscription"
+	metaAttrProductId         = "productId"
+
+	userAuthorityTypeBool = "bool"
+	userAuthorityTypeDate = "date"
+
+	maxSuggestions = 5
+)
+
+// levenshteinDistance returns the number of single character edits
+// required to change one string into the other
+func levenshteinDistance(s1, s2 string) int {
+	r1 := []rune(s1)
+	r2 := []rune(s2)
+	prev := make([]int, len(r2)+1)
+	curr := make([]int, len(r2)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(r1); i++ {
+		curr[0] = i
+		for j := 1; j <= len(r2); j++ {
+			cost := 1
+			if r1[i-1] == r2[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(r2)]
+}
+
+// closeMatches returns the values that are similar to the given value:
+// values that contain it or are within a small edit distance from it.
+// The closest matches are returned first.
+func closeMatches(value string, validValues []string) []string {
+	lowerValue := strings.ToLower(value)
+	maxDistance := len(lowerValue) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	distances := make(map[string]int)
+	matches := make([]string, 0)
+	for _, validValue := range validValues {
+		lowerValidValue := strings.ToLower(validValue)
+		distance := levenshteinDistance(lowerValue, lowerValidValue)
+		if distance <= maxDistance || (lowerValue != "" && strings.Contains(lowerValidValue, lowerValue)) {
+			distances[validValue] = distance
+			matches = append(matches, validValue)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if distances[matches[i]] != distances[matches[j]] {
+			return distances[matches[i]] < distances[matches[j]]
+		}
+		return matches[i] < matches[j]
+	})
+	if len(matches) > maxSuggestions {
+		matches = matches[:maxSuggestions]
+	}
+	return matches
+}
+
+func invalidValueError(label, value string, validValues []string) error {
+	msg := "invalid " + label + " value: " + value
+	if matches := closeMatches(value, validValues); len(matches) != 0 {
+		msg += ", did you mean: " + strings.Join(matches, ", ")
+	}
+	return errors.New(msg)
+}
+
+// validateMetaStoreValue verifies that the value is one of the valid
+// values for the attribute in the server's domain meta store. If the
+// server doesn't have a meta store configured for the attribute or the
+// list can't be retrieved, the validation is left to the server.
+func (cli Zms) validateMetaStoreValue(attribute, value string) error {
+	if value == "" {
+		return nil
+	}
+	list, err := cli.Zms.GetDomainMetaStoreValidValuesList(attribute, "")
+	if err != nil || list == nil || len(list.ValidValues) == 0 {
+		return nil
+	}
+	if indexOfString(list.ValidValues, value) != -1 {
+		return nil
+	}
+	return invalidValueError(attribute, value, list.ValidValues)
+}
+
+// validateUserAuthorityAttributes verifies that all the attributes in the
+// comma separated list are valid user authority attributes of the given
+// type. As with meta store values, the validation is skipped if the
+// server doesn't return any attributes for the type.
+func (cli Zms) validateUserAuthorityAttributes(attributeType, attributes string) error {
+	if attributes == "" {
+		return nil
+	}
+	attributeMap, err := cli.Zms.GetUserAuthorityAttributeMap()
+	if err != nil || attributeMap == nil {
+		return nil
+	}
+	validAttributes := attributeMap.Attributes[zms.SimpleName(attributeType)]
+	if validAttributes == nil || len(validAttributes.Values) == 0 {
+		return nil
+	}
+	for _, attribute := range strings.Split(attributes, ",") {
+		attribute = strings.TrimSpace(attribute)
+		if indexOfString(validAttributes.Values, attribute) == -1 {
+			return invalidValueError("user authority "+attributeType+" attribute", attribute, validAttributes.Values)
+		}
+	}
+	return nil
+}
+
+func (cli Zms) ListMetaStoreValues(attribute, user string) (*string, error) {
+	list, err := cli.Zms.GetDomainMetaStoreValidValuesList(attribute, user)
+	if err != nil {
+		return nil, err
+	}
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		buf.WriteString("valid values for " + attribute + ":\n")
+		for _, value := range list.ValidValues {
+			buf.WriteString(indentLevel1Dash + value + "\n")
+		}
+		s := buf.String()
+		return &s, nil
+	}
+
+	return cli.dumpByFormat(list, oldYamlConverter)
+}
+
+func (cli Zms) ListUserAuthorityAttributes() (*string, error) {
+	attributeMap, err := cli.Zms.GetUserAuthorityAttributeMap()
+	if err != nil {
+		return nil, err
+	}
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		types := make([]string, 0, len(attributeMap.Attributes))
+		for attributeType := range attributeMap.Attributes {
+			types = append(types, string(attributeType))
+		}
+		sort.Strings(types)
+		buf.WriteString("attributes:\n")
+		for _, attributeType := range types {
+			buf.WriteString(indentLevel1 + attributeType + ":\n")
+			attributes := attributeMap.Attributes[zms.SimpleName(attributeType)]
+			if attributes == nil {
+				continue
+			}
+			for _, value := range attributes.Values {
+				buf.WriteString(indentLevel1 + indentLevel1Dash + value + "\n")
+			}
+		}
+		s := buf.String()
+		return &s, nil
+	}
+
+	return cli.dumpByFormat(attributeMap, oldYamlConverter)
+}
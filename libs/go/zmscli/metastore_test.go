@@ -0,0 +1,46 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"testing"
+)
+
+func TestLevenshteinDistance(t *testing.T) {
+	tests := []struct {
+		s1       string
+		s2       string
+		distance int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"security", "security", 0},
+		{"secruity", "security", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, test := range tests {
+		if distance := levenshteinDistance(test.s1, test.s2); distance != test.distance {
+			t.Errorf("%s/%s: expected distance %d, got %d", test.s1, test.s2, test.distance, distance)
+		}
+	}
+}
+
+func TestCloseMatches(t *testing.T) {
+	validValues := []string{"security-tools", "security", "sports-api", "Search", "weather"}
+	matches := closeMatches("securty", validValues)
+	if len(matches) != 1 || matches[0] != "security" {
+		t.Errorf("unexpected matches: %v", matches)
+	}
+	matches = closeMatches("secur", validValues)
+	if len(matches) != 2 || matches[0] != "security" || matches[1] != "security-tools" {
+		t.Errorf("unexpected matches: %v", matches)
+	}
+	matches = closeMatches("search", validValues)
+	if len(matches) != 1 || matches[0] != "Search" {
+		t.Errorf("unexpected matches: %v", matches)
+	}
+	if matches = closeMatches("finance", validValues); len(matches) != 0 {
+		t.Errorf("unexpected matches: %v", matches)
+	}
+}
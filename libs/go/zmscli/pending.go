@@ -0,0 +1,274 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// PendingMember is a pending role or group membership request
+// that has been selected to be cancelled, approved or rejected.
+type PendingMember struct {
+	Domain      string         `json:"domain"`
+	ObjectType  string         `json:"type"`
+	Name        string         `json:"name"`
+	Member      string         `json:"member"`
+	RequestTime string         `json:"requestTime,omitempty"`
+	Expiration  *rdl.Timestamp `json:"expiration,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// pendingMemberFilter selects the pending requests made before the cutoff
+// time for members matching the principal pattern. If the name is not
+// empty, only requests for the role or group with that name are selected.
+type pendingMemberFilter struct {
+	cutoff  time.Time
+	pattern string
+	name    string
+}
+
+func (f *pendingMemberFilter) match(name, member string, requestTime *rdl.Timestamp) (bool, error) {
+	if f.name != "" && f.name != name {
+		return false, nil
+	}
+	return pendingRequestMatch(member, requestTime, f.cutoff, f.pattern)
+}
+
+// pendingCutoff returns the cutoff time for requests older than the
+// given number of days. A zero time is returned for no cutoff.
+func pendingCutoff(days int) time.Time {
+	if days <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+}
+
+// pendingRequestMatch returns true if the pending request was made before
+// the given cutoff time and the member matches the given principal pattern
+func pendingRequestMatch(member string, requestTime *rdl.Timestamp, cutoff time.Time, pattern string) (bool, error) {
+	if pattern != "" {
+		match, err := path.Match(pattern, member)
+		if err != nil || !match {
+			return false, err
+		}
+	}
+	if cutoff.IsZero() {
+		return true, nil
+	}
+	return requestTime != nil && requestTime.Time.Before(cutoff), nil
+}
+
+func (cli Zms) pendingMembersMatching(dn string, filter *pendingMemberFilter) ([]*PendingMember, error) {
+	pendingMembers := make([]*PendingMember, 0)
+	roleMembership, err := cli.Zms.GetPendingDomainRoleMembersList("", dn)
+	if err != nil {
+		return nil, err
+	}
+	for _, domainRoleMembers := range roleMembership.DomainRoleMembersList {
+		for _, roleMember := range domainRoleMembers.Members {
+			for _, role := range roleMember.MemberRoles {
+				rn := localName(string(role.RoleName), ":role.")
+				match, err := filter.match(rn, string(roleMember.MemberName), role.RequestTime)
+				if err != nil {
+					return nil, err
+				}
+				if match {
+					pendingMembers = append(pendingMembers, &PendingMember{
+						Domain:      string(domainRoleMembers.DomainName),
+						ObjectType:  "role",
+						Name:        rn,
+						Member:      string(roleMember.MemberName),
+						RequestTime: timestampString(role.RequestTime),
+						Expiration:  role.Expiration,
+					})
+				}
+			}
+		}
+	}
+	groupMembership, err := cli.Zms.GetPendingDomainGroupMembersList("", dn)
+	if err != nil {
+		return nil, err
+	}
+	for _, domainGroupMembers := range groupMembership.DomainGroupMembersList {
+		for _, groupMember := range domainGroupMembers.Members {
+			for _, group := range groupMember.MemberGroups {
+				gn := localName(string(group.GroupName), ":group.")
+				match, err := filter.match(gn, string(groupMember.MemberName), group.RequestTime)
+				if err != nil {
+					return nil, err
+				}
+				if match {
+					pendingMembers = append(pendingMembers, &PendingMember{
+						Domain:      string(domainGroupMembers.DomainName),
+						ObjectType:  "group",
+						Name:        gn,
+						Member:      string(groupMember.MemberName),
+						RequestTime: timestampString(group.RequestTime),
+						Expiration:  group.Expiration,
+					})
+				}
+			}
+		}
+	}
+	return pendingMembers, nil
+}
+
+func dumpPendingMembers(buf *bytes.Buffer, pendingMembers []*PendingMember) {
+	for _, pendingMember := range pendingMembers {
+		buf.WriteString(indentLevel1Dash + "domain: " + pendingMember.Domain + "\n")
+		buf.WriteString(indentLevel1DashLvl + pendingMember.ObjectType + ": " + pendingMember.Name + "\n")
+		buf.WriteString(indentLevel1DashLvl + "member: " + pendingMember.Member + "\n")
+		dumpStringValue(buf, indentLevel1DashLvl, "request-time", pendingMember.RequestTime)
+		dumpStringValue(buf, indentLevel1DashLvl, "expiration", timestampString(pendingMember.Expiration))
+		dumpStringValue(buf, indentLevel1DashLvl, "error", pendingMember.Error)
+	}
+}
+
+// PurgePendingMembers cancels all pending role and group membership requests
+// that are older than the given number of days and match the principal pattern.
+// The matching requests are listed first and only cancelled after confirmation.
+// Failures are reported for each request without stopping the remaining ones.
+func (cli Zms) PurgePendingMembers(dn string, days int, pattern string) (*string, error) {
+	pendingMembers, err := cli.pendingMembersMatching(dn, &pendingMemberFilter{cutoff: pendingCutoff(days), pattern: pattern})
+	if err != nil {
+		return nil, err
+	}
+	if len(pendingMembers) == 0 {
+		message := SuccessMessage{
+			Status:  200,
+			Message: "[no pending membership requests matched the criteria]",
+		}
+		return cli.dumpByFormat(message, cli.buildYAMLOutput)
+	}
+	var buf bytes.Buffer
+	buf.WriteString("pending members to be deleted:\n")
+	dumpPendingMembers(&buf, pendingMembers)
+	if !cli.confirmChanges(buf.String()) {
+		return nil, fmt.Errorf("purge-pending-members cancelled - no pending requests were deleted")
+	}
+	failed := make([]*PendingMember, 0)
+	for _, pendingMember := range pendingMembers {
+		if pendingMember.ObjectType == "role" {
+			err = cli.Zms.DeletePendingMembership(zms.DomainName(pendingMember.Domain), zms.EntityName(pendingMember.Name), zms.MemberName(pendingMember.Member), cli.AuditRef)
+		} else {
+			err = cli.Zms.DeletePendingGroupMembership(zms.DomainName(pendingMember.Domain), zms.EntityName(pendingMember.Name), zms.GroupMemberName(pendingMember.Member), cli.AuditRef)
+		}
+		if err != nil {
+			pendingMember.Error = err.Error()
+			failed = append(failed, pendingMember)
+		}
+	}
+	if len(failed) != 0 {
+		oldYamlConverter := func(res interface{}) (*string, error) {
+			var buf bytes.Buffer
+			buf.WriteString("failed pending members:\n")
+			dumpPendingMembers(&buf, failed)
+			s := buf.String()
+			return &s, nil
+		}
+		output, err := cli.dumpByFormat(failed, oldYamlConverter)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &CommandFailedError{
+			Output: *output,
+			Reason: "purge-pending-members failed for " + strconv.Itoa(len(failed)) + " of " + strconv.Itoa(len(pendingMembers)) + " pending membership requests",
+		}
+	}
+	s := "[deleted " + strconv.Itoa(len(pendingMembers)) + " pending membership requests]"
+	message := SuccessMessage{
+		Status:  200,
+		Message: s,
+	}
+	return cli.dumpByFormat(message, cli.buildYAMLOutput)
+}
+
+// putPendingMemberDecision approves or rejects the pending request. Role
+// requests with an expiration are processed as temporary memberships.
+func (cli Zms) putPendingMemberDecision(pendingMember *PendingMember, approval bool) error {
+	dn := zms.DomainName(pendingMember.Domain)
+	name := zms.EntityName(pendingMember.Name)
+	if pendingMember.ObjectType == "role" {
+		member := membershipDecision(pendingMember.Name, pendingMember.Member, pendingMember.Expiration, approval)
+		return cli.Zms.PutMembershipDecision(dn, name, zms.MemberName(pendingMember.Member), cli.AuditRef, member)
+	}
+	member := zms.GroupMembership{
+		MemberName: zms.GroupMemberName(pendingMember.Member),
+		GroupName:  zms.ResourceName(pendingMember.Name),
+		Approved:   &approval,
+	}
+	return cli.Zms.PutGroupMembershipDecision(dn, name, zms.GroupMemberName(pendingMember.Member), cli.AuditRef, &member)
+}
+
+// PutPendingMembersDecision approves or rejects all pending role and group
+// membership requests that match the filter. The matching requests are listed
+// first and the decisions are only submitted after confirmation. Failures are
+// reported for each request without stopping the remaining decisions.
+func (cli Zms) PutPendingMembersDecision(dn string, name string, days int, pattern string, approval bool, dryRun bool) (*string, error) {
+	command, decision := "approve-pending", "approved"
+	if !approval {
+		command, decision = "reject-pending", "rejected"
+	}
+	filter := &pendingMemberFilter{cutoff: pendingCutoff(days), pattern: pattern, name: name}
+	pendingMembers, err := cli.pendingMembersMatching(dn, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(pendingMembers) == 0 {
+		message := SuccessMessage{
+			Status:  200,
+			Message: "[no pending membership requests matched the criteria]",
+		}
+		return cli.dumpByFormat(message, cli.buildYAMLOutput)
+	}
+	oldYamlConverter := func(header string) func(res interface{}) (*string, error) {
+		return func(res interface{}) (*string, error) {
+			var buf bytes.Buffer
+			buf.WriteString(header + ":\n")
+			dumpPendingMembers(&buf, res.([]*PendingMember))
+			s := buf.String()
+			return &s, nil
+		}
+	}
+	if dryRun {
+		return cli.dumpByFormat(pendingMembers, oldYamlConverter("pending members to be "+decision))
+	}
+	var buf bytes.Buffer
+	buf.WriteString("pending members to be " + decision + ":\n")
+	dumpPendingMembers(&buf, pendingMembers)
+	if !cli.confirmChanges(buf.String()) {
+		return nil, fmt.Errorf("%s cancelled - no pending requests were %s", command, decision)
+	}
+	failed := make([]*PendingMember, 0)
+	for _, pendingMember := range pendingMembers {
+		err = cli.putPendingMemberDecision(pendingMember, approval)
+		if err != nil {
+			pendingMember.Error = err.Error()
+			failed = append(failed, pendingMember)
+		}
+	}
+	if len(failed) != 0 {
+		output, err := cli.dumpByFormat(failed, oldYamlConverter("failed pending members"))
+		if err != nil {
+			return nil, err
+		}
+		return nil, &CommandFailedError{
+			Output: *output,
+			Reason: command + " failed for " + strconv.Itoa(len(failed)) + " of " + strconv.Itoa(len(pendingMembers)) + " pending membership requests",
+		}
+	}
+	s := "[" + decision + " " + strconv.Itoa(len(pendingMembers)) + " pending membership requests]"
+	message := SuccessMessage{
+		Status:  200,
+		Message: s,
+	}
+	return cli.dumpByFormat(message, cli.buildYAMLOutput)
+}
@@ -0,0 +1,84 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+func TestPendingRequestMatch(t *testing.T) {
+	now := time.Now()
+	old := rdl.Timestamp{Time: now.Add(-48 * time.Hour)}
+	recent := rdl.Timestamp{Time: now.Add(-1 * time.Hour)}
+	cutoff := now.Add(-24 * time.Hour)
+
+	tests := []struct {
+		member      string
+		requestTime *rdl.Timestamp
+		cutoff      time.Time
+		pattern     string
+		expected    bool
+	}{
+		{"user.john", &old, cutoff, "", true},
+		{"user.john", &recent, cutoff, "", false},
+		{"user.john", nil, cutoff, "", false},
+		{"user.john", nil, time.Time{}, "", true},
+		{"user.john", &recent, time.Time{}, "user.*", true},
+		{"sports.api", &old, cutoff, "user.*", false},
+		{"user.john", &old, cutoff, "user.j*", true},
+	}
+	for _, test := range tests {
+		match, err := pendingRequestMatch(test.member, test.requestTime, test.cutoff, test.pattern)
+		if err != nil {
+			t.Fatalf("unexpected error for member %s: %v", test.member, err)
+		}
+		if match != test.expected {
+			t.Errorf("member %s pattern %s: expected match %v, got %v", test.member, test.pattern, test.expected, match)
+		}
+	}
+	if _, err := pendingRequestMatch("user.john", &old, cutoff, "user.[j"); err == nil {
+		t.Error("expected error for invalid principal pattern")
+	}
+}
+
+func TestPendingMemberFilter(t *testing.T) {
+	requestTime := rdl.Timestamp{Time: time.Now().Add(-48 * time.Hour)}
+	filter := &pendingMemberFilter{name: "readers", pattern: "user.*"}
+	tests := []struct {
+		name     string
+		member   string
+		expected bool
+	}{
+		{"readers", "user.john", true},
+		{"writers", "user.john", false},
+		{"readers", "sports.api", false},
+	}
+	for _, test := range tests {
+		match, err := filter.match(test.name, test.member, &requestTime)
+		if err != nil {
+			t.Fatalf("unexpected error for member %s: %v", test.member, err)
+		}
+		if match != test.expected {
+			t.Errorf("name %s member %s: expected match %v, got %v", test.name, test.member, test.expected, match)
+		}
+	}
+	if !pendingCutoff(0).IsZero() {
+		t.Error("expected no cutoff for 0 days")
+	}
+}
+
+func TestMembershipDecision(t *testing.T) {
+	expiration := rdl.Timestamp{Time: time.Now().Add(24 * time.Hour)}
+	member := membershipDecision("readers", "user.john", &expiration, true)
+	if member.Expiration == nil || member.Approved == nil || !*member.Approved {
+		t.Errorf("unexpected temporary membership decision: %+v", member)
+	}
+	member = membershipDecision("readers", "user.john", nil, false)
+	if member.Expiration != nil || member.Approved == nil || *member.Approved {
+		t.Errorf("unexpected membership decision: %+v", member)
+	}
+}
@@ -429,3 +429,175 @@ func (cli Zms) SetActivePolicyVersion(dn string, pn string, version string) (*st
 	}
 	return cli.dumpByFormat(message, cli.buildYAMLOutput)
 }
+
+// getPolicyAssertion returns the policy assertion identified either by its
+// id or by the assertion string along with the remaining arguments
+func (cli Zms) getPolicyAssertion(dn string, pn string, args []string) (*zms.Assertion, []string, error) {
+	if len(args) == 0 {
+		return nil, nil, fmt.Errorf("assertion id or assertion must be specified")
+	}
+	policy, err := cli.Zms.GetPolicy(zms.DomainName(dn), zms.EntityName(pn))
+	if err != nil {
+		return nil, nil, err
+	}
+	assertionID, err := strconv.ParseInt(args[0], 10, 64)
+	if err == nil {
+		for _, assertion := range policy.Assertions {
+			if assertion.Id != nil && *assertion.Id == assertionID {
+				return assertion, args[1:], nil
+			}
+		}
+		return nil, nil, fmt.Errorf("policy does not have an assertion with id %d", assertionID)
+	}
+	if len(args) < 6 {
+		return nil, nil, fmt.Errorf("bad assertion syntax. should be '<effect> <action> to <role> on <resource>'")
+	}
+	matchAssertion, err := parseAssertion(dn, args[0:6])
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, assertion := range policy.Assertions {
+		if cli.assertionMatch(assertion, matchAssertion) {
+			if assertion.Id == nil {
+				return nil, nil, fmt.Errorf("policy assertion does not have an id")
+			}
+			return assertion, args[6:], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("policy does not have the specified assertion")
+}
+
+// parseAssertionCondition parses the list of conditions specified
+// in the 'key operator value' format into an assertion condition
+func parseAssertionCondition(args []string) (*zms.AssertionCondition, error) {
+	if len(args) == 0 || len(args)%3 != 0 {
+		return nil, fmt.Errorf("bad condition syntax. should be '<key> <operator> <value> [<key> <operator> <value> ...]'")
+	}
+	condition := zms.AssertionCondition{
+		ConditionsMap: make(map[zms.AssertionConditionKey]*zms.AssertionConditionData),
+	}
+	for i := 0; i < len(args); i += 3 {
+		err := addAssertionConditionData(&condition, args[i], args[i+1], args[i+2])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &condition, nil
+}
+
+// addAssertionConditionData adds the 'key operator value' entry to the
+// condition. Condition keys are case-insensitive and stored in lowercase.
+func addAssertionConditionData(condition *zms.AssertionCondition, key string, operator string, value string) error {
+	operator = strings.ToUpper(operator)
+	for _, symbol := range zms.EQUALS.SymbolSet() {
+		if symbol != "" && symbol == operator {
+			condition.ConditionsMap[zms.AssertionConditionKey(strings.ToLower(key))] = &zms.AssertionConditionData{
+				Operator: zms.NewAssertionConditionOperator(operator),
+				Value:    zms.AssertionConditionValue(value),
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported condition operator: %s", operator)
+}
+
+func (cli Zms) ListAssertionConditions(dn string, pn string, args []string) (*string, error) {
+	assertion, args, err := cli.getPolicyAssertion(dn, pn, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 0 {
+		return nil, fmt.Errorf("unexpected arguments after assertion: %s", strings.Join(args, " "))
+	}
+	conditions := assertion.Conditions
+	if conditions == nil {
+		conditions = &zms.AssertionConditions{
+			ConditionsList: make([]*zms.AssertionCondition, 0),
+		}
+	}
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		buf.WriteString("assertion: ")
+		cli.dumpAssertionString(&buf, assertion, dn)
+		buf.WriteString("id: " + strconv.FormatInt(*assertion.Id, 10) + "\n")
+		if len(conditions.ConditionsList) == 0 {
+			buf.WriteString("conditions: []\n")
+		} else {
+			buf.WriteString("conditions:\n")
+			for _, condition := range conditions.ConditionsList {
+				dumpAssertionCondition(&buf, condition, indentLevel1Dash)
+			}
+		}
+		s := buf.String()
+		return &s, nil
+	}
+
+	return cli.dumpByFormat(conditions, oldYamlConverter)
+}
+
+func (cli Zms) AddAssertionCondition(dn string, pn string, args []string) (*string, error) {
+	assertion, args, err := cli.getPolicyAssertion(dn, pn, args)
+	if err != nil {
+		return nil, err
+	}
+	condition, err := parseAssertionCondition(args)
+	if err != nil {
+		return nil, err
+	}
+	_, err = cli.Zms.PutAssertionCondition(zms.DomainName(dn), zms.EntityName(pn), *assertion.Id, cli.AuditRef, condition)
+	if err != nil {
+		return nil, err
+	}
+	if cli.Bulkmode {
+		s := ""
+		return &s, nil
+	}
+	return cli.ListAssertionConditions(dn, pn, []string{strconv.FormatInt(*assertion.Id, 10)})
+}
+
+func (cli Zms) DeleteAssertionCondition(dn string, pn string, args []string) (*string, error) {
+	assertion, args, err := cli.getPolicyAssertion(dn, pn, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("condition id must be specified after the assertion")
+	}
+	conditionID, err := cli.getInt32(args[0])
+	if err != nil {
+		return nil, err
+	}
+	err = cli.Zms.DeleteAssertionCondition(zms.DomainName(dn), zms.EntityName(pn), *assertion.Id, conditionID, cli.AuditRef)
+	if err != nil {
+		return nil, err
+	}
+	s := "[Deleted condition " + args[0] + " from assertion " + strconv.FormatInt(*assertion.Id, 10) + " in policy: " + pn + "]"
+
+	message := SuccessMessage{
+		Status:  200,
+		Message: s,
+	}
+	return cli.dumpByFormat(message, cli.buildYAMLOutput)
+}
+
+func (cli Zms) DeleteAssertionConditions(dn string, pn string, args []string) (*string, error) {
+	assertion, args, err := cli.getPolicyAssertion(dn, pn, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 0 {
+		return nil, fmt.Errorf("unexpected arguments after assertion: %s", strings.Join(args, " "))
+	}
+	err = cli.Zms.DeleteAssertionConditions(zms.DomainName(dn), zms.EntityName(pn), *assertion.Id, cli.AuditRef)
+	if err != nil {
+		return nil, err
+	}
+	s := "[Deleted all conditions from assertion " + strconv.FormatInt(*assertion.Id, 10) + " in policy: " + pn + "]"
+
+	message := SuccessMessage{
+		Status:  200,
+		Message: s,
+	}
+	return cli.dumpByFormat(message, cli.buildYAMLOutput)
+}
@@ -4,9 +4,11 @@
 package zmscli
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
+	"gopkg.in/yaml.v2"
 )
 
 func TestAssertionMatchTrue(t *testing.T) {
@@ -142,3 +144,76 @@ func TestAssertionMatchFalse(t *testing.T) {
 		t.Error("assertion #6 incorrectly matched")
 	}
 }
+
+func TestAssertionConditionsRoundTrip(t *testing.T) {
+	cli := Zms{}
+	assertion, err := parseAssertion("coretech", []string{"grant", "read", "to", "readers", "on", "articles"})
+	if err != nil {
+		t.Fatalf("unable to parse assertion: %v", err)
+	}
+	condition, err := parseAssertionCondition([]string{"instances", "equals", "host1,host2", "enforcementstate", "EQUALS", "report"})
+	if err != nil {
+		t.Fatalf("unable to parse condition: %v", err)
+	}
+	assertion.Conditions = &zms.AssertionConditions{
+		ConditionsList: []*zms.AssertionCondition{condition},
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("assertions:\n")
+	cli.dumpAssertion(&buf, assertion, "coretech", "  - ")
+
+	var spec map[string]interface{}
+	err = yaml.Unmarshal(buf.Bytes(), &spec)
+	if err != nil {
+		t.Fatalf("unable to parse dumped assertion: %v\n%s", err, buf.String())
+	}
+	lstAssertions := spec["assertions"].([]interface{})
+	if len(lstAssertions) != 1 {
+		t.Fatalf("expected 1 assertion, got %d", len(lstAssertions))
+	}
+	newAssertion, err := parseAssertionSpec("coretech", lstAssertions[0])
+	if err != nil {
+		t.Fatalf("unable to parse assertion spec: %v", err)
+	}
+	if !cli.assertionMatch(assertion, newAssertion) {
+		t.Error("assertion didn't match after round trip")
+	}
+	if assertionConditionsString(assertion.Conditions) != assertionConditionsString(newAssertion.Conditions) {
+		t.Error("conditions didn't match after round trip: " + assertionConditionsString(newAssertion.Conditions))
+	}
+}
+
+func TestAssertionConditionKeysLowercase(t *testing.T) {
+	condition, err := parseAssertionCondition([]string{"EnforcementState", "EQUALS", "report"})
+	if err != nil {
+		t.Fatalf("unable to parse condition: %v", err)
+	}
+	if _, ok := condition.ConditionsMap["enforcementstate"]; !ok {
+		t.Errorf("condition key was not lowercased: %v", condition.ConditionsMap)
+	}
+	spec := map[interface{}]interface{}{
+		"assertion":  "grant read to readers on articles",
+		"conditions": []interface{}{map[interface{}]interface{}{"EnforcementState": "EQUALS report"}},
+	}
+	assertion, err := parseAssertionSpec("coretech", spec)
+	if err != nil {
+		t.Fatalf("unable to parse assertion spec: %v", err)
+	}
+	if assertionConditionsString(assertion.Conditions) != assertionConditionsString(&zms.AssertionConditions{
+		ConditionsList: []*zms.AssertionCondition{condition},
+	}) {
+		t.Error("condition keys from the domain file were not lowercased: " + assertionConditionsString(assertion.Conditions))
+	}
+}
+
+func TestParseAssertionConditionInvalid(t *testing.T) {
+	_, err := parseAssertionCondition([]string{"instances", "EQUALS"})
+	if err == nil {
+		t.Error("incomplete condition was parsed successfully")
+	}
+	_, err = parseAssertionCondition([]string{"instances", "MATCHES", "host1"})
+	if err == nil {
+		t.Error("condition with unsupported operator was parsed successfully")
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+// PolicyVersionDiff lists the assertions added and removed
+// between two versions of the same policy
+type PolicyVersionDiff struct {
+	Domain      string                 `json:"domain"`
+	Policy      string                 `json:"policy"`
+	FromVersion string                 `json:"fromVersion"`
+	ToVersion   string                 `json:"toVersion"`
+	Changes     []*DomainAttributeDiff `json:"changes"`
+}
+
+// PolicyPromotionReport is the result of promoting a policy version
+// with the access checks that failed against the candidate version
+type PolicyPromotionReport struct {
+	PolicyVersionDiff
+	Checks   int                       `json:"checks"`
+	Failures []*AccessSimulationResult `json:"failures,omitempty"`
+}
+
+func diffPolicyVersions(dn string, from, to *zms.Policy) *PolicyVersionDiff {
+	object := diffPolicy(dn, from, to)
+	return &PolicyVersionDiff{
+		Domain:      dn,
+		Policy:      localName(string(to.Name), ":policy."),
+		FromVersion: string(from.Version),
+		ToVersion:   string(to.Version),
+		Changes:     object.Changes,
+	}
+}
+
+func (cli Zms) dumpPolicyVersionDiff(buf *bytes.Buffer, diff *PolicyVersionDiff) {
+	dumpStringValue(buf, "", "policy", diff.Policy)
+	dumpStringValue(buf, "", "from-version", diff.FromVersion)
+	dumpStringValue(buf, "", "to-version", diff.ToVersion)
+	if len(diff.Changes) == 0 {
+		buf.WriteString("[no assertion differences found]\n")
+		return
+	}
+	buf.WriteString("assertions:\n")
+	for _, change := range diff.Changes {
+		buf.WriteString(indentLevel1 + diffActionSymbol(change.Action) + " " + change.Value + "\n")
+	}
+}
+
+// DiffPolicyVersion displays the assertions that were added and
+// removed in the second policy version compared to the first one
+func (cli Zms) DiffPolicyVersion(dn, pn, version1, version2 string) (*string, error) {
+	policy1, err := cli.Zms.GetPolicyVersion(zms.DomainName(dn), zms.EntityName(pn), zms.SimpleName(version1))
+	if err != nil {
+		return nil, err
+	}
+	policy2, err := cli.Zms.GetPolicyVersion(zms.DomainName(dn), zms.EntityName(pn), zms.SimpleName(version2))
+	if err != nil {
+		return nil, err
+	}
+	diff := diffPolicyVersions(dn, policy1, policy2)
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		cli.dumpPolicyVersionDiff(&buf, diff)
+		s := buf.String()
+		return &s, nil
+	}
+
+	return cli.dumpByFormat(diff, oldYamlConverter)
+}
+
+// replacePolicy returns a copy of the domain data with the given policy
+// replacing the domain policy with the same name. Non-active versions are
+// returned by the server with the active flag set to false so the copy is
+// marked active to be included when evaluating the access checks.
+func replacePolicy(domainData *zms.DomainData, policy *zms.Policy) *zms.DomainData {
+	active := true
+	activePolicy := *policy
+	activePolicy.Active = &active
+	policy = &activePolicy
+	dn := string(domainData.Name)
+	policyName := fullPolicyName(dn, string(policy.Name))
+	policies := make([]*zms.Policy, 0)
+	for _, domainPolicy := range domainPolicies(domainData) {
+		if fullPolicyName(dn, string(domainPolicy.Name)) != policyName {
+			policies = append(policies, domainPolicy)
+		}
+	}
+	policies = append(policies, policy)
+	candidate := *domainData
+	candidate.Policies = &zms.SignedPolicies{
+		Contents: &zms.DomainPolicies{
+			Domain:   zms.DomainName(dn),
+			Policies: policies,
+		},
+	}
+	return &candidate
+}
+
+// checkPolicyPromotion evaluates the access checks against the current and
+// candidate evaluators. A check fails if the candidate decision does not match
+// the expected decision or, if none is specified, the current decision.
+func checkPolicyPromotion(checks []*AccessCheck, current, candidate *accessEvaluator) ([]*AccessSimulationResult, error) {
+	failures := make([]*AccessSimulationResult, 0)
+	for _, check := range checks {
+		currentExplanation, err := current.explain(check.Principal, check.Action, check.Resource)
+		if err != nil {
+			return nil, err
+		}
+		candidateExplanation, err := candidate.explain(check.Principal, check.Action, check.Resource)
+		if err != nil {
+			return nil, err
+		}
+		expected := check.Expected
+		if expected == "" {
+			expected = accessDecisionString(currentExplanation.Decision)
+		}
+		if accessDecisionString(candidateExplanation.Decision) != expected {
+			failures = append(failures, &AccessSimulationResult{
+				AccessCheck: *check,
+				Current:     currentExplanation.Decision,
+				Proposed:    candidateExplanation.Decision,
+			})
+		}
+	}
+	return failures, nil
+}
+
+func (cli Zms) dumpPolicyPromotion(buf *bytes.Buffer, report *PolicyPromotionReport) {
+	cli.dumpPolicyVersionDiff(buf, &report.PolicyVersionDiff)
+	if len(report.Failures) != 0 {
+		buf.WriteString("failed-checks:\n")
+		for _, result := range report.Failures {
+			dumpStringValue(buf, indentLevel1Dash, "principal", result.Principal)
+			dumpStringValue(buf, indentLevel1DashLvl, "action", result.Action)
+			dumpStringValue(buf, indentLevel1DashLvl, "resource", result.Resource)
+			dumpStringValue(buf, indentLevel1DashLvl, "expected", result.Expected)
+			dumpStringValue(buf, indentLevel1DashLvl, "decision", accessDecisionString(result.Current)+" -> "+accessDecisionString(result.Proposed))
+		}
+	}
+	if report.Checks != 0 {
+		buf.WriteString("[" + strconv.Itoa(report.Checks-len(report.Failures)) + " of " + strconv.Itoa(report.Checks) + " access checks passed]\n")
+	}
+}
+
+// PromotePolicyVersion displays the differences between the active and the
+// given policy version and activates the version once confirmed. If the
+// access matrix file is given, the checks are evaluated against the candidate
+// version first and the activation is refused if any of the decisions change.
+func (cli Zms) PromotePolicyVersion(dn, pn, version, matrixFile string) (*string, error) {
+	active, err := cli.Zms.GetPolicy(zms.DomainName(dn), zms.EntityName(pn))
+	if err != nil {
+		return nil, err
+	}
+	if string(active.Version) == version {
+		return nil, fmt.Errorf("version %s of policy %s is already active", version, pn)
+	}
+	candidatePolicy, err := cli.Zms.GetPolicyVersion(zms.DomainName(dn), zms.EntityName(pn), zms.SimpleName(version))
+	if err != nil {
+		return nil, err
+	}
+	report := &PolicyPromotionReport{
+		PolicyVersionDiff: *diffPolicyVersions(dn, active, candidatePolicy),
+	}
+	if matrixFile != "" {
+		checks, err := cli.parseAccessMatrix(matrixFile, dn)
+		if err != nil {
+			return nil, err
+		}
+		current := newAccessEvaluator(cli.liveDomainData)
+		candidate := newAccessEvaluator(func(name string) (*zms.DomainData, error) {
+			domainData, err := current.domainData(name)
+			if err != nil || name != dn {
+				return domainData, err
+			}
+			return replacePolicy(domainData, candidatePolicy), nil
+		})
+		report.Checks = len(checks)
+		report.Failures, err = checkPolicyPromotion(checks, current, candidate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		cli.dumpPolicyPromotion(&buf, report)
+		s := buf.String()
+		return &s, nil
+	}
+
+	if len(report.Failures) != 0 {
+		output, err := cli.dumpByFormat(report, oldYamlConverter)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &CommandFailedError{
+			Output: *output,
+			Reason: "promote-policy-version refused to activate version " + version + " of policy " + pn +
+				": " + strconv.Itoa(len(report.Failures)) + " access checks failed",
+		}
+	}
+	var buf bytes.Buffer
+	cli.dumpPolicyPromotion(&buf, report)
+	if !cli.confirmChanges(buf.String()) {
+		return nil, fmt.Errorf("promote-policy-version cancelled - version %s was not activated", version)
+	}
+	return cli.SetActivePolicyVersion(dn, pn, version)
+}
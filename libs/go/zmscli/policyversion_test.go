@@ -0,0 +1,112 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+func TestDiffPolicyVersions(t *testing.T) {
+	allow := zms.ALLOW
+	from := &zms.Policy{
+		Name:    "coretech:policy.readers",
+		Version: "0",
+		Assertions: []*zms.Assertion{
+			{Role: "coretech:role.readers", Action: "read", Resource: "coretech:articles.*", Effect: &allow},
+			{Role: "coretech:role.readers", Action: "update", Resource: "coretech:articles.*", Effect: &allow},
+		},
+	}
+	to := &zms.Policy{
+		Name:    "coretech:policy.readers",
+		Version: "dev",
+		Assertions: []*zms.Assertion{
+			{Role: "coretech:role.readers", Action: "read", Resource: "coretech:articles.*", Effect: &allow},
+			{Role: "coretech:role.writers", Action: "update", Resource: "coretech:articles.*", Effect: &allow},
+		},
+	}
+	diff := diffPolicyVersions("coretech", from, to)
+	if diff.Policy != "readers" || diff.FromVersion != "0" || diff.ToVersion != "dev" || len(diff.Changes) != 2 {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+
+	var buf bytes.Buffer
+	cli := Zms{}
+	cli.dumpPolicyVersionDiff(&buf, diff)
+	expected := "policy: readers\nfrom-version: 0\nto-version: dev\nassertions:\n" +
+		"    - grant update to readers on articles.*\n" +
+		"    + grant update to writers on articles.*\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected diff output:\n%s", buf.String())
+	}
+
+	diff = diffPolicyVersions("coretech", from, from)
+	buf.Reset()
+	cli.dumpPolicyVersionDiff(&buf, diff)
+	if len(diff.Changes) != 0 || buf.String() != "policy: readers\nfrom-version: 0\nto-version: 0\n[no assertion differences found]\n" {
+		t.Errorf("unexpected diff output:\n%s", buf.String())
+	}
+}
+
+func TestCheckPolicyPromotion(t *testing.T) {
+	domains := testAccessDomains()
+	current := newAccessEvaluator(func(dn string) (*zms.DomainData, error) {
+		if domainData, ok := domains[dn]; ok {
+			return domainData, nil
+		}
+		return nil, fmt.Errorf("unknown domain %s", dn)
+	})
+	// the candidate version of the blocked policy denies the readers instead
+	// and, as returned by the server for non-active versions, is not active
+	inactive := false
+	deny := zms.DENY
+	candidatePolicy := &zms.Policy{
+		Name:    "coretech:policy.blocked",
+		Version: "dev",
+		Active:  &inactive,
+		Assertions: []*zms.Assertion{
+			{Role: "coretech:role.readers", Action: "read", Resource: "coretech:articles.sports", Effect: &deny},
+		},
+	}
+	candidate := newAccessEvaluator(func(dn string) (*zms.DomainData, error) {
+		domainData, err := current.domainData(dn)
+		if err != nil || dn != "coretech" {
+			return domainData, err
+		}
+		return replacePolicy(domainData, candidatePolicy), nil
+	})
+	if len(domainPolicies(domains["coretech"])) != 2 {
+		t.Fatal("replacing the policy must not modify the current domain")
+	}
+
+	checks := []*AccessCheck{
+		{Principal: "user.john", Action: "read", Resource: "coretech:articles.sports"},
+		{Principal: "user.joe", Action: "read", Resource: "coretech:articles.sports"},
+		{Principal: "user.joe", Action: "read", Resource: "coretech:articles.sports", Expected: "allow"},
+		{Principal: "user.jane", Action: "read", Resource: "coretech:articles.sports", Expected: "allow"},
+	}
+	failures, err := checkPolicyPromotion(checks, current, candidate)
+	if err != nil {
+		t.Fatalf("unable to check promotion: %v", err)
+	}
+	// john is now denied, joe is still denied and jane's membership is expired
+	if len(failures) != 3 {
+		t.Fatalf("expected 3 failures, got %d", len(failures))
+	}
+	if failures[0].Principal != "user.john" || failures[0].Expected != "" || failures[0].Current != accessDecisionAllow || failures[0].Proposed != accessDecisionDeny {
+		t.Errorf("unexpected failure: %+v", failures[0])
+	}
+	if failures[1].Principal != "user.joe" || failures[1].Expected != "allow" || failures[1].Proposed != accessDecisionDeny {
+		t.Errorf("unexpected failure: %+v", failures[1])
+	}
+	if failures[2].Principal != "user.jane" || failures[2].Expected != "allow" {
+		t.Errorf("unexpected failure: %+v", failures[2])
+	}
+	if candidatePolicy.Active == nil || *candidatePolicy.Active {
+		t.Error("replacing the policy must not modify the candidate version")
+	}
+}
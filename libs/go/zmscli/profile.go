@@ -0,0 +1,160 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Profile is a named set of zms-cli settings for a ZMS environment.
+// Any setting specified with a command line option takes precedence.
+type Profile struct {
+	ZmsUrl       string `yaml:"zms,omitempty"`
+	X509KeyFile  string `yaml:"key,omitempty"`
+	X509CertFile string `yaml:"cert,omitempty"`
+	NtokenFile   string `yaml:"ntoken-file,omitempty"`
+	CACertFile   string `yaml:"ca-cert,omitempty"`
+	SocksProxy   string `yaml:"socks-proxy,omitempty"`
+	UserDomain   string `yaml:"user-domain,omitempty"`
+	HomeDomain   string `yaml:"home-domain,omitempty"`
+	OutputFormat string `yaml:"output,omitempty"`
+	Domain       string `yaml:"domain,omitempty"`
+}
+
+// ProfileConfig is the zms-cli configuration file with the named
+// profiles and the name of the profile used by default
+type ProfileConfig struct {
+	Current  string              `yaml:"current,omitempty"`
+	Profiles map[string]*Profile `yaml:"profiles"`
+}
+
+// DefaultProfileConfigFile returns the path of the configuration file in the user's home directory
+func DefaultProfileConfigFile() string {
+	return filepath.Join(os.Getenv("HOME"), ".athenz", "config.yaml")
+}
+
+// LoadProfileConfig reads the configuration file. If the file
+// does not exist, an empty configuration is returned.
+func LoadProfileConfig(filename string) (*ProfileConfig, error) {
+	config := &ProfileConfig{Profiles: make(map[string]*Profile)}
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(data, config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse configuration file %s: %v", filename, err)
+	}
+	if config.Profiles == nil {
+		config.Profiles = make(map[string]*Profile)
+	}
+	return config, nil
+}
+
+// Save writes the configuration file creating its directory if necessary
+func (c *ProfileConfig) Save(filename string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(filename), 0700)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0600)
+}
+
+func (c *ProfileConfig) profileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile returns the profile with the given name or the current profile
+// if no name is given. If neither is set, no profile is returned.
+func (c *ProfileConfig) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = c.Current
+	}
+	if name == "" {
+		return nil, nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return nil, invalidValueError("profile", name, c.profileNames())
+	}
+	return profile, nil
+}
+
+// ProfileCommand executes the config list, use and show subcommands.
+// The selected profile is the one given with the --profile option or
+// the ATHENZ_PROFILE environment variable and is marked in the list.
+func ProfileCommand(filename string, selected string, args []string) (*string, error) {
+	config, err := LoadProfileConfig(filename)
+	if err != nil {
+		return nil, err
+	}
+	if selected == "" {
+		selected = config.Current
+	}
+	var buf bytes.Buffer
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		if len(config.Profiles) == 0 {
+			buf.WriteString("[no profiles configured in " + filename + "]\n")
+		}
+		for _, name := range config.profileNames() {
+			if name == selected {
+				buf.WriteString("* " + name + "\n")
+			} else {
+				buf.WriteString("  " + name + "\n")
+			}
+		}
+	case len(args) == 2 && args[0] == "use":
+		if _, err := config.Profile(args[1]); err != nil {
+			return nil, err
+		}
+		config.Current = args[1]
+		err = config.Save(filename)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("[profile " + args[1] + " is now the current profile]\n")
+	case (len(args) == 1 || len(args) == 2) && args[0] == "show":
+		name := selected
+		if len(args) == 2 {
+			name = args[1]
+		}
+		profile, err := config.Profile(name)
+		if err != nil {
+			return nil, err
+		}
+		if profile == nil {
+			return nil, fmt.Errorf("no profile selected - use the --profile option or the config use command")
+		}
+		data, err := yaml.Marshal(profile)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("profile: " + name + "\n")
+		buf.Write(data)
+	default:
+		return nil, fmt.Errorf("usage: zms-cli config list|use profile|show [profile]")
+	}
+	s := buf.String()
+	return &s, nil
+}
@@ -0,0 +1,73 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProfileConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zms-cli-profile")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, ".athenz", "config.yaml")
+
+	config, err := LoadProfileConfig(filename)
+	if err != nil {
+		t.Fatalf("unable to load missing config file: %v", err)
+	}
+	profile, err := config.Profile("")
+	if err != nil || profile != nil {
+		t.Fatalf("expected no profile without current profile, got %v, %v", profile, err)
+	}
+	config.Profiles["prod"] = &Profile{ZmsUrl: "https://zms.example.com:4443/zms/v1", Domain: "coretech"}
+	config.Profiles["stage"] = &Profile{ZmsUrl: "https://zms-stage.example.com:4443/zms/v1", OutputFormat: "json"}
+	err = config.Save(filename)
+	if err != nil {
+		t.Fatalf("unable to save config file: %v", err)
+	}
+
+	output, err := ProfileCommand(filename, "", []string{"use", "stage"})
+	if err != nil {
+		t.Fatalf("unable to use profile: %v", err)
+	}
+	if !strings.Contains(*output, "stage") {
+		t.Errorf("unexpected use output: %s", *output)
+	}
+	config, err = LoadProfileConfig(filename)
+	if err != nil {
+		t.Fatalf("unable to load config file: %v", err)
+	}
+	profile, err = config.Profile("")
+	if err != nil || profile == nil || profile.OutputFormat != "json" {
+		t.Fatalf("expected current stage profile, got %v, %v", profile, err)
+	}
+
+	output, err = ProfileCommand(filename, "prod", []string{"list"})
+	if err != nil {
+		t.Fatalf("unable to list profiles: %v", err)
+	}
+	if *output != "* prod\n  stage\n" {
+		t.Errorf("unexpected list output: %s", *output)
+	}
+	output, err = ProfileCommand(filename, "", []string{"show", "prod"})
+	if err != nil {
+		t.Fatalf("unable to show profile: %v", err)
+	}
+	if !strings.Contains(*output, "domain: coretech") {
+		t.Errorf("unexpected show output: %s", *output)
+	}
+	if _, err = ProfileCommand(filename, "", []string{"use", "prd"}); err == nil || !strings.Contains(err.Error(), "did you mean: prod") {
+		t.Errorf("expected unknown profile error, got %v", err)
+	}
+	if _, err = ProfileCommand(filename, "", []string{"delete"}); err == nil {
+		t.Error("expected usage error for unknown subcommand")
+	}
+}
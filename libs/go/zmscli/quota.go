@@ -6,6 +6,7 @@ package zmscli
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
@@ -98,3 +99,225 @@ func (cli Zms) SetQuota(dn string, attrs []string) (*string, error) {
 
 	return cli.dumpByFormat(message, cli.buildYAMLOutput)
 }
+
+const defaultQuotaThreshold = 80
+
+// QuotaUsage is the number of objects of a quota dimension
+// used by the domain compared against the domain quota limit.
+// Per-object dimensions such as role members are limited for
+// each object so the count of the largest object is reported.
+type QuotaUsage struct {
+	Dimension string `json:"dimension"`
+	Used      int32  `json:"used"`
+	Limit     int32  `json:"limit"`
+	Percent   int    `json:"percent"`
+	Object    string `json:"object,omitempty"`
+}
+
+// DomainQuotaReport is the quota usage of a domain along with
+// the dimensions whose usage is at or above the threshold
+type DomainQuotaReport struct {
+	Domain        string        `json:"domain"`
+	Usage         []*QuotaUsage `json:"usage"`
+	OverThreshold []string      `json:"overThreshold,omitempty"`
+}
+
+// QuotaReport is the quota usage of the requested domains. When
+// all domains are reported, the system wide counts are included.
+type QuotaReport struct {
+	Threshold int                  `json:"threshold"`
+	Domains   []*DomainQuotaReport `json:"domains"`
+	System    *zms.Stats           `json:"system,omitempty"`
+}
+
+// objectUsage is the name and count of the largest object
+// for a per-object quota dimension
+type objectUsage struct {
+	name string
+	used int32
+}
+
+func (u *objectUsage) update(name string, used int) {
+	if int32(used) > u.used {
+		u.name = name
+		u.used = int32(used)
+	}
+}
+
+// largestObjects returns the object with the most entries for each of the
+// per-object quota dimensions: members per role and group, assertions per
+// policy and hosts and public keys per service.
+func largestObjects(domainData *zms.DomainData) map[string]*objectUsage {
+	roleMember := &objectUsage{}
+	for _, role := range domainData.Roles {
+		roleMember.update(localName(string(role.Name), ":role."), len(role.RoleMembers))
+	}
+	groupMember := &objectUsage{}
+	for _, group := range domainData.Groups {
+		groupMember.update(localName(string(group.Name), ":group."), len(group.GroupMembers))
+	}
+	assertion := &objectUsage{}
+	for _, policy := range domainPolicies(domainData) {
+		assertion.update(localName(string(policy.Name), ":policy."), len(policy.Assertions))
+	}
+	serviceHost := &objectUsage{}
+	publicKey := &objectUsage{}
+	for _, service := range domainData.Services {
+		sn := strings.TrimPrefix(string(service.Name), string(domainData.Name)+".")
+		serviceHost.update(sn, len(service.Hosts))
+		publicKey.update(sn, len(service.PublicKeys))
+	}
+	return map[string]*objectUsage{
+		"role-member":  roleMember,
+		"assertion":    assertion,
+		"service-host": serviceHost,
+		"public-key":   publicKey,
+		"group-member": groupMember,
+	}
+}
+
+// domainQuotaUsage compares the domain object counts against the quota
+// limits using the same dimension names as the set-quota command. A zero
+// limit indicates that the dimension is not restricted. The per-object
+// dimensions are compared against the largest object in the domain data
+// while without domain data, e.g. for system counts, the totals are used.
+func domainQuotaUsage(dn string, quota *zms.Quota, stats *zms.Stats, domainData *zms.DomainData, threshold int) *DomainQuotaReport {
+	dimensions := []struct {
+		name  string
+		used  int32
+		limit int32
+	}{
+		{"role", stats.Role, quota.Role},
+		{"role-member", stats.RoleMember, quota.RoleMember},
+		{"policy", stats.Policy, quota.Policy},
+		{"assertion", stats.Assertion, quota.Assertion},
+		{"service", stats.Service, quota.Service},
+		{"service-host", stats.ServiceHost, quota.ServiceHost},
+		{"public-key", stats.PublicKey, quota.PublicKey},
+		{"entity", stats.Entity, quota.Entity},
+		{"subdomain", stats.Subdomain, quota.Subdomain},
+		{"group", stats.Group, quota.Group},
+		{"group-member", stats.GroupMember, quota.GroupMember},
+	}
+	var largest map[string]*objectUsage
+	if domainData != nil {
+		largest = largestObjects(domainData)
+	}
+	report := &DomainQuotaReport{Domain: dn, Usage: make([]*QuotaUsage, 0)}
+	for _, dimension := range dimensions {
+		usage := &QuotaUsage{
+			Dimension: dimension.name,
+			Used:      dimension.used,
+			Limit:     dimension.limit,
+		}
+		if object, ok := largest[dimension.name]; ok {
+			usage.Used = object.used
+			usage.Object = object.name
+		}
+		if dimension.limit > 0 {
+			usage.Percent = int(int64(usage.Used) * 100 / int64(dimension.limit))
+			if usage.Percent >= threshold {
+				report.OverThreshold = append(report.OverThreshold, dimension.name)
+			}
+		}
+		report.Usage = append(report.Usage, usage)
+	}
+	return report
+}
+
+func (cli Zms) getDomainQuotaUsage(dn string, threshold int) (*DomainQuotaReport, error) {
+	quota, err := cli.Zms.GetQuota(zms.DomainName(dn))
+	if err != nil {
+		return nil, err
+	}
+	stats, err := cli.Zms.GetStats(zms.DomainName(dn))
+	if err != nil {
+		return nil, err
+	}
+	domainData, err := cli.liveDomainData(dn)
+	if err != nil {
+		return nil, err
+	}
+	return domainQuotaUsage(dn, quota, stats, domainData, threshold), nil
+}
+
+func dumpDomainQuotaReport(buf *bytes.Buffer, report *DomainQuotaReport, threshold int) {
+	buf.WriteString(indentLevel1Dash + "domain: " + report.Domain + "\n")
+	buf.WriteString(indentLevel1DashLvl + "usage:\n")
+	for _, usage := range report.Usage {
+		buf.WriteString(indentLevel2 + usage.Dimension + ": " + strconv.Itoa(int(usage.Used)) + "/" + strconv.Itoa(int(usage.Limit)))
+		if usage.Object != "" {
+			buf.WriteString(" in " + usage.Object)
+		}
+		if usage.Limit > 0 {
+			buf.WriteString(" (" + strconv.Itoa(usage.Percent) + "%)")
+			if usage.Percent >= threshold {
+				buf.WriteString(" *** above " + strconv.Itoa(threshold) + "% threshold")
+			}
+		}
+		buf.WriteString("\n")
+	}
+}
+
+// QuotaReport displays the used, limit and percent values for each quota
+// dimension of the domain. For per-object dimensions the usage of the
+// largest object in the domain is displayed. If no domain is given, all domains are processed
+// and only the domains with dimensions at or above the threshold percent are
+// displayed along with the system wide object counts.
+func (cli Zms) QuotaReport(dn string, threshold int) (*string, error) {
+	if threshold <= 0 || threshold > 100 {
+		return nil, fmt.Errorf("invalid threshold %d - must be a percentage between 1 and 100", threshold)
+	}
+	report := &QuotaReport{Threshold: threshold, Domains: make([]*DomainQuotaReport, 0)}
+	if dn != "" {
+		domainReport, err := cli.getDomainQuotaUsage(dn, threshold)
+		if err != nil {
+			return nil, err
+		}
+		report.Domains = append(report.Domains, domainReport)
+	} else {
+		res, err := cli.Zms.GetDomainList(nil, "", "", nil, "", nil, "", "", "", "", "", "", "")
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range res.Names {
+			domainReport, err := cli.getDomainQuotaUsage(string(name), threshold)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Unable to get quota usage for domain %s: %v\n", name, err)
+				continue
+			}
+			if len(domainReport.OverThreshold) != 0 {
+				report.Domains = append(report.Domains, domainReport)
+			}
+		}
+		report.System, err = cli.Zms.GetSystemStats()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		buf.WriteString("threshold: " + strconv.Itoa(threshold) + "%\n")
+		if len(report.Domains) == 0 {
+			buf.WriteString("[no domains with quota usage above the threshold]\n")
+		} else {
+			buf.WriteString("domains:\n")
+			for _, domainReport := range report.Domains {
+				dumpDomainQuotaReport(&buf, domainReport, threshold)
+			}
+		}
+		if report.System != nil {
+			buf.WriteString("system:\n")
+			// system counts have no limits so only the used values are displayed
+			system := domainQuotaUsage("", &zms.Quota{}, report.System, nil, threshold)
+			for _, usage := range system.Usage {
+				buf.WriteString(indentLevel1 + usage.Dimension + ": " + strconv.Itoa(int(usage.Used)) + "\n")
+			}
+		}
+		s := buf.String()
+		return &s, nil
+	}
+
+	return cli.dumpByFormat(report, oldYamlConverter)
+}
@@ -0,0 +1,62 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+func TestDomainQuotaUsage(t *testing.T) {
+	quota := &zms.Quota{Role: 100, RoleMember: 4, Policy: 50, GroupMember: 10, ServiceHost: 2}
+	stats := &zms.Stats{Role: 80, RoleMember: 250, Policy: 49, GroupMember: 10, Service: 5, ServiceHost: 2}
+	domainData := &zms.DomainData{
+		Name: "coretech",
+		Roles: []*zms.Role{
+			{Name: "coretech:role.readers", RoleMembers: []*zms.RoleMember{{MemberName: "user.jane"}}},
+			{Name: "coretech:role.writers", RoleMembers: []*zms.RoleMember{{MemberName: "user.john"}, {MemberName: "user.joe"}}},
+		},
+		Groups: []*zms.Group{
+			{Name: "coretech:group.devs", GroupMembers: []*zms.GroupMember{{MemberName: "user.john"}}},
+		},
+		Services: []*zms.ServiceIdentity{
+			{Name: "coretech.api", Hosts: []string{"host1.example.com"}},
+			{Name: "coretech.backend", Hosts: []string{"host2.example.com"}},
+		},
+	}
+	report := domainQuotaUsage("coretech", quota, stats, domainData, 80)
+	if len(report.Usage) != 11 {
+		t.Fatalf("expected 11 quota dimensions, got %d", len(report.Usage))
+	}
+	expected := map[string]int{"role": 80, "role-member": 50, "policy": 98, "group-member": 10, "service": 0, "service-host": 50}
+	objects := map[string]string{"role-member": "writers", "group-member": "devs", "service-host": "api", "role": ""}
+	for _, usage := range report.Usage {
+		percent, ok := expected[usage.Dimension]
+		if ok && usage.Percent != percent {
+			t.Errorf("dimension %s: expected %d%%, got %d%%", usage.Dimension, percent, usage.Percent)
+		}
+		object, ok := objects[usage.Dimension]
+		if ok && usage.Object != object {
+			t.Errorf("dimension %s: expected object %q, got %q", usage.Dimension, object, usage.Object)
+		}
+	}
+	over := []string{"role", "policy"}
+	if len(report.OverThreshold) != len(over) {
+		t.Fatalf("expected dimensions %v over threshold, got %v", over, report.OverThreshold)
+	}
+	for i, dimension := range over {
+		if report.OverThreshold[i] != dimension {
+			t.Errorf("expected dimension %s over threshold, got %s", dimension, report.OverThreshold[i])
+		}
+	}
+
+	// without domain data the per-object dimensions use the total counts
+	report = domainQuotaUsage("", &zms.Quota{}, stats, nil, 80)
+	for _, usage := range report.Usage {
+		if usage.Dimension == "role-member" && (usage.Used != 250 || usage.Object != "") {
+			t.Errorf("unexpected system role-member usage: %+v", usage)
+		}
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+const (
+	replacementStatusPlanned    = "planned"
+	replacementStatusReplaced   = "replaced"
+	replacementStatusSkipped    = "skipped"
+	replacementStatusFailed     = "failed"
+	replacementStatusRolledBack = "rolled-back"
+)
+
+// MemberReplacement is a single role or group membership of the old
+// member that is replaced with the new member. The original expiration
+// and review dates are kept so the change can be rolled back.
+type MemberReplacement struct {
+	Domain          string         `json:"domain"`
+	Type            string         `json:"type"`
+	Name            string         `json:"name"`
+	Expiration      *rdl.Timestamp `json:"expiration,omitempty"`
+	ReviewReminder  *rdl.Timestamp `json:"reviewReminder,omitempty"`
+	NewMemberExists bool           `json:"newMemberExists,omitempty"`
+	Status          string         `json:"status"`
+	Message         string         `json:"message,omitempty"`
+}
+
+// MemberReplacementReport lists all the memberships processed by the
+// replace-member command. The same report is stored in the rollback
+// file and used by the rollback-replace-member command.
+type MemberReplacementReport struct {
+	OldMember    string               `json:"oldMember"`
+	NewMember    string               `json:"newMember"`
+	KeepExpiry   bool                 `json:"keepExpiry,omitempty"`
+	DryRun       bool                 `json:"dryRun,omitempty"`
+	RollbackFile string               `json:"rollbackFile,omitempty"`
+	Replacements []*MemberReplacement `json:"replacements"`
+}
+
+func (report *MemberReplacementReport) count(status string) int {
+	count := 0
+	for _, replacement := range report.Replacements {
+		if replacement.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+func replacementKey(dn, objectType, name string) string {
+	return objectType + ":" + dn + ":" + name
+}
+
+// inDomainHierarchy returns true if the domain is the given parent domain
+// or one of its sub-domains. An empty parent domain matches all domains.
+func inDomainHierarchy(dn, parent string) bool {
+	return parent == "" || dn == parent || strings.HasPrefix(dn, parent+".")
+}
+
+// planMemberReplacements returns the replacements for all the role and
+// group memberships of the old member in the given domain and its sub-domains.
+// The memberships of the new member are used to detect objects where it's
+// already a member so it's not added again nor removed during rollback.
+func planMemberReplacements(newMember, prefix string, oldRoles *zms.DomainRoleMember, oldGroups *zms.DomainGroupMember,
+	newRoles *zms.DomainRoleMember, newGroups *zms.DomainGroupMember) []*MemberReplacement {
+
+	existing := make(map[string]bool)
+	if newRoles != nil {
+		for _, role := range newRoles.MemberRoles {
+			existing[replacementKey(string(role.DomainName), "role", localName(string(role.RoleName), ":role."))] = true
+		}
+	}
+	if newGroups != nil {
+		for _, group := range newGroups.MemberGroups {
+			existing[replacementKey(string(group.DomainName), "group", localName(string(group.GroupName), ":group."))] = true
+		}
+	}
+	replacements := make([]*MemberReplacement, 0)
+	add := func(dn, objectType, name string, expiration, reviewReminder *rdl.Timestamp) {
+		if !inDomainHierarchy(dn, prefix) {
+			return
+		}
+		replacement := &MemberReplacement{
+			Domain:          dn,
+			Type:            objectType,
+			Name:            name,
+			Expiration:      expiration,
+			ReviewReminder:  reviewReminder,
+			NewMemberExists: existing[replacementKey(dn, objectType, name)],
+			Status:          replacementStatusPlanned,
+		}
+		// groups can only include users and services as members
+		if objectType == "group" && strings.Contains(newMember, ":group.") {
+			replacement.Status = replacementStatusSkipped
+			replacement.Message = "groups cannot include other groups as members"
+		}
+		replacements = append(replacements, replacement)
+	}
+	if oldRoles != nil {
+		for _, role := range oldRoles.MemberRoles {
+			add(string(role.DomainName), "role", localName(string(role.RoleName), ":role."), role.Expiration, role.ReviewReminder)
+		}
+	}
+	if oldGroups != nil {
+		for _, group := range oldGroups.MemberGroups {
+			add(string(group.DomainName), "group", localName(string(group.GroupName), ":group."), group.Expiration, nil)
+		}
+	}
+	return replacements
+}
+
+func (cli Zms) putReplacementMember(replacement *MemberReplacement, member string, expiration, reviewReminder *rdl.Timestamp, auditRef string) error {
+	dn := zms.DomainName(replacement.Domain)
+	name := zms.EntityName(replacement.Name)
+	if replacement.Type == "group" {
+		membership := zms.GroupMembership{
+			MemberName: zms.GroupMemberName(member),
+			GroupName:  zms.ResourceName(replacement.Name),
+			Expiration: expiration,
+		}
+		return cli.Zms.PutGroupMembership(dn, name, zms.GroupMemberName(member), auditRef, &membership)
+	}
+	membership := zms.Membership{
+		MemberName:     zms.MemberName(member),
+		RoleName:       zms.ResourceName(replacement.Name),
+		Expiration:     expiration,
+		ReviewReminder: reviewReminder,
+	}
+	return cli.Zms.PutMembership(dn, name, zms.MemberName(member), auditRef, &membership)
+}
+
+func (cli Zms) deleteReplacementMember(replacement *MemberReplacement, member string, auditRef string) error {
+	dn := zms.DomainName(replacement.Domain)
+	name := zms.EntityName(replacement.Name)
+	if replacement.Type == "group" {
+		return cli.Zms.DeleteGroupMembership(dn, name, zms.GroupMemberName(member), auditRef)
+	}
+	return cli.Zms.DeleteMembership(dn, name, zms.MemberName(member), auditRef)
+}
+
+// replaceMember adds the new member and only if that's successful
+// deletes the old member from the role or group
+func (cli Zms) replaceMember(report *MemberReplacementReport, replacement *MemberReplacement, auditRef string) {
+	if !replacement.NewMemberExists {
+		var expiration, reviewReminder *rdl.Timestamp
+		if report.KeepExpiry {
+			expiration = replacement.Expiration
+			reviewReminder = replacement.ReviewReminder
+		}
+		err := cli.putReplacementMember(replacement, report.NewMember, expiration, reviewReminder, auditRef)
+		if err != nil {
+			replacement.Status = replacementStatusFailed
+			replacement.Message = "unable to add " + report.NewMember + ": " + err.Error()
+			return
+		}
+	}
+	err := cli.deleteReplacementMember(replacement, report.OldMember, auditRef)
+	if err != nil {
+		replacement.Status = replacementStatusFailed
+		replacement.Message = "unable to delete " + report.OldMember + ": " + err.Error()
+		if !replacement.NewMemberExists {
+			replacement.Message += " (" + report.NewMember + " was added)"
+		}
+		return
+	}
+	replacement.Status = replacementStatusReplaced
+}
+
+// rollbackMember restores the old member with its original expiration
+// and review dates and deletes the new member unless it was already
+// a member before the replacement
+func (cli Zms) rollbackMember(report *MemberReplacementReport, replacement *MemberReplacement, auditRef string) error {
+	err := cli.putReplacementMember(replacement, report.OldMember, replacement.Expiration, replacement.ReviewReminder, auditRef)
+	if err != nil {
+		return fmt.Errorf("unable to restore %s: %v", report.OldMember, err)
+	}
+	if !replacement.NewMemberExists {
+		err = cli.deleteReplacementMember(replacement, report.NewMember, auditRef)
+		if err != nil {
+			return fmt.Errorf("unable to delete %s: %v", report.NewMember, err)
+		}
+	}
+	return nil
+}
+
+func (cli Zms) dumpMemberReplacements(buf *bytes.Buffer, report *MemberReplacementReport) {
+	dumpStringValue(buf, "", "old-member", report.OldMember)
+	dumpStringValue(buf, "", "new-member", report.NewMember)
+	if len(report.Replacements) != 0 {
+		buf.WriteString("memberships:\n")
+		for _, replacement := range report.Replacements {
+			dumpStringValue(buf, indentLevel1Dash, replacement.Type, replacement.Domain+":"+replacement.Type+"."+replacement.Name)
+			if replacement.Expiration != nil {
+				dumpStringValue(buf, indentLevel1DashLvl, "expiration", replacement.Expiration.String())
+			}
+			if replacement.ReviewReminder != nil {
+				dumpStringValue(buf, indentLevel1DashLvl, "review", replacement.ReviewReminder.String())
+			}
+			if replacement.NewMemberExists {
+				dumpStringValue(buf, indentLevel1DashLvl, "new-member-exists", "true")
+			}
+			dumpStringValue(buf, indentLevel1DashLvl, "status", replacement.Status)
+			dumpStringValue(buf, indentLevel1DashLvl, "message", replacement.Message)
+		}
+	}
+	dumpStringValue(buf, "", "rollback-file", report.RollbackFile)
+}
+
+func (cli Zms) replacementAuditRef(oldMember, newMember string) string {
+	if cli.AuditRef != "" {
+		return cli.AuditRef
+	}
+	return "replace-member " + oldMember + " with " + newMember
+}
+
+func writeReplacementReport(filename string, report *MemberReplacementReport) error {
+	data, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// memberReplacementOutput returns the report in the requested format and
+// if any of the changes failed, the output is included in the error
+func (cli Zms) memberReplacementOutput(report *MemberReplacementReport, command string, failed int) (*string, error) {
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		cli.dumpMemberReplacements(&buf, report)
+		s := buf.String()
+		return &s, nil
+	}
+	output, err := cli.dumpByFormat(report, oldYamlConverter)
+	if err != nil {
+		return nil, err
+	}
+	if failed != 0 {
+		return nil, &CommandFailedError{
+			Output: *output,
+			Reason: command + " failed to process " + strconv.Itoa(failed) + " memberships",
+		}
+	}
+	return output, nil
+}
+
+// ReplaceMember replaces the old member with the new member in every
+// role and group the old member belongs to in the given domain and its
+// sub-domains. The rollback file is written with all the planned changes
+// before any membership is changed and updated after each replacement so
+// the changes can be reverted with the rollback-replace-member command.
+func (cli Zms) ReplaceMember(oldMember, newMember, prefix string, keepExpiry, dryRun bool, rollbackFile string) (*string, error) {
+	oldMember = cli.validatedUser(oldMember)
+	newMember = cli.validatedUser(newMember)
+	if oldMember == newMember {
+		return nil, fmt.Errorf("old and new members must be different")
+	}
+	oldRoles, err := cli.Zms.GetPrincipalRoles(zms.ResourceName(oldMember), "")
+	if err != nil {
+		return nil, err
+	}
+	newRoles, err := cli.Zms.GetPrincipalRoles(zms.ResourceName(newMember), "")
+	if err != nil {
+		return nil, err
+	}
+	// group members can't be groups so there are no group memberships to look up
+	var oldGroups, newGroups *zms.DomainGroupMember
+	if !strings.Contains(oldMember, ":group.") {
+		oldGroups, err = cli.Zms.GetPrincipalGroups(zms.EntityName(oldMember), "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !strings.Contains(newMember, ":group.") {
+		newGroups, err = cli.Zms.GetPrincipalGroups(zms.EntityName(newMember), "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	report := &MemberReplacementReport{
+		OldMember:    oldMember,
+		NewMember:    newMember,
+		KeepExpiry:   keepExpiry,
+		DryRun:       dryRun,
+		Replacements: planMemberReplacements(newMember, prefix, oldRoles, oldGroups, newRoles, newGroups),
+	}
+	if dryRun || report.count(replacementStatusPlanned) == 0 {
+		return cli.memberReplacementOutput(report, "replace-member", report.count(replacementStatusFailed))
+	}
+
+	var buf bytes.Buffer
+	cli.dumpMemberReplacements(&buf, report)
+	if !cli.confirmChanges(buf.String()) {
+		return nil, fmt.Errorf("replace-member cancelled - no memberships were changed")
+	}
+	if rollbackFile == "" {
+		rollbackFile = "replace-member-rollback-" + time.Now().UTC().Format("20060102T150405") + ".json"
+	}
+	report.RollbackFile, err = filepath.Abs(rollbackFile)
+	if err != nil {
+		return nil, err
+	}
+	err = writeReplacementReport(report.RollbackFile, report)
+	if err != nil {
+		return nil, fmt.Errorf("unable to write rollback file %s - no memberships were changed: %v", report.RollbackFile, err)
+	}
+	auditRef := cli.replacementAuditRef(oldMember, newMember)
+	for _, replacement := range report.Replacements {
+		if replacement.Status != replacementStatusPlanned {
+			continue
+		}
+		cli.replaceMember(report, replacement, auditRef)
+		err = writeReplacementReport(report.RollbackFile, report)
+		if err != nil {
+			return nil, fmt.Errorf("unable to update rollback file %s after processing %s:%s.%s - remaining memberships were not changed: %v",
+				report.RollbackFile, replacement.Domain, replacement.Type, replacement.Name, err)
+		}
+	}
+	return cli.memberReplacementOutput(report, "replace-member", report.count(replacementStatusFailed))
+}
+
+// RollbackReplaceMember reverts all the successfully replaced memberships
+// recorded in the rollback file generated by the replace-member command
+func (cli Zms) RollbackReplaceMember(rollbackFile string) (*string, error) {
+	data, err := ioutil.ReadFile(rollbackFile)
+	if err != nil {
+		return nil, err
+	}
+	var report MemberReplacementReport
+	err = json.Unmarshal(data, &report)
+	if err != nil {
+		return nil, err
+	}
+	if report.DryRun {
+		return nil, fmt.Errorf("%s was generated by a dry run - there are no changes to roll back", rollbackFile)
+	}
+	auditRef := cli.replacementAuditRef(report.OldMember, report.NewMember)
+	// memberships that fail to be rolled back keep their replaced status
+	// so running the rollback again only processes the failed ones
+	rolledBack := make([]*MemberReplacement, 0)
+	failed := 0
+	for _, replacement := range report.Replacements {
+		if replacement.Status != replacementStatusReplaced {
+			continue
+		}
+		err = cli.rollbackMember(&report, replacement, auditRef)
+		if err != nil {
+			replacement.Message = err.Error()
+			failed++
+		} else {
+			replacement.Status = replacementStatusRolledBack
+			replacement.Message = ""
+		}
+		rolledBack = append(rolledBack, replacement)
+	}
+	err = writeReplacementReport(rollbackFile, &report)
+	if err != nil {
+		return nil, err
+	}
+	report.Replacements = rolledBack
+	report.RollbackFile = ""
+	return cli.memberReplacementOutput(&report, "rollback-replace-member", failed)
+}
@@ -0,0 +1,140 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+func TestPlanMemberReplacements(t *testing.T) {
+	expiration := rdl.Timestamp{Time: time.Now().Add(24 * time.Hour)}
+	oldRoles := &zms.DomainRoleMember{
+		MemberName: "user.alice",
+		MemberRoles: []*zms.MemberRole{
+			{DomainName: "coretech", RoleName: "readers", Expiration: &expiration},
+			{DomainName: "coretech.api", RoleName: "writers"},
+			{DomainName: "sports", RoleName: "readers"},
+			{DomainName: "coretechnology", RoleName: "readers"},
+		},
+	}
+	oldGroups := &zms.DomainGroupMember{
+		MemberName: "user.alice",
+		MemberGroups: []*zms.GroupMember{
+			{DomainName: "coretech", GroupName: "devs"},
+		},
+	}
+	newRoles := &zms.DomainRoleMember{
+		MemberName: "user.bob",
+		MemberRoles: []*zms.MemberRole{
+			{DomainName: "coretech.api", RoleName: "writers"},
+		},
+	}
+
+	replacements := planMemberReplacements("user.bob", "coretech", oldRoles, oldGroups, newRoles, nil)
+	if len(replacements) != 3 {
+		t.Fatalf("expected 3 replacements, got %d", len(replacements))
+	}
+	replacement := replacements[0]
+	if replacement.Domain != "coretech" || replacement.Type != "role" || replacement.Name != "readers" ||
+		replacement.Expiration != &expiration || replacement.NewMemberExists || replacement.Status != replacementStatusPlanned {
+		t.Errorf("unexpected readers replacement: %v", replacement)
+	}
+	if !replacements[1].NewMemberExists {
+		t.Error("new member must be detected as an existing writers member")
+	}
+	if replacements[2].Type != "group" || replacements[2].Status != replacementStatusPlanned {
+		t.Errorf("unexpected group replacement: %v", replacements[2])
+	}
+
+	// groups can't be added to other groups
+	replacements = planMemberReplacements("coretech:group.admins", "", oldRoles, oldGroups, nil, nil)
+	if len(replacements) != 5 {
+		t.Fatalf("expected 5 replacements, got %d", len(replacements))
+	}
+	if replacements[4].Status != replacementStatusSkipped || replacements[4].Message == "" {
+		t.Errorf("group membership must be skipped for group members: %v", replacements[4])
+	}
+	report := MemberReplacementReport{Replacements: replacements}
+	if report.count(replacementStatusPlanned) != 4 || report.count(replacementStatusSkipped) != 1 {
+		t.Errorf("unexpected status counts: %d planned %d skipped", report.count(replacementStatusPlanned), report.count(replacementStatusSkipped))
+	}
+}
+
+func TestInDomainHierarchy(t *testing.T) {
+	tests := []struct {
+		dn       string
+		parent   string
+		expected bool
+	}{
+		{"sports", "sports", true},
+		{"sports.api", "sports", true},
+		{"sportsbook", "sports", false},
+		{"sportsbook", "", true},
+		{"coretech", "sports", false},
+	}
+	for _, test := range tests {
+		if inDomainHierarchy(test.dn, test.parent) != test.expected {
+			t.Errorf("%s in %s: expected %v", test.dn, test.parent, test.expected)
+		}
+	}
+}
+
+func TestReplacementAuditRef(t *testing.T) {
+	cli := Zms{}
+	if auditRef := cli.replacementAuditRef("user.alice", "user.bob"); auditRef != "replace-member user.alice with user.bob" {
+		t.Errorf("unexpected generated audit ref: %s", auditRef)
+	}
+	cli.AuditRef = "TICKET-1234"
+	if auditRef := cli.replacementAuditRef("user.alice", "user.bob"); auditRef != "TICKET-1234" {
+		t.Errorf("unexpected audit ref: %s", auditRef)
+	}
+}
+
+func TestRollbackReplaceMemberDryRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zms-cli-replace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "rollback.json")
+	report := &MemberReplacementReport{
+		OldMember: "user.alice",
+		NewMember: "user.bob",
+		DryRun:    true,
+		Replacements: []*MemberReplacement{
+			{Domain: "coretech", Type: "role", Name: "readers", Status: replacementStatusPlanned},
+		},
+	}
+	err = writeReplacementReport(filename, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := Zms{UserDomain: "user", OutputFormat: DefaultOutputFormat}
+	if _, err = cli.RollbackReplaceMember(filename); err == nil {
+		t.Error("expected error rolling back a dry run report")
+	}
+
+	// without any replaced memberships there is nothing to roll back
+	report.DryRun = false
+	report.Replacements[0].Status = replacementStatusFailed
+	err = writeReplacementReport(filename, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := cli.RollbackReplaceMember(filename)
+	if err != nil {
+		t.Fatalf("unexpected rollback error: %v", err)
+	}
+	if *output != "old-member: user.alice\nnew-member: user.bob\n" {
+		t.Errorf("unexpected rollback output: %q", *output)
+	}
+}
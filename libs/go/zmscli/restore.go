@@ -0,0 +1,331 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	restoreStatusRestored = "restored"
+	restoreStatusSkipped  = "skipped"
+	restoreStatusFailed   = "failed"
+
+	restoreCheckpointFile = ".zms-restore-checkpoint"
+)
+
+// DomainRestoreResult is the outcome of restoring a single domain
+type DomainRestoreResult struct {
+	Domain string `json:"domain"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RestoreReport is the list of per-domain results of a system restore
+type RestoreReport struct {
+	Checkpoint string                 `json:"checkpoint"`
+	Domains    []*DomainRestoreResult `json:"domains"`
+}
+
+func (report *RestoreReport) count(status string) int {
+	count := 0
+	for _, result := range report.Domains {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// backupDomain writes the full domain data object, including the
+// non-active policy versions, into the given directory using json if
+// that's the requested output format and yaml otherwise so that it
+// can be restored without any loss
+func (cli Zms) backupDomain(dir string, dn string) error {
+	domainData, err := cli.liveDomainData(dn)
+	if err != nil {
+		return err
+	}
+	assertions := true
+	includeNonActive := true
+	policies, err := cli.Zms.GetPolicies(zms.DomainName(dn), &assertions, &includeNonActive)
+	if err != nil {
+		return err
+	}
+	domainData.Policies.Contents.Policies = policies.List
+	var data []byte
+	filename := filepath.Join(dir, dn)
+	if cli.OutputFormat == JSONOutputFormat {
+		data, err = json.MarshalIndent(domainData, "", "    ")
+		filename += ".json"
+	} else {
+		data, err = yaml.Marshal(domainData)
+		filename += ".yaml"
+	}
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// sortDomainsByHierarchy sorts the domains so that all parent domains
+// are processed before their sub-domains
+func sortDomainsByHierarchy(domains []*zms.DomainData) {
+	sort.SliceStable(domains, func(i, j int) bool {
+		name1 := string(domains[i].Name)
+		name2 := string(domains[j].Name)
+		level1 := strings.Count(name1, ".")
+		level2 := strings.Count(name2, ".")
+		if level1 != level2 {
+			return level1 < level2
+		}
+		return name1 < name2
+	})
+}
+
+func readRestoreCheckpoint(filename string) (map[string]bool, error) {
+	completed := make(map[string]bool)
+	file, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return completed, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name != "" {
+			completed[name] = true
+		}
+	}
+	return completed, scanner.Err()
+}
+
+func appendRestoreCheckpoint(filename string, dn string) error {
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(dn + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// restorePolicyVersion creates the non-active policy version from the
+// active version if it doesn't exist and then updates its assertions
+// to match the backup data
+func (cli Zms) restorePolicyVersion(dn string, desired *zms.DomainData, policy *zms.Policy) error {
+	pn := localName(string(policy.Name), ":policy.")
+	version := policy.Version
+	current, err := cli.Zms.GetPolicyVersion(zms.DomainName(dn), zms.EntityName(pn), version)
+	if err != nil {
+		switch v := err.(type) {
+		case rdl.ResourceError:
+			if v.Code != 404 {
+				return v
+			}
+		default:
+			return err
+		}
+		options := zms.PolicyOptions{Version: version}
+		if active := findPolicy(desired, pn); active != nil {
+			options.FromVersion = active.Version
+		}
+		err = cli.Zms.PutPolicyVersion(zms.DomainName(dn), zms.EntityName(pn), &options, cli.AuditRef)
+		if err != nil {
+			return err
+		}
+		current, err = cli.Zms.GetPolicyVersion(zms.DomainName(dn), zms.EntityName(pn), version)
+		if err != nil {
+			return err
+		}
+	}
+	desiredAssertions := make(map[string]bool)
+	for _, assertion := range policy.Assertions {
+		desiredAssertions[assertionString(dn, assertion)] = true
+	}
+	currentAssertions := make(map[string]bool)
+	for _, assertion := range current.Assertions {
+		currentAssertions[assertionString(dn, assertion)] = true
+		if desiredAssertions[assertionString(dn, assertion)] || assertion.Id == nil {
+			continue
+		}
+		err = cli.Zms.DeleteAssertionPolicyVersion(zms.DomainName(dn), zms.EntityName(pn), version, *assertion.Id, cli.AuditRef)
+		if err != nil {
+			return err
+		}
+	}
+	for _, assertion := range policy.Assertions {
+		if currentAssertions[assertionString(dn, assertion)] {
+			continue
+		}
+		newAssertion := *assertion
+		newAssertion.Id = nil
+		newAssertion.Conditions = nil
+		added, err := cli.Zms.PutAssertionPolicyVersion(zms.DomainName(dn), zms.EntityName(pn), version, cli.AuditRef, &newAssertion)
+		if err != nil {
+			return err
+		}
+		if assertion.Conditions == nil || len(assertion.Conditions.ConditionsList) == 0 || added.Id == nil {
+			continue
+		}
+		_, err = cli.Zms.PutAssertionConditions(zms.DomainName(dn), zms.EntityName(pn), *added.Id, cli.AuditRef, copyAssertionConditions(assertion.Conditions))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreDomain creates the domain if it doesn't exist and then applies
+// all the differences between the live domain and the backup data. The
+// non-active policy versions and the enabled flag are restored last.
+func (cli Zms) restoreDomain(desired *zms.DomainData) error {
+	dn := string(desired.Name)
+	_, err := cli.Zms.GetDomain(zms.DomainName(dn))
+	if err != nil {
+		switch v := err.(type) {
+		case rdl.ResourceError:
+			if v.Code != 404 {
+				return v
+			}
+		default:
+			return err
+		}
+		admins := make([]string, 0)
+		if adminRole := findRole(desired, "admin"); adminRole != nil {
+			for _, member := range adminRole.RoleMembers {
+				if !isPendingMember(member.Approved) {
+					admins = append(admins, string(member.MemberName))
+				}
+			}
+		}
+		if len(admins) == 0 {
+			admins = cli.validatedUsers(nil, true)
+		}
+		_, err = cli.createDomain(dn, desired.YpmId, admins)
+		if err != nil {
+			return err
+		}
+	}
+	current, err := cli.liveDomainData(dn)
+	if err != nil {
+		return err
+	}
+	err = cli.applyDomainDiff(dn, diffDomainData(current, desired), current, desired)
+	if err != nil {
+		return err
+	}
+	for _, policy := range nonActivePolicies(desired) {
+		err = cli.restorePolicyVersion(dn, desired, policy)
+		if err != nil {
+			return fmt.Errorf("unable to restore policy %s version %s: %v", policy.Name, policy.Version, err)
+		}
+	}
+	// domains without the enabled flag are enabled
+	if desired.Enabled != nil && *desired.Enabled != (current.Enabled == nil || *current.Enabled) {
+		meta := zms.DomainMeta{Enabled: desired.Enabled}
+		return cli.Zms.PutDomainSystemMeta(zms.DomainName(dn), "enabled", cli.AuditRef, &meta)
+	}
+	return nil
+}
+
+func (cli Zms) dumpRestoreReport(buf *bytes.Buffer, report *RestoreReport) {
+	buf.WriteString("domains:\n")
+	for _, result := range report.Domains {
+		dumpStringValue(buf, indentLevel1Dash, "domain", result.Domain)
+		dumpStringValue(buf, indentLevel1DashLvl, "status", result.Status)
+		dumpStringValue(buf, indentLevel1DashLvl, "error", result.Error)
+	}
+	buf.WriteString("[restored " + strconv.Itoa(report.count(restoreStatusRestored)) + " domains, skipped " +
+		strconv.Itoa(report.count(restoreStatusSkipped)) + ", failed " + strconv.Itoa(report.count(restoreStatusFailed)) + "]\n")
+	if report.count(restoreStatusFailed) != 0 {
+		buf.WriteString("[fix the failed domains and re-run the command to resume using checkpoint " + report.Checkpoint + "]\n")
+	}
+}
+
+// SystemRestore restores all domains from the backup files in the given
+// directory. Domains that were restored successfully are recorded in the
+// checkpoint file and are skipped if the command is executed again.
+func (cli Zms) SystemRestore(dir string, checkpoint string) (*string, error) {
+	if checkpoint == "" {
+		checkpoint = filepath.Join(dir, restoreCheckpointFile)
+	}
+	completed, err := readRestoreCheckpoint(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	report := RestoreReport{
+		Checkpoint: checkpoint,
+		Domains:    make([]*DomainRestoreResult, 0),
+	}
+	domains := make([]*zms.DomainData, 0)
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		domainData, err := loadDomainFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			report.Domains = append(report.Domains, &DomainRestoreResult{
+				Domain: file.Name(),
+				Status: restoreStatusFailed,
+				Error:  "unable to load backup file: " + err.Error(),
+			})
+			continue
+		}
+		domains = append(domains, domainData)
+	}
+	sortDomainsByHierarchy(domains)
+	for _, domainData := range domains {
+		dn := string(domainData.Name)
+		result := DomainRestoreResult{
+			Domain: dn,
+			Status: restoreStatusRestored,
+		}
+		if completed[dn] {
+			result.Status = restoreStatusSkipped
+		} else {
+			_, _ = fmt.Fprintf(os.Stderr, "Restoring domain "+dn+"...\n")
+			err = cli.restoreDomain(domainData)
+			if err == nil {
+				err = appendRestoreCheckpoint(checkpoint, dn)
+			}
+			if err != nil {
+				result.Status = restoreStatusFailed
+				result.Error = err.Error()
+			}
+		}
+		report.Domains = append(report.Domains, &result)
+	}
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		cli.dumpRestoreReport(&buf, &report)
+		s := buf.String()
+		return &s, nil
+	}
+
+	return cli.dumpByFormat(report, oldYamlConverter)
+}
@@ -0,0 +1,241 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"gopkg.in/yaml.v2"
+)
+
+func TestSortDomainsByHierarchy(t *testing.T) {
+	domains := []*zms.DomainData{
+		{Name: "coretech.api.prod"},
+		{Name: "sports"},
+		{Name: "coretech.api"},
+		{Name: "coretech"},
+		{Name: "athenz.ci"},
+	}
+	sortDomainsByHierarchy(domains)
+	expected := []string{"coretech", "sports", "athenz.ci", "coretech.api", "coretech.api.prod"}
+	for i, name := range expected {
+		if string(domains[i].Name) != name {
+			t.Errorf("position %d: expected %s, got %s", i, name, domains[i].Name)
+		}
+	}
+}
+
+func TestRestoreCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zms-restore-test")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	checkpoint := filepath.Join(dir, restoreCheckpointFile)
+
+	completed, err := readRestoreCheckpoint(checkpoint)
+	if err != nil || len(completed) != 0 {
+		t.Fatalf("expected empty checkpoint, got %v, %v", completed, err)
+	}
+	_ = appendRestoreCheckpoint(checkpoint, "coretech")
+	_ = appendRestoreCheckpoint(checkpoint, "coretech.api")
+	completed, err = readRestoreCheckpoint(checkpoint)
+	if err != nil {
+		t.Fatalf("unable to read checkpoint: %v", err)
+	}
+	if len(completed) != 2 || !completed["coretech"] || !completed["coretech.api"] {
+		t.Errorf("unexpected checkpoint contents: %v", completed)
+	}
+}
+
+func TestLoadDomainFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zms-restore-test")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	desired, err := parseDomainDataOld([]byte(desiredDomainYaml))
+	if err != nil {
+		t.Fatalf("unable to parse domain: %v", err)
+	}
+	data, err := yaml.Marshal(desired)
+	if err != nil {
+		t.Fatalf("unable to marshal domain: %v", err)
+	}
+	modelFile := filepath.Join(dir, "coretech.yaml")
+	_ = ioutil.WriteFile(modelFile, data, 0644)
+	legacyFile := filepath.Join(dir, "coretech")
+	_ = ioutil.WriteFile(legacyFile, []byte(desiredDomainYaml), 0644)
+	exportFile := filepath.Join(dir, "coretech-export.yaml")
+	_ = ioutil.WriteFile(exportFile, []byte(desiredDomainYaml), 0644)
+	data, err = json.Marshal(desired)
+	if err != nil {
+		t.Fatalf("unable to marshal domain: %v", err)
+	}
+	jsonFile := filepath.Join(dir, "coretech.json")
+	_ = ioutil.WriteFile(jsonFile, data, 0644)
+	jsonLegacyFile := filepath.Join(dir, "coretech-json")
+	_ = ioutil.WriteFile(jsonLegacyFile, data, 0644)
+
+	for _, filename := range []string{modelFile, legacyFile, exportFile, jsonFile, jsonLegacyFile} {
+		domainData, err := loadDomainFile(filename)
+		if err != nil {
+			t.Fatalf("unable to load %s: %v", filename, err)
+		}
+		if diff := diffDomainData(desired, domainData); len(diff.Objects) != 0 {
+			t.Errorf("domain loaded from %s does not match the original", filename)
+		}
+	}
+}
+
+func TestRestoreDomainMetaRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zms-restore-test")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	days := int32(30)
+	mins := int32(60)
+	ypmID := int32(1001)
+	enabled := true
+	backup := &zms.DomainData{
+		Name:                "coretech",
+		Account:             "123456789012",
+		AzureSubscription:   "azure-sub",
+		YpmId:               &ypmID,
+		UserAuthorityFilter: "employee",
+		MemberExpiryDays:    &days,
+		ServiceExpiryDays:   &days,
+		TokenExpiryMins:     &mins,
+		Roles: []*zms.Role{
+			{
+				Name:             "coretech:role.readers",
+				ReviewEnabled:    &enabled,
+				AuditEnabled:     &enabled,
+				SelfServe:        &enabled,
+				MemberReviewDays: &days,
+				NotifyRoles:      "admin",
+				RoleMembers:      []*zms.RoleMember{{MemberName: "user.jane"}},
+			},
+		},
+		Groups: []*zms.Group{
+			{Name: "coretech:group.devs", SelfServe: &enabled, MemberExpiryDays: &days},
+		},
+	}
+	data, err := yaml.Marshal(backup)
+	if err != nil {
+		t.Fatalf("unable to marshal domain: %v", err)
+	}
+	filename := filepath.Join(dir, "coretech.yaml")
+	_ = ioutil.WriteFile(filename, data, 0644)
+	restored, err := loadDomainFile(filename)
+	if err != nil {
+		t.Fatalf("unable to load %s: %v", filename, err)
+	}
+	if diff := diffDomainData(backup, restored); len(diff.Objects) != 0 {
+		t.Fatalf("restored domain does not match the backup: %+v", diff.Objects[0])
+	}
+
+	// restoring into a newly created domain must update all the meta
+	// attributes including the system ones and those of new roles/groups
+	created := &zms.DomainData{Name: "coretech"}
+	diff := diffDomainData(created, restored)
+	if len(diff.Objects) != 3 || diff.Objects[0].ObjectType != diffObjectDomain {
+		t.Fatalf("unexpected restore changes: %d", len(diff.Objects))
+	}
+	for _, attribute := range []string{"account", "azure-subscription", "product-id", "user-authority-filter",
+		"member-expiry-days", "service-expiry-days", "token-expiry-mins"} {
+		if !diff.Objects[0].hasChange(attribute) {
+			t.Errorf("domain %s is not restored", attribute)
+		}
+	}
+	role := diffRole(&zms.Role{Name: restored.Roles[0].Name}, restored.Roles[0])
+	for _, attribute := range []string{"review-enabled", "audit-enabled", "self-serve", "member-review-days", "notify-roles"} {
+		if !role.hasChange(attribute) {
+			t.Errorf("role %s is not restored", attribute)
+		}
+	}
+	group := diffGroup(&zms.Group{Name: restored.Groups[0].Name}, restored.Groups[0])
+	if !group.hasChange("self-serve") || !group.hasChange("member-expiry-days") {
+		t.Error("group meta is not restored")
+	}
+}
+
+func TestBackupNonActivePolicies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zms-restore-test")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	active := true
+	inactive := false
+	allow := zms.ALLOW
+	backup := &zms.DomainData{
+		Name:    "coretech",
+		Enabled: &inactive,
+		Policies: &zms.SignedPolicies{
+			Contents: &zms.DomainPolicies{
+				Domain: "coretech",
+				Policies: []*zms.Policy{
+					{
+						Name:    "coretech:policy.readers",
+						Version: "0",
+						Active:  &active,
+						Assertions: []*zms.Assertion{
+							{Role: "coretech:role.readers", Action: "read", Resource: "coretech:articles", Effect: &allow},
+						},
+					},
+					{
+						Name:    "coretech:policy.readers",
+						Version: "dev",
+						Active:  &inactive,
+						Assertions: []*zms.Assertion{
+							{Role: "coretech:role.readers", Action: "update", Resource: "coretech:articles", Effect: &allow},
+						},
+					},
+				},
+			},
+		},
+	}
+	data, err := yaml.Marshal(backup)
+	if err != nil {
+		t.Fatalf("unable to marshal domain: %v", err)
+	}
+	filename := filepath.Join(dir, "coretech.yaml")
+	_ = ioutil.WriteFile(filename, data, 0644)
+	restored, err := loadDomainFile(filename)
+	if err != nil {
+		t.Fatalf("unable to load %s: %v", filename, err)
+	}
+	if restored.Enabled == nil || *restored.Enabled {
+		t.Error("domain enabled flag is not restored")
+	}
+	policies := domainPolicies(restored)
+	if len(policies) != 1 || policies[0].Version != "0" {
+		t.Fatalf("expected only the active policy version, got %d", len(policies))
+	}
+	versions := nonActivePolicies(restored)
+	if len(versions) != 1 || versions[0].Version != "dev" || len(versions[0].Assertions) != 1 {
+		t.Fatalf("expected the non-active dev policy version, got %d", len(versions))
+	}
+
+	// the non-active versions are not compared with the live active policies
+	current := &zms.DomainData{
+		Name: "coretech",
+		Policies: &zms.SignedPolicies{
+			Contents: &zms.DomainPolicies{Domain: "coretech", Policies: policies},
+		},
+	}
+	if diff := diffDomainData(current, restored); len(diff.Objects) != 0 {
+		t.Errorf("unexpected restore changes: %d", len(diff.Objects))
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	reviewActionKeep   = "keep"
+	reviewActionExtend = "extend"
+	reviewActionRemove = "remove"
+
+	reviewExtendAll = "extend-all"
+)
+
+// ReviewMember is a single member entry in the review file along
+// with the decision made by the reviewer for the member.
+type ReviewMember struct {
+	Name       string `yaml:"name"`
+	Action     string `yaml:"action"`
+	Expiration string `yaml:"expiration,omitempty"`
+	Review     string `yaml:"review,omitempty"`
+}
+
+// ReviewFile is the editable list of members for a role or group review.
+type ReviewFile struct {
+	Members []*ReviewMember `yaml:"members"`
+}
+
+func timestampString(timestamp *rdl.Timestamp) string {
+	if timestamp == nil {
+		return ""
+	}
+	return timestamp.String()
+}
+
+func parseReviewTimestamp(member, label, value string) (*rdl.Timestamp, error) {
+	if value == "" {
+		return nil, nil
+	}
+	timestamp, err := getTimestamp(value)
+	if err != nil {
+		return nil, fmt.Errorf("member %s: invalid %s date %s: %v", member, label, value, err)
+	}
+	return &timestamp, nil
+}
+
+func dumpReviewFile(buf *bytes.Buffer, objectType, name string, members []*ReviewMember) {
+	buf.WriteString("# review for " + objectType + " " + name + "\n")
+	buf.WriteString("# set the action for each member to one of:\n")
+	buf.WriteString("#   keep   - no changes for the member\n")
+	buf.WriteString("#   extend - set the member expiration (and review for roles) to the given dates\n")
+	buf.WriteString("#   remove - delete the member from the " + objectType + "\n")
+	buf.WriteString("# dates are specified in the YYYY-MM-DDTHH:MM:SS.000Z format\n")
+	if len(members) == 0 {
+		buf.WriteString("members: []\n")
+		return
+	}
+	buf.WriteString("members:\n")
+	for _, member := range members {
+		dumpStringValue(buf, indentLevel1Dash, "name", member.Name)
+		dumpStringValue(buf, indentLevel1DashLvl, "action", member.Action)
+		dumpStringValue(buf, indentLevel1DashLvl, "expiration", member.Expiration)
+		dumpStringValue(buf, indentLevel1DashLvl, "review", member.Review)
+	}
+}
+
+// editReviewFile writes the review file into a temporary file, opens
+// it with the user's editor and returns the updated list of members.
+func editReviewFile(objectType, name string, members []*ReviewMember) ([]*ReviewMember, error) {
+	file, err := ioutil.TempFile("", "zms-cli-review-*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	var buf bytes.Buffer
+	dumpReviewFile(&buf, objectType, name, members)
+	_, err = file.Write(buf.Bytes())
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command(editor, file.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("unable to edit review file: %v", err)
+	}
+	return loadReviewFile(file.Name())
+}
+
+func loadReviewFile(filename string) ([]*ReviewMember, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var reviewFile ReviewFile
+	err = yaml.Unmarshal(data, &reviewFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range reviewFile.Members {
+		switch member.Action {
+		case reviewActionKeep, reviewActionExtend, reviewActionRemove:
+		case "":
+			member.Action = reviewActionKeep
+		default:
+			return nil, fmt.Errorf("member %s: unknown review action %s", member.Name, member.Action)
+		}
+	}
+	return reviewFile.Members, nil
+}
+
+// extendReviewMembers marks all members, except the given list of excluded
+// members, to be extended by the given number of days from now. Both the
+// expiration and the review dates that are set are extended so the member
+// is no longer overdue for either. Members without any dates never expire
+// so they're left unchanged unless all members are to be extended.
+func extendReviewMembers(members []*ReviewMember, days int, excluded []string, all bool) {
+	extendTimestamp := rdl.Timestamp{
+		Time: time.Now().UTC().Add(time.Duration(days) * 24 * time.Hour),
+	}
+	extendDate := extendTimestamp.String()
+	for _, member := range members {
+		if indexOfString(excluded, member.Name) != -1 {
+			continue
+		}
+		if member.Expiration == "" && member.Review == "" && !all {
+			continue
+		}
+		member.Action = reviewActionExtend
+		if member.Review != "" {
+			member.Review = extendDate
+		}
+		if member.Expiration != "" || member.Review == "" {
+			member.Expiration = extendDate
+		}
+	}
+}
+
+// validateReviewMembers verifies that all the given member names
+// are current members of the role or group being reviewed
+func validateReviewMembers(objectType, name string, members []*ReviewMember, names []string) error {
+	for _, memberName := range names {
+		found := false
+		for _, member := range members {
+			if member.Name == memberName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s is not a member of %s %s", memberName, objectType, name)
+		}
+	}
+	return nil
+}
+
+func reviewMemberNames(members []*ReviewMember) []string {
+	names := make([]string, 0, len(members))
+	for _, member := range members {
+		names = append(names, member.Name)
+	}
+	return names
+}
+
+// getReviewMembers returns the updated list of review members either
+// from the given review arguments or interactively from the editor
+func (cli Zms) getReviewMembers(objectType, name string, members []*ReviewMember, args []string) ([]*ReviewMember, error) {
+	var reviewMembers []*ReviewMember
+	var err error
+	switch {
+	case len(args) == 0:
+		reviewMembers, err = editReviewFile(objectType, name, members)
+	case (args[0] == reviewActionExtend || args[0] == reviewExtendAll) && len(args) >= 2:
+		days, err := strconv.Atoi(args[1])
+		if err != nil || days <= 0 {
+			return nil, fmt.Errorf("invalid number of days: %s", args[1])
+		}
+		excluded := cli.validatedUsers(args[2:], false)
+		err = validateReviewMembers(objectType, name, members, excluded)
+		if err != nil {
+			return nil, err
+		}
+		extendReviewMembers(members, days, excluded, args[0] == reviewExtendAll)
+		return members, nil
+	case len(args) == 1:
+		reviewMembers, err = loadReviewFile(args[0])
+	default:
+		return nil, fmt.Errorf("bad review syntax. should be '[review-file.yaml | extend|extend-all days [member ...]]'")
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = validateReviewMembers(objectType, name, members, reviewMemberNames(reviewMembers))
+	if err != nil {
+		return nil, err
+	}
+	return reviewMembers, nil
+}
+
+func reviewSummary(objectType, name string, members []*ReviewMember) string {
+	counts := make(map[string]int)
+	for _, member := range members {
+		counts[member.Action]++
+	}
+	return "[reviewed " + objectType + " " + name + ": " + strconv.Itoa(counts[reviewActionExtend]) + " extended, " +
+		strconv.Itoa(counts[reviewActionRemove]) + " removed, " + strconv.Itoa(counts[reviewActionKeep]) + " kept]"
+}
+
+func (cli Zms) ReviewRole(dn string, rn string, args []string) (*string, error) {
+	role, err := cli.Zms.GetRole(zms.DomainName(dn), zms.EntityName(rn), nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]*ReviewMember, 0)
+	for _, roleMember := range role.RoleMembers {
+		if isPendingMember(roleMember.Approved) {
+			continue
+		}
+		members = append(members, &ReviewMember{
+			Name:       string(roleMember.MemberName),
+			Action:     reviewActionKeep,
+			Expiration: timestampString(roleMember.Expiration),
+			Review:     timestampString(roleMember.ReviewReminder),
+		})
+	}
+	members, err = cli.getReviewMembers("role", rn, members, args)
+	if err != nil {
+		return nil, err
+	}
+	reviewRole := zms.Role{
+		Name:        zms.ResourceName(dn + ":role." + rn),
+		RoleMembers: make([]*zms.RoleMember, 0),
+	}
+	for _, member := range members {
+		active := member.Action != reviewActionRemove
+		roleMember := zms.RoleMember{
+			MemberName: zms.MemberName(member.Name),
+			Active:     &active,
+		}
+		switch member.Action {
+		case reviewActionKeep:
+			continue
+		case reviewActionExtend:
+			roleMember.Expiration, err = parseReviewTimestamp(member.Name, "expiration", member.Expiration)
+			if err != nil {
+				return nil, err
+			}
+			roleMember.ReviewReminder, err = parseReviewTimestamp(member.Name, "review", member.Review)
+			if err != nil {
+				return nil, err
+			}
+		}
+		reviewRole.RoleMembers = append(reviewRole.RoleMembers, &roleMember)
+	}
+	if len(reviewRole.RoleMembers) != 0 {
+		err = cli.Zms.PutRoleReview(zms.DomainName(dn), zms.EntityName(rn), cli.AuditRef, &reviewRole)
+		if err != nil {
+			return nil, err
+		}
+	}
+	message := SuccessMessage{
+		Status:  200,
+		Message: reviewSummary("role", rn, members),
+	}
+	return cli.dumpByFormat(message, cli.buildYAMLOutput)
+}
+
+func (cli Zms) ReviewGroup(dn string, gn string, args []string) (*string, error) {
+	group, err := cli.Zms.GetGroup(zms.DomainName(dn), zms.EntityName(gn), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]*ReviewMember, 0)
+	for _, groupMember := range group.GroupMembers {
+		if isPendingMember(groupMember.Approved) {
+			continue
+		}
+		members = append(members, &ReviewMember{
+			Name:       string(groupMember.MemberName),
+			Action:     reviewActionKeep,
+			Expiration: timestampString(groupMember.Expiration),
+		})
+	}
+	members, err = cli.getReviewMembers("group", gn, members, args)
+	if err != nil {
+		return nil, err
+	}
+	reviewGroup := zms.Group{
+		Name:         zms.ResourceName(dn + ":group." + gn),
+		GroupMembers: make([]*zms.GroupMember, 0),
+	}
+	for _, member := range members {
+		active := member.Action != reviewActionRemove
+		groupMember := zms.GroupMember{
+			MemberName: zms.GroupMemberName(member.Name),
+			Active:     &active,
+		}
+		switch member.Action {
+		case reviewActionKeep:
+			continue
+		case reviewActionExtend:
+			groupMember.Expiration, err = parseReviewTimestamp(member.Name, "expiration", member.Expiration)
+			if err != nil {
+				return nil, err
+			}
+		}
+		reviewGroup.GroupMembers = append(reviewGroup.GroupMembers, &groupMember)
+	}
+	if len(reviewGroup.GroupMembers) != 0 {
+		err = cli.Zms.PutGroupReview(zms.DomainName(dn), zms.EntityName(gn), cli.AuditRef, &reviewGroup)
+		if err != nil {
+			return nil, err
+		}
+	}
+	message := SuccessMessage{
+		Status:  200,
+		Message: reviewSummary("group", gn, members),
+	}
+	return cli.dumpByFormat(message, cli.buildYAMLOutput)
+}
@@ -0,0 +1,90 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestReviewFileRoundTrip(t *testing.T) {
+	members := []*ReviewMember{
+		{Name: "user.jane", Action: reviewActionKeep, Expiration: "2030-01-01T00:00:00.000Z"},
+		{Name: "user.joe", Action: reviewActionRemove, Review: "2030-02-01T00:00:00.000Z"},
+	}
+	var buf bytes.Buffer
+	dumpReviewFile(&buf, "role", "readers", members)
+
+	file, err := ioutil.TempFile("", "review-test-*.yaml")
+	if err != nil {
+		t.Fatalf("unable to create temp file: %v", err)
+	}
+	defer os.Remove(file.Name())
+	_, _ = file.Write(buf.Bytes())
+	file.Close()
+
+	loaded, err := loadReviewFile(file.Name())
+	if err != nil {
+		t.Fatalf("unable to load review file: %v", err)
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("expected 2 members, got %d", len(loaded))
+	}
+	for i := range members {
+		if *loaded[i] != *members[i] {
+			t.Errorf("member %d didn't match after round trip: %v", i, *loaded[i])
+		}
+	}
+}
+
+func TestExtendReviewMembers(t *testing.T) {
+	members := []*ReviewMember{
+		{Name: "user.jane", Action: reviewActionKeep, Expiration: "2020-01-01T00:00:00.000Z"},
+		{Name: "user.joe", Action: reviewActionKeep, Review: "2020-01-01T00:00:00.000Z"},
+		{Name: "user.jack", Action: reviewActionKeep, Expiration: "2020-01-01T00:00:00.000Z"},
+		{Name: "user.john", Action: reviewActionKeep},
+		{Name: "user.jill", Action: reviewActionKeep, Expiration: "2020-01-01T00:00:00.000Z", Review: "2020-01-01T00:00:00.000Z"},
+	}
+	extendReviewMembers(members, 30, []string{"user.jack"}, false)
+	if members[0].Action != reviewActionExtend || members[0].Expiration == "2020-01-01T00:00:00.000Z" {
+		t.Error("user.jane expiration was not extended")
+	}
+	if members[1].Action != reviewActionExtend || members[1].Review == "2020-01-01T00:00:00.000Z" || members[1].Expiration != "" {
+		t.Error("user.joe review date was not extended")
+	}
+	if members[2].Action != reviewActionKeep || members[2].Expiration != "2020-01-01T00:00:00.000Z" {
+		t.Error("excluded user.jack was modified")
+	}
+	if members[3].Action != reviewActionKeep || members[3].Expiration != "" {
+		t.Error("user.john without an expiration was modified")
+	}
+	if members[4].Action != reviewActionExtend || members[4].Expiration == "2020-01-01T00:00:00.000Z" ||
+		members[4].Review != members[4].Expiration {
+		t.Error("user.jill expiration and review dates were not both extended")
+	}
+	if _, err := getTimestamp(members[0].Expiration); err != nil {
+		t.Errorf("extended expiration is not a valid timestamp: %v", err)
+	}
+
+	extendReviewMembers(members, 30, []string{"user.jack"}, true)
+	if members[3].Action != reviewActionExtend || members[3].Expiration == "" {
+		t.Error("user.john expiration was not set with all members extended")
+	}
+}
+
+func TestValidateReviewMembers(t *testing.T) {
+	members := []*ReviewMember{
+		{Name: "user.jane", Action: reviewActionKeep},
+		{Name: "user.joe", Action: reviewActionKeep},
+	}
+	if err := validateReviewMembers("role", "readers", members, []string{"user.joe"}); err != nil {
+		t.Errorf("unexpected error for role member: %v", err)
+	}
+	err := validateReviewMembers("role", "readers", members, []string{"user.jane", "user.jack"})
+	if err == nil || err.Error() != "user.jack is not a member of role readers" {
+		t.Errorf("unexpected error for unknown member: %v", err)
+	}
+}
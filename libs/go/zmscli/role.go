@@ -469,6 +469,10 @@ func (cli Zms) SetRoleSelfServe(dn string, rn string, selfServe bool) (*string,
 }
 
 func (cli Zms) SetRoleUserAuthorityFilter(dn string, rn, filter string) (*string, error) {
+	err := cli.validateUserAuthorityAttributes(userAuthorityTypeBool, filter)
+	if err != nil {
+		return nil, err
+	}
 	role, err := cli.Zms.GetRole(zms.DomainName(dn), zms.EntityName(rn), nil, nil, nil)
 	if err != nil {
 		return nil, err
@@ -490,6 +494,10 @@ func (cli Zms) SetRoleUserAuthorityFilter(dn string, rn, filter string) (*string
 }
 
 func (cli Zms) SetRoleUserAuthorityExpiration(dn string, rn, filter string) (*string, error) {
+	err := cli.validateUserAuthorityAttributes(userAuthorityTypeDate, filter)
+	if err != nil {
+		return nil, err
+	}
 	role, err := cli.Zms.GetRole(zms.DomainName(dn), zms.EntityName(rn), nil, nil, nil)
 	if err != nil {
 		return nil, err
@@ -589,7 +597,7 @@ func (cli Zms) DeleteRoleTags(dn string, rn, tagKey string, tagValue string) (*s
 }
 
 func (cli Zms) ShowRoles(dn string, tagKey string, tagValue string) (*string, error) {
-	if cli.OutputFormat == JSONOutputFormat || cli.OutputFormat == YAMLOutputFormat {
+	if cli.OutputFormat != DefaultOutputFormat {
 		members := true
 		roles, err := cli.Zms.GetRoles(zms.DomainName(dn), &members, zms.CompoundName(tagKey), zms.CompoundName(tagValue))
 		if err != nil {
@@ -814,14 +822,22 @@ func (cli Zms) SetRoleNotifyRoles(dn string, rn string, notifyRoles string) (*st
 	return cli.dumpByFormat(message, cli.buildYAMLOutput)
 }
 
-func (cli Zms) PutTempMembershipDecision(dn string, rn string, mbr string, expiration rdl.Timestamp, approval bool) (*string, error) {
-	validatedUser := cli.validatedUser(mbr)
+// membershipDecision returns the membership for the decision request. The
+// server only confirms the member if approved is set, so it's always included
+// while the expiration is only given for temporary memberships.
+func membershipDecision(rn string, mbr string, expiration *rdl.Timestamp, approval bool) *zms.Membership {
 	var member zms.Membership
-	member.MemberName = zms.MemberName(validatedUser)
+	member.MemberName = zms.MemberName(mbr)
 	member.RoleName = zms.ResourceName(rn)
-	member.Expiration = &expiration
-	member.Active = &approval
-	err := cli.Zms.PutMembershipDecision(zms.DomainName(dn), zms.EntityName(rn), zms.MemberName(validatedUser), cli.AuditRef, &member)
+	member.Approved = &approval
+	member.Expiration = expiration
+	return &member
+}
+
+func (cli Zms) PutTempMembershipDecision(dn string, rn string, mbr string, expiration rdl.Timestamp, approval bool) (*string, error) {
+	validatedUser := cli.validatedUser(mbr)
+	member := membershipDecision(rn, validatedUser, &expiration, approval)
+	err := cli.Zms.PutMembershipDecision(zms.DomainName(dn), zms.EntityName(rn), zms.MemberName(validatedUser), cli.AuditRef, member)
 	if err != nil {
 		return nil, err
 	}
@@ -842,11 +858,8 @@ func (cli Zms) PutTempMembershipDecision(dn string, rn string, mbr string, expir
 
 func (cli Zms) PutMembershipDecision(dn string, rn string, mbr string, approval bool) (*string, error) {
 	validatedUser := cli.validatedUser(mbr)
-	var member zms.Membership
-	member.MemberName = zms.MemberName(validatedUser)
-	member.RoleName = zms.ResourceName(rn)
-	member.Approved = &approval
-	err := cli.Zms.PutMembershipDecision(zms.DomainName(dn), zms.EntityName(rn), zms.MemberName(validatedUser), cli.AuditRef, &member)
+	member := membershipDecision(rn, validatedUser, nil, approval)
+	err := cli.Zms.PutMembershipDecision(zms.DomainName(dn), zms.EntityName(rn), zms.MemberName(validatedUser), cli.AuditRef, member)
 	if err != nil {
 		return nil, err
 	}
@@ -864,3 +877,18 @@ func (cli Zms) PutMembershipDecision(dn string, rn string, mbr string, approval
 
 	return cli.dumpByFormat(message, cli.buildYAMLOutput)
 }
+
+func (cli Zms) DeletePendingMembership(dn string, rn string, mbr string) (*string, error) {
+	validatedUser := cli.validatedUser(mbr)
+	err := cli.Zms.DeletePendingMembership(zms.DomainName(dn), zms.EntityName(rn), zms.MemberName(validatedUser), cli.AuditRef)
+	if err != nil {
+		return nil, err
+	}
+	s := "[domain " + dn + " role " + rn + " pending member " + validatedUser + " successfully deleted]\n"
+	message := SuccessMessage{
+		Status:  200,
+		Message: s,
+	}
+
+	return cli.dumpByFormat(message, cli.buildYAMLOutput)
+}
@@ -21,6 +21,18 @@ func shortname(dn string, sn string) string {
 	return shortName
 }
 
+func (cli Zms) serviceNames(dn string) ([]string, error) {
+	services := make([]string, 0)
+	lst, err := cli.Zms.GetServiceIdentityList(zms.DomainName(dn), nil, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range lst.Names {
+		services = append(services, string(n))
+	}
+	return services, nil
+}
+
 func (cli Zms) ListServices(dn string) (*string, error) {
 	services := make([]string, 0)
 	lst, err := cli.Zms.GetServiceIdentityList(zms.DomainName(dn), nil, "")
@@ -0,0 +1,305 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const (
+	shellObjectDomain  = "domain"
+	shellObjectRole    = "role"
+	shellObjectGroup   = "group"
+	shellObjectPolicy  = "policy"
+	shellObjectService = "service"
+)
+
+// shellDomainCommands are the commands that accept a domain name
+// as their first argument in the interactive shell
+var shellDomainCommands = []string{
+	"use-domain", "show-domain", "delete-domain", "disable-domain", "enable-domain",
+	"overdue-review", "export-domain", "get-jws-domain", "get-stats", "stats",
+}
+
+// shellCompleter provides tab completion for command names and the
+// names of the objects in the current domain. The object names are
+// retrieved from the server only once and cached for the session.
+type shellCompleter struct {
+	cli      *Zms
+	commands []string
+	cache    map[string][]string
+}
+
+func newShellCompleter(cli *Zms) *shellCompleter {
+	commandSet := map[string]bool{"exit": true, "quit": true, "help": true}
+	for _, line := range strings.Split(cli.HelpListCommand(), "\n") {
+		if !strings.HasPrefix(line, "   ") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 0 && !strings.HasPrefix(fields[0], "-") {
+			commandSet[fields[0]] = true
+		}
+	}
+	commands := make([]string, 0, len(commandSet))
+	for command := range commandSet {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	return &shellCompleter{
+		cli:      cli,
+		commands: commands,
+		cache:    make(map[string][]string),
+	}
+}
+
+// shellObjectType returns the type of object the first argument
+// of the given command refers to
+func shellObjectType(cmd string) string {
+	switch {
+	case indexOfString(shellDomainCommands, cmd) != -1:
+		return shellObjectDomain
+	case strings.Contains(cmd, "group"):
+		return shellObjectGroup
+	case strings.Contains(cmd, "role"), strings.Contains(cmd, "member"):
+		return shellObjectRole
+	case strings.Contains(cmd, "policy"), strings.Contains(cmd, "assertion"):
+		return shellObjectPolicy
+	case strings.Contains(cmd, "service"), strings.Contains(cmd, "public-key"), strings.Contains(cmd, "host"):
+		return shellObjectService
+	}
+	return ""
+}
+
+func (c *shellCompleter) objectNames(cmd string) []string {
+	objectType := shellObjectType(cmd)
+	dn := c.cli.Domain
+	if objectType == "" || (objectType != shellObjectDomain && dn == "") {
+		return nil
+	}
+	key := objectType + ":" + dn
+	if objectType == shellObjectDomain {
+		key = shellObjectDomain + ":"
+	}
+	if names, ok := c.cache[key]; ok {
+		return names
+	}
+	var names []string
+	var err error
+	switch objectType {
+	case shellObjectDomain:
+		names, err = c.cli.domainNames()
+	case shellObjectRole:
+		names, err = c.cli.roleNames(dn)
+	case shellObjectGroup:
+		names, err = c.cli.groupNames(dn)
+	case shellObjectPolicy:
+		names, err = c.cli.policyNames(dn)
+	case shellObjectService:
+		names, err = c.cli.serviceNames(dn)
+	}
+	if err != nil {
+		return nil
+	}
+	c.cache[key] = names
+	return names
+}
+
+// invalidate removes all cached object names except for the list
+// of domains. It's called after any command that updates objects.
+func (c *shellCompleter) invalidate() {
+	for key := range c.cache {
+		if !strings.HasPrefix(key, shellObjectDomain+":") {
+			delete(c.cache, key)
+		}
+	}
+}
+
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, value := range values[1:] {
+		for !strings.HasPrefix(value, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// complete is the terminal auto complete callback. The command name
+// and the first argument of the command are completed to the longest
+// common prefix of all matching values.
+func (c *shellCompleter) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	prefix := line[:pos]
+	fields := strings.Fields(prefix)
+	word := ""
+	if len(fields) != 0 && !strings.HasSuffix(prefix, " ") {
+		word = fields[len(fields)-1]
+		fields = fields[:len(fields)-1]
+	}
+	var candidates []string
+	switch len(fields) {
+	case 0:
+		candidates = c.commands
+	case 1:
+		candidates = c.objectNames(fields[0])
+	}
+	matches := make([]string, 0)
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+	completion := commonPrefix(matches)
+	if len(matches) == 1 {
+		completion += " "
+	}
+	newPrefix := prefix[:len(prefix)-len(word)] + completion
+	return newPrefix + line[pos:], len(newPrefix), true
+}
+
+// splitShellLine splits the command line into its arguments. Arguments
+// with spaces or shell characters must be enclosed in single or double quotes.
+func splitShellLine(line string) ([]string, error) {
+	args := make([]string, 0)
+	var arg strings.Builder
+	var quote rune
+	inArg := false
+	for _, ch := range line {
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(ch)
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+			inArg = true
+		case ch == ' ' || ch == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(ch)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quoted argument")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// isUpdateCommand returns true if the command might modify objects
+// in which case the cached object names are no longer valid
+func isUpdateCommand(cmd string) bool {
+	for _, prefix := range []string{"list-", "show-", "get-", "check-", "lookup-", "help", "use-domain"} {
+		if strings.HasPrefix(cmd, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+func (cli *Zms) shellPrompt() string {
+	if cli.Domain == "" {
+		return "zms> "
+	}
+	return cli.Domain + "> "
+}
+
+// Shell runs the interactive shell reading commands from the standard
+// input until the exit command is entered or end of input is reached.
+// If the input is a terminal, the commands can be edited, retrieved
+// from the session history and completed with the tab key.
+func (cli *Zms) Shell() error {
+	cli.Interactive = true
+	completer := newShellCompleter(cli)
+
+	fd := int(os.Stdin.Fd())
+	var term *terminal.Terminal
+	if cli.Stdin == nil {
+		cli.Stdin = bufio.NewReader(os.Stdin)
+	}
+	if terminal.IsTerminal(fd) {
+		term = terminal.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, os.Stdout}, cli.shellPrompt())
+		term.AutoCompleteCallback = completer.complete
+	}
+
+	for {
+		var line string
+		if term != nil {
+			// the terminal is only in raw mode while reading the command
+			// so that the output of the commands is displayed as usual
+			state, err := terminal.MakeRaw(fd)
+			if err != nil {
+				return err
+			}
+			term.SetPrompt(cli.shellPrompt())
+			line, err = term.ReadLine()
+			_ = terminal.Restore(fd, state)
+			if err == io.EOF {
+				fmt.Println()
+				return nil
+			} else if err != nil {
+				return err
+			}
+		} else {
+			// the confirmation prompts of the commands read from the
+			// same reader so the remaining input is not consumed here
+			var err error
+			line, err = cli.Stdin.ReadString('\n')
+			if err == io.EOF && line == "" {
+				return nil
+			} else if err != nil && err != io.EOF {
+				return err
+			}
+			line = strings.TrimRight(line, "\r\n")
+		}
+		params, err := splitShellLine(line)
+		if err != nil {
+			fmt.Println("***", err)
+			continue
+		}
+		if len(params) == 0 {
+			continue
+		}
+		if params[0] == "exit" || params[0] == "quit" {
+			return nil
+		}
+		msg, err := cli.EvalCommand(params)
+		if isUpdateCommand(params[0]) {
+			completer.invalidate()
+		}
+		if failure, ok := err.(*CommandFailedError); ok {
+			fmt.Println(failure.Output)
+		}
+		if err != nil {
+			fmt.Println("***", err)
+		} else if msg != nil {
+			fmt.Println(*msg)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestSplitShellLine(t *testing.T) {
+	args, err := splitShellLine(`add-policy readers  grant read to readers on 'articles.*' "a b"`)
+	if err != nil {
+		t.Fatalf("unable to split line: %v", err)
+	}
+	expected := []string{"add-policy", "readers", "grant", "read", "to", "readers", "on", "articles.*", "a b"}
+	if len(args) != len(expected) {
+		t.Fatalf("expected %d arguments, got %d: %v", len(expected), len(args), args)
+	}
+	for i := range expected {
+		if args[i] != expected[i] {
+			t.Errorf("argument %d: expected %s, got %s", i, expected[i], args[i])
+		}
+	}
+	if _, err := splitShellLine("show-role 'readers"); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
+
+func TestShellComplete(t *testing.T) {
+	cli := Zms{Domain: "coretech", UserDomain: "user", HomeDomain: "home"}
+	completer := newShellCompleter(&cli)
+	completer.cache["role:coretech"] = []string{"admin", "readers", "reviewers"}
+
+	tests := []struct {
+		line     string
+		expected string
+		ok       bool
+	}{
+		{"use-dom", "use-domain ", true},
+		{"show-ro", "show-role", true},
+		{"add-member r", "add-member re", true},
+		{"add-member rea", "add-member readers ", true},
+		{"add-member x", "", false},
+		{"add-member readers user.jo", "", false},
+	}
+	for _, test := range tests {
+		line, pos, ok := completer.complete(test.line, len(test.line), '\t')
+		if ok != test.ok {
+			t.Errorf("%s: expected ok %v", test.line, test.ok)
+			continue
+		}
+		if ok && (line != test.expected || pos != len(test.expected)) {
+			t.Errorf("%s: expected '%s', got '%s' (%d)", test.line, test.expected, line, pos)
+		}
+	}
+	if _, _, ok := completer.complete("show-", 5, 'a'); ok {
+		t.Error("only the tab key should be processed")
+	}
+	completer.invalidate()
+	if _, ok := completer.cache["role:coretech"]; ok {
+		t.Error("role names were not removed from the cache")
+	}
+}
+
+func TestConfirmChangesSharedReader(t *testing.T) {
+	cli := Zms{Stdin: bufio.NewReader(strings.NewReader("yes\nno\nyes\n"))}
+	if !cli.confirmChanges("") {
+		t.Error("first confirmation was not accepted")
+	}
+	if cli.confirmChanges("") {
+		t.Error("second confirmation was accepted")
+	}
+	line, _ := cli.Stdin.ReadString('\n')
+	if line != "yes\n" {
+		t.Errorf("remaining input was consumed by the confirmations: %q", line)
+	}
+}
@@ -0,0 +1,331 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/athenz/libs/go/athenzconf"
+	"github.com/AthenZ/athenz/libs/go/athenzutils"
+	"github.com/AthenZ/athenz/libs/go/zmssvctoken"
+	"github.com/ardielle/ardielle-go/rdl"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// canonicalStruct holds the attributes of an object that are included
+// in the signature generated by ZMS. The attributes are written in
+// sorted order when the canonical string is generated.
+type canonicalStruct map[string]interface{}
+
+func (s canonicalStruct) appendString(name, value string) {
+	if value != "" {
+		s[name] = value
+	}
+}
+
+func (s canonicalStruct) appendInt32(name string, value *int32) {
+	if value != nil {
+		s[name] = *value
+	}
+}
+
+func (s canonicalStruct) appendBool(name string, value *bool) {
+	if value != nil {
+		s[name] = *value
+	}
+}
+
+func (s canonicalStruct) appendTimestamp(name string, value *rdl.Timestamp) {
+	if value != nil {
+		s[name] = value.String()
+	}
+}
+
+func (s canonicalStruct) appendList(name string, list []string) {
+	if list == nil {
+		return
+	}
+	items := make([]interface{}, 0, len(list))
+	for _, item := range list {
+		items = append(items, item)
+	}
+	s[name] = items
+}
+
+func canonicalPolicy(policy *zms.Policy) canonicalStruct {
+	s := make(canonicalStruct)
+	if len(policy.Assertions) != 0 {
+		assertions := make([]interface{}, 0, len(policy.Assertions))
+		for _, assertion := range policy.Assertions {
+			a := make(canonicalStruct)
+			a.appendString("action", assertion.Action)
+			if assertion.Effect != nil {
+				a.appendString("effect", assertion.Effect.String())
+			}
+			a.appendString("resource", assertion.Resource)
+			a.appendString("role", assertion.Role)
+			assertions = append(assertions, a)
+		}
+		s["assertions"] = assertions
+	}
+	s.appendTimestamp("modified", policy.Modified)
+	s.appendString("name", string(policy.Name))
+	return s
+}
+
+func canonicalDomainPolicies(domainPolicies *zms.DomainPolicies) canonicalStruct {
+	s := make(canonicalStruct)
+	s.appendString("domain", string(domainPolicies.Domain))
+	policies := make([]interface{}, 0, len(domainPolicies.Policies))
+	for _, policy := range domainPolicies.Policies {
+		policies = append(policies, canonicalPolicy(policy))
+	}
+	s["policies"] = policies
+	return s
+}
+
+func canonicalRole(role *zms.Role) canonicalStruct {
+	s := make(canonicalStruct)
+	s.appendBool("auditEnabled", role.AuditEnabled)
+	s.appendInt32("certExpiryMins", role.CertExpiryMins)
+	s.appendInt32("memberExpiryDays", role.MemberExpiryDays)
+	s.appendInt32("memberReviewDays", role.MemberReviewDays)
+	if role.Members != nil {
+		members := make([]string, 0, len(role.Members))
+		for _, member := range role.Members {
+			members = append(members, string(member))
+		}
+		s.appendList("members", members)
+	}
+	s.appendTimestamp("modified", role.Modified)
+	s.appendString("name", string(role.Name))
+	if role.RoleMembers != nil {
+		roleMembers := make([]interface{}, 0, len(role.RoleMembers))
+		for _, roleMember := range role.RoleMembers {
+			m := make(canonicalStruct)
+			m.appendTimestamp("expiration", roleMember.Expiration)
+			m.appendString("memberName", string(roleMember.MemberName))
+			m.appendInt32("systemDisabled", roleMember.SystemDisabled)
+			roleMembers = append(roleMembers, m)
+		}
+		s["roleMembers"] = roleMembers
+	}
+	s.appendBool("selfServe", role.SelfServe)
+	s.appendInt32("serviceExpiryDays", role.ServiceExpiryDays)
+	s.appendInt32("serviceReviewDays", role.ServiceReviewDays)
+	s.appendString("signAlgorithm", string(role.SignAlgorithm))
+	s.appendInt32("tokenExpiryMins", role.TokenExpiryMins)
+	s.appendString("trust", string(role.Trust))
+	return s
+}
+
+func canonicalGroup(group *zms.Group) canonicalStruct {
+	s := make(canonicalStruct)
+	s.appendBool("auditEnabled", group.AuditEnabled)
+	s.appendInt32("memberExpiryDays", group.MemberExpiryDays)
+	if group.GroupMembers != nil {
+		groupMembers := make([]interface{}, 0, len(group.GroupMembers))
+		for _, groupMember := range group.GroupMembers {
+			m := make(canonicalStruct)
+			m.appendTimestamp("expiration", groupMember.Expiration)
+			m.appendString("groupName", string(groupMember.GroupName))
+			m.appendString("memberName", string(groupMember.MemberName))
+			m.appendInt32("systemDisabled", groupMember.SystemDisabled)
+			groupMembers = append(groupMembers, m)
+		}
+		s["groupMembers"] = groupMembers
+	}
+	s.appendTimestamp("modified", group.Modified)
+	s.appendString("name", string(group.Name))
+	s.appendBool("reviewEnabled", group.ReviewEnabled)
+	s.appendBool("selfServe", group.SelfServe)
+	s.appendInt32("serviceExpiryDays", group.ServiceExpiryDays)
+	return s
+}
+
+func canonicalService(service *zms.ServiceIdentity) canonicalStruct {
+	s := make(canonicalStruct)
+	s.appendString("description", service.Description)
+	s.appendString("executable", service.Executable)
+	s.appendString("group", service.Group)
+	s.appendList("hosts", service.Hosts)
+	s.appendTimestamp("modified", service.Modified)
+	s.appendString("name", string(service.Name))
+	s.appendString("providerEndpoint", service.ProviderEndpoint)
+	publicKeys := make([]interface{}, 0, len(service.PublicKeys))
+	for _, publicKey := range service.PublicKeys {
+		k := make(canonicalStruct)
+		k.appendString("id", publicKey.Id)
+		k.appendString("key", publicKey.Key)
+		publicKeys = append(publicKeys, k)
+	}
+	s["publicKeys"] = publicKeys
+	s.appendString("user", service.User)
+	return s
+}
+
+func canonicalDomainData(domainData *zms.DomainData) canonicalStruct {
+	s := make(canonicalStruct)
+	s.appendString("account", domainData.Account)
+	s.appendBool("auditEnabled", domainData.AuditEnabled)
+	s.appendString("certDnsDomain", domainData.CertDnsDomain)
+	s.appendBool("enabled", domainData.Enabled)
+	if len(domainData.Groups) != 0 {
+		groups := make([]interface{}, 0, len(domainData.Groups))
+		for _, group := range domainData.Groups {
+			groups = append(groups, canonicalGroup(group))
+		}
+		s["groups"] = groups
+	}
+	s.appendInt32("memberExpiryDays", domainData.MemberExpiryDays)
+	s.appendTimestamp("modified", &domainData.Modified)
+	s.appendString("name", string(domainData.Name))
+	if domainData.Policies != nil {
+		p := make(canonicalStruct)
+		if domainData.Policies.Contents != nil {
+			p["contents"] = canonicalDomainPolicies(domainData.Policies.Contents)
+		}
+		p.appendString("keyId", domainData.Policies.KeyId)
+		p.appendString("signature", domainData.Policies.Signature)
+		s["policies"] = p
+	}
+	s.appendInt32("roleCertExpiryMins", domainData.RoleCertExpiryMins)
+	roles := make([]interface{}, 0, len(domainData.Roles))
+	for _, role := range domainData.Roles {
+		roles = append(roles, canonicalRole(role))
+	}
+	s["roles"] = roles
+	s.appendInt32("serviceCertExpiryMins", domainData.ServiceCertExpiryMins)
+	s.appendInt32("serviceExpiryDays", domainData.ServiceExpiryDays)
+	services := make([]interface{}, 0, len(domainData.Services))
+	for _, service := range domainData.Services {
+		services = append(services, canonicalService(service))
+	}
+	s["services"] = services
+	s.appendString("signAlgorithm", string(domainData.SignAlgorithm))
+	s.appendInt32("tokenExpiryMins", domainData.TokenExpiryMins)
+	s.appendInt32("ypmId", domainData.YpmId)
+	return s
+}
+
+// writeCanonicalString generates the same canonical string that ZMS
+// uses when signing objects: attributes are sorted by name, no white
+// space is included and string values are written without escaping.
+func writeCanonicalString(buf *bytes.Buffer, obj interface{}) {
+	switch value := obj.(type) {
+	case canonicalStruct:
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		buf.WriteString("{")
+		for idx, name := range names {
+			if idx != 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\"" + name + "\":")
+			writeCanonicalString(buf, value[name])
+		}
+		buf.WriteString("}")
+	case []interface{}:
+		buf.WriteString("[")
+		for idx, item := range value {
+			if idx != 0 {
+				buf.WriteString(",")
+			}
+			writeCanonicalString(buf, item)
+		}
+		buf.WriteString("]")
+	case string:
+		buf.WriteString("\"" + value + "\"")
+	case int32:
+		buf.WriteString(strconv.Itoa(int(value)))
+	case bool:
+		buf.WriteString(strconv.FormatBool(value))
+	default:
+		buf.WriteString(fmt.Sprintf("%v", value))
+	}
+}
+
+func domainDataCanonicalString(domainData *zms.DomainData) string {
+	var buf bytes.Buffer
+	writeCanonicalString(&buf, canonicalDomainData(domainData))
+	return buf.String()
+}
+
+// zmsPublicKey returns the pem encoded ZMS public key with the given
+// key id from the configured athenz.conf file
+func (cli Zms) zmsPublicKey(keyId string) ([]byte, error) {
+	conf, err := athenzconf.ReadConf(cli.AthenzConf)
+	if err != nil {
+		return nil, err
+	}
+	return conf.FetchZMSPublicKey(keyId)
+}
+
+// verifySignedDomain verifies the signature of the signed domain
+// using the ZMS public key identified by the domain's key id
+func (cli Zms) verifySignedDomain(signedDomain *zms.SignedDomain) error {
+	domainName := string(signedDomain.Domain.Name)
+	if signedDomain.Signature == "" {
+		return fmt.Errorf("domain %s is not signed", domainName)
+	}
+	publicKey, err := cli.zmsPublicKey(signedDomain.KeyId)
+	if err != nil {
+		return err
+	}
+	verifier, err := zmssvctoken.NewVerifier(publicKey)
+	if err != nil {
+		return err
+	}
+	err = verifier.Verify(domainDataCanonicalString(signedDomain.Domain), signedDomain.Signature)
+	if err != nil {
+		return fmt.Errorf("signature verification for domain %s with zms key id %s failed: %v", domainName, signedDomain.KeyId, err)
+	}
+	return nil
+}
+
+// decodeJWSDomain decodes the payload of the JWS domain object and, if
+// requested, verifies its signature using the ZMS public key identified
+// by the kid header. It returns the domain data along with the key id.
+func (cli Zms) decodeJWSDomain(jwsDomain *zms.JWSDomain, verify bool) (*zms.DomainData, string, error) {
+	jwsBytes, err := json.Marshal(jwsDomain)
+	if err != nil {
+		return nil, "", err
+	}
+	object, err := jose.ParseSigned(string(jwsBytes))
+	if err != nil {
+		return nil, "", err
+	}
+	keyId := object.Signatures[0].Header.KeyID
+	var payload []byte
+	if verify {
+		pemKey, err := cli.zmsPublicKey(keyId)
+		if err != nil {
+			return nil, "", err
+		}
+		publicKey, err := athenzutils.LoadPublicKey(pemKey)
+		if err != nil {
+			return nil, "", err
+		}
+		payload, err = object.Verify(publicKey)
+		if err != nil {
+			return nil, "", fmt.Errorf("signature verification with zms key id %s failed: %v", keyId, err)
+		}
+	} else {
+		payload = object.UnsafePayloadWithoutVerification()
+	}
+	var domainData zms.DomainData
+	err = json.Unmarshal(payload, &domainData)
+	if err != nil {
+		return nil, "", err
+	}
+	return &domainData, keyId, nil
+}
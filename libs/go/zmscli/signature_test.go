@@ -0,0 +1,169 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/AthenZ/athenz/libs/go/zmssvctoken"
+	"github.com/ardielle/ardielle-go/rdl"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func testDomainData() *zms.DomainData {
+	modified := rdl.Timestamp{Time: rdl.TimestampNow().Time.UTC()}
+	enabled := true
+	effect := zms.ALLOW
+	return &zms.DomainData{
+		Name:     "coretech",
+		Enabled:  &enabled,
+		Modified: modified,
+		Roles: []*zms.Role{
+			{
+				Name:        "coretech:role.admin",
+				Modified:    &modified,
+				RoleMembers: []*zms.RoleMember{{MemberName: "user.john"}},
+			},
+		},
+		Policies: &zms.SignedPolicies{
+			Contents: &zms.DomainPolicies{
+				Domain: "coretech",
+				Policies: []*zms.Policy{
+					{
+						Name: "coretech:policy.admin",
+						Assertions: []*zms.Assertion{
+							{Role: "coretech:role.admin", Resource: "coretech:*", Action: "*", Effect: &effect},
+						},
+					},
+				},
+			},
+			Signature: "policy-signature",
+			KeyId:     "0",
+		},
+		Services: []*zms.ServiceIdentity{},
+	}
+}
+
+// testAthenzConf generates a new ECDSA key pair and writes an athenz.conf
+// file with the public key registered as ZMS key id 0
+func testAthenzConf(t *testing.T) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("unable to marshal public key: %v", err)
+	}
+	publicKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER})
+	conf := map[string]interface{}{
+		"zmsUrl": "https://zms.athenz.io:4443/zms/v1",
+		"zmsPublicKeys": []map[string]string{
+			{"id": "0", "key": new(zmssvctoken.YBase64).EncodeToString(publicKeyPEM)},
+		},
+	}
+	data, _ := json.Marshal(conf)
+	file, err := ioutil.TempFile("", "athenz-*.conf")
+	if err != nil {
+		t.Fatalf("unable to create conf file: %v", err)
+	}
+	_, _ = file.Write(data)
+	file.Close()
+	return key, file.Name()
+}
+
+func TestDomainDataCanonicalString(t *testing.T) {
+	domainData := testDomainData()
+	modified := domainData.Modified.String()
+	expected := `{"enabled":true,"modified":"` + modified + `","name":"coretech",` +
+		`"policies":{"contents":{"domain":"coretech","policies":[{"assertions":[{"action":"*","effect":"ALLOW",` +
+		`"resource":"coretech:*","role":"coretech:role.admin"}],"name":"coretech:policy.admin"}]},"keyId":"0","signature":"policy-signature"},` +
+		`"roles":[{"modified":"` + modified + `","name":"coretech:role.admin","roleMembers":[{"memberName":"user.john"}]}],` +
+		`"services":[]}`
+	if canonical := domainDataCanonicalString(domainData); canonical != expected {
+		t.Errorf("unexpected canonical string:\n%s\nexpected:\n%s", canonical, expected)
+	}
+}
+
+func TestVerifySignedDomain(t *testing.T) {
+	key, confFile := testAthenzConf(t)
+	defer os.Remove(confFile)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unable to marshal private key: %v", err)
+	}
+	signer, err := zmssvctoken.NewSigner(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	if err != nil {
+		t.Fatalf("unable to create signer: %v", err)
+	}
+	domainData := testDomainData()
+	signature, err := signer.Sign(domainDataCanonicalString(domainData))
+	if err != nil {
+		t.Fatalf("unable to sign domain: %v", err)
+	}
+
+	cli := Zms{AthenzConf: confFile}
+	signedDomain := &zms.SignedDomain{Domain: domainData, Signature: signature, KeyId: "0"}
+	if err := cli.verifySignedDomain(signedDomain); err != nil {
+		t.Errorf("unable to verify signed domain: %v", err)
+	}
+	domainData.Roles[0].RoleMembers[0].MemberName = "user.jane"
+	if err := cli.verifySignedDomain(signedDomain); err == nil {
+		t.Error("modified domain was verified successfully")
+	}
+	signedDomain.KeyId = "1"
+	if err := cli.verifySignedDomain(signedDomain); err == nil {
+		t.Error("domain was verified with an unknown key id")
+	}
+}
+
+func TestDecodeJWSDomain(t *testing.T) {
+	key, confFile := testAthenzConf(t)
+	defer os.Remove(confFile)
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, nil)
+	if err != nil {
+		t.Fatalf("unable to create signer: %v", err)
+	}
+	payload, _ := json.Marshal(testDomainData())
+	object, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatalf("unable to sign domain: %v", err)
+	}
+	// use the flattened serialization generated by jose
+	// and add the key id as an unprotected header
+	var jwsDomain zms.JWSDomain
+	err = json.Unmarshal([]byte(object.FullSerialize()), &jwsDomain)
+	if err != nil {
+		t.Fatalf("unable to parse serialized jws: %v", err)
+	}
+	jwsDomain.Header = map[string]string{"kid": "0"}
+
+	cli := Zms{AthenzConf: confFile}
+	domainData, keyId, err := cli.decodeJWSDomain(&jwsDomain, true)
+	if err != nil {
+		t.Fatalf("unable to decode jws domain: %v", err)
+	}
+	if domainData.Name != "coretech" || keyId != "0" {
+		t.Errorf("unexpected domain %s with key id %s", domainData.Name, keyId)
+	}
+
+	jwsDomain.Signature = jwsDomain.Signature[:len(jwsDomain.Signature)-4] + "AAAA"
+	if _, _, err := cli.decodeJWSDomain(&jwsDomain, true); err == nil {
+		t.Error("tampered jws domain was verified successfully")
+	}
+	if _, _, err := cli.decodeJWSDomain(&jwsDomain, false); err != nil {
+		t.Errorf("unable to decode jws domain without verification: %v", err)
+	}
+}
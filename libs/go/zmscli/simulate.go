@@ -0,0 +1,174 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+// AccessCheck is a single principal/action/resource tuple from the
+// access matrix file used to simulate domain changes along with the
+// optional expected access decision
+type AccessCheck struct {
+	Principal string `json:"principal"`
+	Action    string `json:"action"`
+	Resource  string `json:"resource"`
+	Expected  string `json:"expected,omitempty"`
+}
+
+// AccessSimulationResult is the decision for an access check against
+// the live domain and the proposed domain file
+type AccessSimulationResult struct {
+	AccessCheck
+	Current  string `json:"current"`
+	Proposed string `json:"proposed"`
+}
+
+// AccessSimulationReport lists the access checks whose decision
+// changes if the proposed domain file is applied
+type AccessSimulationReport struct {
+	Domain    string                    `json:"domain"`
+	Checks    int                       `json:"checks"`
+	Unchanged int                       `json:"unchanged"`
+	Changes   []*AccessSimulationResult `json:"changes"`
+}
+
+// parseAccessMatrix parses the access matrix file where each line includes
+// the principal, action, resource and optionally the expected decision (allow
+// or deny) separated by spaces or commas. Empty lines and lines starting
+// with # are ignored. Resources without a domain prefix are assumed to be
+// in the given domain.
+func (cli Zms) parseAccessMatrix(filename string, dn string) ([]*AccessCheck, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	checks := make([]*AccessCheck, 0)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(fields) != 3 && len(fields) != 4 {
+			return nil, fmt.Errorf("invalid access check on line %d: expected principal, action, resource and optional decision", lineNumber)
+		}
+		check := &AccessCheck{
+			Principal: cli.validatedUser(fields[0]),
+			Action:    fields[1],
+			Resource:  fullResourceName(dn, fields[2]),
+		}
+		if len(fields) == 4 {
+			check.Expected = strings.ToLower(fields[3])
+			if check.Expected != "allow" && check.Expected != "deny" {
+				return nil, fmt.Errorf("invalid access check on line %d: expected decision must be allow or deny", lineNumber)
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks, scanner.Err()
+}
+
+// simulateAccess evaluates each access check against both evaluators
+// and returns the report with the checks whose access decision flips
+func simulateAccess(dn string, checks []*AccessCheck, current, proposed *accessEvaluator) (*AccessSimulationReport, error) {
+	report := &AccessSimulationReport{
+		Domain:  dn,
+		Checks:  len(checks),
+		Changes: make([]*AccessSimulationResult, 0),
+	}
+	for _, check := range checks {
+		currentExplanation, err := current.explain(check.Principal, check.Action, check.Resource)
+		if err != nil {
+			return nil, err
+		}
+		proposedExplanation, err := proposed.explain(check.Principal, check.Action, check.Resource)
+		if err != nil {
+			return nil, err
+		}
+		if currentExplanation.Granted == proposedExplanation.Granted {
+			report.Unchanged++
+			continue
+		}
+		report.Changes = append(report.Changes, &AccessSimulationResult{
+			AccessCheck: *check,
+			Current:     currentExplanation.Decision,
+			Proposed:    proposedExplanation.Decision,
+		})
+	}
+	return report, nil
+}
+
+func accessDecisionString(decision string) string {
+	if decision == accessDecisionAllow {
+		return "allow"
+	}
+	return "deny"
+}
+
+func (cli Zms) dumpAccessSimulation(buf *bytes.Buffer, report *AccessSimulationReport) {
+	dumpStringValue(buf, "", "domain", report.Domain)
+	if len(report.Changes) != 0 {
+		buf.WriteString("changes:\n")
+		for _, result := range report.Changes {
+			dumpStringValue(buf, indentLevel1Dash, "principal", result.Principal)
+			dumpStringValue(buf, indentLevel1DashLvl, "action", result.Action)
+			dumpStringValue(buf, indentLevel1DashLvl, "resource", result.Resource)
+			dumpStringValue(buf, indentLevel1DashLvl, "decision", accessDecisionString(result.Current)+" -> "+accessDecisionString(result.Proposed))
+		}
+	}
+	buf.WriteString("[" + strconv.Itoa(len(report.Changes)) + " of " + strconv.Itoa(report.Checks) +
+		" access checks change, " + strconv.Itoa(report.Unchanged) + " unchanged]\n")
+}
+
+// SimulateAccess evaluates the access checks from the matrix file against
+// the live domain and the domain contents in the given file and reports
+// the access decisions that would change if the file was applied
+func (cli Zms) SimulateAccess(dn string, domainFile string, matrixFile string) (*string, error) {
+	desired, err := loadDomainFile(domainFile)
+	if err != nil {
+		return nil, err
+	}
+	if dn != "" && dn != string(desired.Name) {
+		return nil, fmt.Errorf("Domain name mismatch. Expected " + dn + ", encountered " + string(desired.Name))
+	}
+	dn = string(desired.Name)
+	checks, err := cli.parseAccessMatrix(matrixFile, dn)
+	if err != nil {
+		return nil, err
+	}
+	current := newAccessEvaluator(cli.liveDomainData)
+	proposed := newAccessEvaluator(func(name string) (*zms.DomainData, error) {
+		if name == dn {
+			return desired, nil
+		}
+		return current.domainData(name)
+	})
+	report, err := simulateAccess(dn, checks, current, proposed)
+	if err != nil {
+		return nil, err
+	}
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		cli.dumpAccessSimulation(&buf, report)
+		s := buf.String()
+		return &s, nil
+	}
+
+	return cli.dumpByFormat(report, oldYamlConverter)
+}
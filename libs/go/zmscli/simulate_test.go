@@ -0,0 +1,103 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+func testMatrixFile(t *testing.T, contents string) string {
+	file, err := ioutil.TempFile("", "access-matrix-*.txt")
+	if err != nil {
+		t.Fatalf("unable to create matrix file: %v", err)
+	}
+	_, _ = file.WriteString(contents)
+	file.Close()
+	return file.Name()
+}
+
+func TestParseAccessMatrix(t *testing.T) {
+	filename := testMatrixFile(t, "# principal action resource\n\njohn read articles.sports\nsports.api, read, coretech:articles.public\n")
+	defer os.Remove(filename)
+
+	cli := Zms{UserDomain: "user"}
+	checks, err := cli.parseAccessMatrix(filename, "coretech")
+	if err != nil {
+		t.Fatalf("unable to parse matrix: %v", err)
+	}
+	if len(checks) != 2 {
+		t.Fatalf("expected 2 checks, got %d", len(checks))
+	}
+	if *checks[0] != (AccessCheck{Principal: "user.john", Action: "read", Resource: "coretech:articles.sports"}) {
+		t.Errorf("unexpected check: %v", *checks[0])
+	}
+	if *checks[1] != (AccessCheck{Principal: "sports.api", Action: "read", Resource: "coretech:articles.public"}) {
+		t.Errorf("unexpected check: %v", *checks[1])
+	}
+
+	expectedFile := testMatrixFile(t, "user.john read articles.sports ALLOW\n")
+	defer os.Remove(expectedFile)
+	checks, err = cli.parseAccessMatrix(expectedFile, "coretech")
+	if err != nil || len(checks) != 1 || checks[0].Expected != "allow" {
+		t.Errorf("unexpected checks with expected decision: %v", err)
+	}
+
+	invalidFile := testMatrixFile(t, "user.john read\n")
+	defer os.Remove(invalidFile)
+	if _, err := cli.parseAccessMatrix(invalidFile, "coretech"); err == nil {
+		t.Error("invalid access check was accepted")
+	}
+
+	invalidDecisionFile := testMatrixFile(t, "user.john read articles.sports maybe\n")
+	defer os.Remove(invalidDecisionFile)
+	if _, err := cli.parseAccessMatrix(invalidDecisionFile, "coretech"); err == nil {
+		t.Error("invalid expected decision was accepted")
+	}
+}
+
+func TestSimulateAccess(t *testing.T) {
+	domains := testAccessDomains()
+	current := newAccessEvaluator(func(dn string) (*zms.DomainData, error) {
+		if domainData, ok := domains[dn]; ok {
+			return domainData, nil
+		}
+		return nil, fmt.Errorf("unknown domain %s", dn)
+	})
+
+	// the proposed domain removes the deny policy and the trust role
+	proposedDomains := testAccessDomains()
+	desired := proposedDomains["coretech"]
+	desired.Policies.Contents.Policies = desired.Policies.Contents.Policies[:1]
+	desired.Roles[2].Trust = ""
+	proposed := newAccessEvaluator(func(dn string) (*zms.DomainData, error) {
+		if dn == "coretech" {
+			return desired, nil
+		}
+		return current.domainData(dn)
+	})
+
+	checks := []*AccessCheck{
+		{Principal: "user.john", Action: "read", Resource: "coretech:articles.sports"},
+		{Principal: "user.joe", Action: "read", Resource: "coretech:articles.sports"},
+		{Principal: "sports.api", Action: "read", Resource: "coretech:articles.public"},
+	}
+	report, err := simulateAccess("coretech", checks, current, proposed)
+	if err != nil {
+		t.Fatalf("unable to simulate access: %v", err)
+	}
+	if report.Checks != 3 || report.Unchanged != 1 || len(report.Changes) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.Changes[0].Principal != "user.joe" || report.Changes[0].Current != accessDecisionDeny || report.Changes[0].Proposed != accessDecisionAllow {
+		t.Errorf("unexpected change: %+v", report.Changes[0])
+	}
+	if report.Changes[1].Principal != "sports.api" || report.Changes[1].Current != accessDecisionAllow || report.Changes[1].Proposed != accessDecisionNoMatch {
+		t.Errorf("unexpected change: %+v", report.Changes[1])
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v2"
+)
+
+// tableBuilder flattens a response object into rows of columns. The
+// first list of objects in the response is expanded into rows with
+// nested lists of objects expanded recursively so that, for example,
+// each member/role pair of a domain role members object is a row.
+// Nested objects are flattened into dotted column names while lists
+// of values are joined with commas.
+type tableBuilder struct {
+	columns []string
+	seen    map[string]bool
+}
+
+type tableRow map[string]string
+
+func newTableBuilder() *tableBuilder {
+	return &tableBuilder{
+		columns: make([]string, 0),
+		seen:    make(map[string]bool),
+	}
+}
+
+func (t *tableBuilder) addColumn(name string) {
+	if !t.seen[name] {
+		t.seen[name] = true
+		t.columns = append(t.columns, name)
+	}
+}
+
+// setValue stores the value in the row. Empty values are skipped so that
+// optional fields that are not set in any row don't generate a column.
+func (t *tableBuilder) setValue(row tableRow, name, value string) {
+	if value == "" {
+		return
+	}
+	t.addColumn(name)
+	row[name] = value
+}
+
+func containsObjects(values []interface{}) bool {
+	for _, value := range values {
+		if _, ok := value.(yaml.MapSlice); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func scalarString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// flattenValue stores the value in the row without creating any
+// additional rows. It's used for all fields that are not expanded.
+func (t *tableBuilder) flattenValue(row tableRow, name string, value interface{}) {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		for _, item := range v {
+			t.flattenValue(row, name+"."+scalarString(item.Key), item.Value)
+		}
+	case []interface{}:
+		if containsObjects(v) {
+			data, _ := json.Marshal(yamlToJSONValue(v))
+			t.setValue(row, name, string(data))
+			return
+		}
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, scalarString(item))
+		}
+		t.setValue(row, name, strings.Join(values, ","))
+	default:
+		t.setValue(row, name, scalarString(v))
+	}
+}
+
+// expandField returns the index of the list field that is expanded into
+// rows. At the top level a list of values is expanded as well so that
+// simple name lists are displayed one name per row.
+func expandField(object yaml.MapSlice, top bool) int {
+	for i, item := range object {
+		if values, ok := item.Value.([]interface{}); ok && containsObjects(values) {
+			return i
+		}
+	}
+	if top {
+		for i, item := range object {
+			if _, ok := item.Value.([]interface{}); ok {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (t *tableBuilder) flatten(object yaml.MapSlice, prefix string, top bool) []tableRow {
+	row := make(tableRow)
+	expandIndex := expandField(object, top)
+	for i, item := range object {
+		if i != expandIndex {
+			t.flattenValue(row, prefix+scalarString(item.Key), item.Value)
+		}
+	}
+	if expandIndex == -1 {
+		return []tableRow{row}
+	}
+
+	name := scalarString(object[expandIndex].Key)
+	childPrefix := prefix + name + "."
+	if top {
+		childPrefix = ""
+	}
+	values := object[expandIndex].Value.([]interface{})
+	rows := make([]tableRow, 0, len(values))
+	for _, value := range values {
+		var childRows []tableRow
+		if child, ok := value.(yaml.MapSlice); ok {
+			childRows = t.flatten(child, childPrefix, false)
+		} else {
+			childRow := make(tableRow)
+			t.setValue(childRow, prefix+name, scalarString(value))
+			childRows = []tableRow{childRow}
+		}
+		for _, childRow := range childRows {
+			for column, columnValue := range row {
+				childRow[column] = columnValue
+			}
+			rows = append(rows, childRow)
+		}
+	}
+	if len(rows) == 0 && !top {
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// yamlToJSONValue converts the ordered yaml maps back into
+// generic maps so they can be encoded as json
+func yamlToJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		object := make(map[string]interface{})
+		for _, item := range v {
+			object[scalarString(item.Key)] = yamlToJSONValue(item.Value)
+		}
+		return object
+	case []interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			values = append(values, yamlToJSONValue(item))
+		}
+		return values
+	default:
+		return v
+	}
+}
+
+// tableRows converts the response object into table rows. The object is
+// encoded as json first so that the column names match the json output
+// and then decoded as an ordered map so the columns keep their order.
+func tableRows(res interface{}) ([]string, []tableRow, error) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return nil, nil, err
+	}
+	var object yaml.MapSlice
+	err = yaml.Unmarshal(data, &object)
+	if err != nil {
+		return nil, nil, err
+	}
+	t := newTableBuilder()
+	rows := t.flatten(object, "", true)
+	return t.columns, rows, nil
+}
+
+// selectColumns returns the list of columns requested with the
+// columns option or all available columns if none are specified
+func (cli Zms) selectColumns(available []string) ([]string, error) {
+	if cli.Columns == "" {
+		return available, nil
+	}
+	columns := make([]string, 0)
+	for _, column := range strings.Split(cli.Columns, ",") {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			continue
+		}
+		if indexOfString(available, column) == -1 {
+			return nil, invalidValueError("column", column, available)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+func (cli Zms) buildTableOutput(res interface{}) (*string, error) {
+	// update commands only return a status message so there are
+	// no rows to display and we just return the message itself
+	if message, ok := res.(SuccessMessage); ok {
+		return &message.Message, nil
+	}
+	available, rows, err := tableRows(res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to produce %s output: %v", cli.OutputFormat, err)
+	}
+	columns, err := cli.selectColumns(available)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if cli.OutputFormat == CSVOutputFormat {
+		w := csv.NewWriter(&buf)
+		_ = w.Write(columns)
+		for _, row := range rows {
+			record := make([]string, 0, len(columns))
+			for _, column := range columns {
+				record = append(record, row[column])
+			}
+			_ = w.Write(record)
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, fmt.Errorf("failed to produce csv output: %v", err)
+		}
+	} else {
+		replacer := strings.NewReplacer("\t", " ", "\n", " ")
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, strings.Join(columns, "\t"))
+		for _, row := range rows {
+			values := make([]string, 0, len(columns))
+			for _, column := range columns {
+				values = append(values, replacer.Replace(row[column]))
+			}
+			_, _ = fmt.Fprintln(w, strings.Join(values, "\t"))
+		}
+		_ = w.Flush()
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if cli.OutputFormat == TableOutputFormat {
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(line, " ")
+		}
+	}
+	output := strings.Join(lines, "\n")
+	return &output, nil
+}
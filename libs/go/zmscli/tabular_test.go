@@ -0,0 +1,102 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+func testDomainRoleMembers() *zms.DomainRoleMembers {
+	expiration := rdl.Timestamp{Time: rdl.TimestampNow().Time.UTC()}
+	return &zms.DomainRoleMembers{
+		DomainName: "coretech",
+		Members: []*zms.DomainRoleMember{
+			{
+				MemberName: "user.john",
+				MemberRoles: []*zms.MemberRole{
+					{RoleName: "admin"},
+					{RoleName: "readers", Expiration: &expiration},
+				},
+			},
+			{
+				MemberName:  "user.jane",
+				MemberRoles: []*zms.MemberRole{},
+			},
+		},
+	}
+}
+
+func TestTableRows(t *testing.T) {
+	columns, rows, err := tableRows(testDomainRoleMembers())
+	if err != nil {
+		t.Fatalf("unable to generate rows: %v", err)
+	}
+	expectedColumns := []string{"domainName", "memberName", "memberRoles.roleName", "memberRoles.expiration"}
+	if len(columns) != len(expectedColumns) {
+		t.Fatalf("unexpected columns: %v", columns)
+	}
+	for i := range expectedColumns {
+		if columns[i] != expectedColumns[i] {
+			t.Errorf("column %d: expected %s, got %s", i, expectedColumns[i], columns[i])
+		}
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(rows))
+	}
+	if rows[1]["domainName"] != "coretech" || rows[1]["memberName"] != "user.john" || rows[1]["memberRoles.roleName"] != "readers" {
+		t.Errorf("unexpected row: %v", rows[1])
+	}
+	if rows[2]["memberName"] != "user.jane" || rows[2]["memberRoles.roleName"] != "" {
+		t.Errorf("unexpected row: %v", rows[2])
+	}
+
+	// simple lists are displayed one value per row
+	columns, rows, err = tableRows(&zms.RoleList{Names: []zms.EntityName{"admin", "readers"}})
+	if err != nil {
+		t.Fatalf("unable to generate rows: %v", err)
+	}
+	if len(columns) != 1 || columns[0] != "names" || len(rows) != 2 || rows[1]["names"] != "readers" {
+		t.Errorf("unexpected role list rows: %v %v", columns, rows)
+	}
+}
+
+func TestBuildTableOutput(t *testing.T) {
+	cli := Zms{OutputFormat: TableOutputFormat, Columns: "memberName,memberRoles.roleName"}
+	output, err := cli.buildTableOutput(testDomainRoleMembers())
+	if err != nil {
+		t.Fatalf("unable to generate table: %v", err)
+	}
+	expected := "memberName  memberRoles.roleName\n" +
+		"user.john   admin\n" +
+		"user.john   readers\n" +
+		"user.jane"
+	if *output != expected {
+		t.Errorf("unexpected table output:\n%s\nexpected:\n%s", *output, expected)
+	}
+
+	cli.OutputFormat = CSVOutputFormat
+	cli.Columns = "memberRoles.roleName, memberName"
+	output, err = cli.buildTableOutput(testDomainRoleMembers())
+	if err != nil {
+		t.Fatalf("unable to generate csv: %v", err)
+	}
+	expected = "memberRoles.roleName,memberName\nadmin,user.john\nreaders,user.john\n,user.jane"
+	if *output != expected {
+		t.Errorf("unexpected csv output:\n%s\nexpected:\n%s", *output, expected)
+	}
+
+	cli.Columns = "membername"
+	if _, err = cli.buildTableOutput(testDomainRoleMembers()); err == nil {
+		t.Error("unknown column was accepted")
+	}
+
+	message := SuccessMessage{Status: 200, Message: "[role updated]"}
+	output, err = cli.buildTableOutput(message)
+	if err != nil || *output != message.Message {
+		t.Errorf("unexpected message output: %v", output)
+	}
+}
@@ -6,6 +6,8 @@ package zmscli
 import (
 	"bytes"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/AthenZ/athenz/clients/go/zms"
@@ -80,7 +82,9 @@ func (cli Zms) ShowServerTemplate(templateName string) (*string, error) {
 	return cli.dumpByFormat(template, oldYamlConverter)
 }
 
-func (cli Zms) SetDomainTemplate(dn string, templateArgs []string) (*string, error) {
+// parseTemplateArgs splits the arguments into the template
+// names and the param-key=param-value template parameters
+func parseTemplateArgs(templateArgs []string) ([]zms.SimpleName, []*zms.TemplateParam) {
 	templateNames := make([]zms.SimpleName, 0)
 	templateParams := make([]*zms.TemplateParam, 0)
 	for _, value := range templateArgs {
@@ -95,6 +99,11 @@ func (cli Zms) SetDomainTemplate(dn string, templateArgs []string) (*string, err
 			templateParams = append(templateParams, &param)
 		}
 	}
+	return templateNames, templateParams
+}
+
+func (cli Zms) SetDomainTemplate(dn string, templateArgs []string) (*string, error) {
+	templateNames, templateParams := parseTemplateArgs(templateArgs)
 	//make sure we have some templates specified
 	if len(templateNames) == 0 {
 		return nil, fmt.Errorf("no template names specified")
@@ -130,3 +139,278 @@ func (cli Zms) DeleteDomainTemplate(dn string, template string) (*string, error)
 
 	return cli.dumpByFormat(message, cli.buildYAMLOutput)
 }
+
+func (cli Zms) dumpTemplateDetails(buf *bytes.Buffer, details *zms.DomainTemplateDetailsList) {
+	buf.WriteString("templates:\n")
+	for _, metadata := range details.MetaData {
+		dumpStringValue(buf, indentLevel1Dash, "template-name", metadata.TemplateName)
+		dumpStringValue(buf, indentLevel1DashLvl, "description", metadata.Description)
+		dumpStringValue(buf, indentLevel1DashLvl, "keywords-to-replace", metadata.KeywordsToReplace)
+		dumpInt32Value(buf, indentLevel1DashLvl, "current-version", metadata.CurrentVersion)
+		dumpInt32Value(buf, indentLevel1DashLvl, "latest-version", metadata.LatestVersion)
+		dumpBoolValue(buf, indentLevel1DashLvl, "auto-update", metadata.AutoUpdate)
+		if templateUpdateAvailable(metadata) {
+			buf.WriteString(indentLevel1DashLvl + "update-available: true\n")
+		}
+	}
+}
+
+// templateUpdateAvailable returns true if the version of the template
+// applied to the domain is older than the latest server version
+func templateUpdateAvailable(metadata *zms.TemplateMetaData) bool {
+	return metadata.CurrentVersion != nil && metadata.LatestVersion != nil &&
+		*metadata.CurrentVersion < *metadata.LatestVersion
+}
+
+// ListDomainTemplateDetails displays the current and latest versions of the
+// templates applied to the domain. If no domain is given, the details of all
+// the server templates are displayed instead.
+func (cli Zms) ListDomainTemplateDetails(dn string) (*string, error) {
+	var details *zms.DomainTemplateDetailsList
+	var err error
+	if dn == "" {
+		details, err = cli.Zms.GetServerTemplateDetailsList()
+	} else {
+		details, err = cli.Zms.GetDomainTemplateDetailsList(zms.DomainName(dn))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		cli.dumpTemplateDetails(&buf, details)
+		s := buf.String()
+		return &s, nil
+	}
+
+	return cli.dumpByFormat(details, oldYamlConverter)
+}
+
+// templateRenderer carries out the same substitutions as the server when
+// the template is applied: the _domain_ keyword is replaced with the domain
+// name and each _param-key_ keyword with the corresponding parameter value.
+// Keywords listed in the template metadata that remain after the substitutions
+// are recorded as missing.
+type templateRenderer struct {
+	dn       string
+	params   []*zms.TemplateParam
+	keywords []string
+	missing  map[string]bool
+}
+
+func (r *templateRenderer) render(value string) string {
+	value = strings.Replace(value, "_domain_", r.dn, -1)
+	for _, param := range r.params {
+		value = strings.Replace(value, "_"+string(param.Name)+"_", string(param.Value), -1)
+	}
+	for _, keyword := range r.keywords {
+		if strings.Contains(value, keyword) {
+			r.missing[keyword] = true
+		}
+	}
+	return value
+}
+
+// missingKeywords returns the template keywords that were not replaced
+// since no value was given for them
+func (r *templateRenderer) missingKeywords() []string {
+	keywords := make([]string, 0, len(r.missing))
+	for keyword := range r.missing {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+	return keywords
+}
+
+// renderTemplate returns the domain data with the template roles, policies
+// and services after all the keyword substitutions
+func renderTemplate(template *zms.Template, dn string, params []*zms.TemplateParam) (*zms.DomainData, []string) {
+	r := &templateRenderer{dn: dn, params: params, missing: make(map[string]bool)}
+	if template.Metadata != nil {
+		for _, keyword := range strings.Split(template.Metadata.KeywordsToReplace, ",") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				r.keywords = append(r.keywords, keyword)
+			}
+		}
+	}
+	rendered := &zms.DomainData{
+		Name:     zms.DomainName(dn),
+		Roles:    make([]*zms.Role, 0),
+		Services: make([]*zms.ServiceIdentity, 0),
+		Policies: &zms.SignedPolicies{
+			Contents: &zms.DomainPolicies{
+				Domain:   zms.DomainName(dn),
+				Policies: make([]*zms.Policy, 0),
+			},
+		},
+	}
+	for _, role := range template.Roles {
+		renderedRole := &zms.Role{
+			Name:                    zms.ResourceName(r.render(string(role.Name))),
+			Trust:                   zms.DomainName(r.render(string(role.Trust))),
+			SelfServe:               role.SelfServe,
+			AuditEnabled:            role.AuditEnabled,
+			ReviewEnabled:           role.ReviewEnabled,
+			MemberExpiryDays:        role.MemberExpiryDays,
+			ServiceExpiryDays:       role.ServiceExpiryDays,
+			GroupExpiryDays:         role.GroupExpiryDays,
+			MemberReviewDays:        role.MemberReviewDays,
+			ServiceReviewDays:       role.ServiceReviewDays,
+			GroupReviewDays:         role.GroupReviewDays,
+			TokenExpiryMins:         role.TokenExpiryMins,
+			CertExpiryMins:          role.CertExpiryMins,
+			SignAlgorithm:           role.SignAlgorithm,
+			NotifyRoles:             r.render(role.NotifyRoles),
+			UserAuthorityFilter:     role.UserAuthorityFilter,
+			UserAuthorityExpiration: role.UserAuthorityExpiration,
+			Tags:                    role.Tags,
+			RoleMembers:             make([]*zms.RoleMember, 0),
+		}
+		for _, member := range role.RoleMembers {
+			renderedRole.RoleMembers = append(renderedRole.RoleMembers, &zms.RoleMember{
+				MemberName:     zms.MemberName(r.render(string(member.MemberName))),
+				Expiration:     member.Expiration,
+				ReviewReminder: member.ReviewReminder,
+			})
+		}
+		rendered.Roles = append(rendered.Roles, renderedRole)
+	}
+	for _, policy := range template.Policies {
+		renderedPolicy := &zms.Policy{
+			Name:       zms.ResourceName(r.render(string(policy.Name))),
+			Assertions: make([]*zms.Assertion, 0),
+		}
+		for _, assertion := range policy.Assertions {
+			renderedPolicy.Assertions = append(renderedPolicy.Assertions, &zms.Assertion{
+				Role:          r.render(assertion.Role),
+				Resource:      r.render(assertion.Resource),
+				Action:        assertion.Action,
+				Effect:        assertion.Effect,
+				CaseSensitive: assertion.CaseSensitive,
+				Conditions:    assertion.Conditions,
+			})
+		}
+		rendered.Policies.Contents.Policies = append(rendered.Policies.Contents.Policies, renderedPolicy)
+	}
+	for _, service := range template.Services {
+		rendered.Services = append(rendered.Services, &zms.ServiceIdentity{
+			Name:             zms.ServiceName(r.render(string(service.Name))),
+			PublicKeys:       service.PublicKeys,
+			ProviderEndpoint: service.ProviderEndpoint,
+			Executable:       service.Executable,
+			User:             service.User,
+			Group:            service.Group,
+		})
+	}
+	return rendered, r.missingKeywords()
+}
+
+// templateRoleMeta returns the role meta diff attributes along with
+// whether or not they are specified in the template role. The server
+// only updates the meta attributes that are set in the template.
+func templateRoleMeta(role *zms.Role) map[string]bool {
+	return map[string]bool{
+		"audit-enabled":             role.AuditEnabled != nil,
+		"self-serve":                role.SelfServe != nil,
+		"review-enabled":            role.ReviewEnabled != nil,
+		"member-expiry-days":        role.MemberExpiryDays != nil,
+		"service-expiry-days":       role.ServiceExpiryDays != nil,
+		"group-expiry-days":         role.GroupExpiryDays != nil,
+		"member-review-days":        role.MemberReviewDays != nil,
+		"service-review-days":       role.ServiceReviewDays != nil,
+		"group-review-days":         role.GroupReviewDays != nil,
+		"token-expiry-mins":         role.TokenExpiryMins != nil,
+		"cert-expiry-mins":          role.CertExpiryMins != nil,
+		"sign-algorithm":            role.SignAlgorithm != "",
+		"notify-roles":              role.NotifyRoles != "",
+		"user-authority-filter":     role.UserAuthorityFilter != "",
+		"user-authority-expiration": role.UserAuthorityExpiration != "",
+	}
+}
+
+// previewTemplate compares the rendered template objects against the current
+// domain. The server never deletes any existing members, assertions or keys
+// when applying a template so only the additions and the attributes set by
+// the template are reported for existing objects.
+func previewTemplate(current, rendered *zms.DomainData) *DomainDiff {
+	dn := string(rendered.Name)
+	diff := diffDomainData(current, rendered)
+	objects := make([]*DomainObjectDiff, 0)
+	for _, object := range diff.Objects {
+		if object.ObjectType == diffObjectDomain || object.Action == diffActionDelete {
+			continue
+		}
+		if object.Action == diffActionUpdate {
+			var roleMeta map[string]bool
+			if object.ObjectType == diffObjectRole {
+				roleMeta = templateRoleMeta(findRole(rendered, object.Name))
+			}
+			changes := make([]*DomainAttributeDiff, 0)
+			for _, change := range object.Changes {
+				if specified, ok := roleMeta[change.Attribute]; ok {
+					if specified {
+						changes = append(changes, change)
+					}
+				} else if change.Action == diffActionAdd || (change.Action == diffActionUpdate && change.To != "") {
+					changes = append(changes, change)
+				}
+			}
+			if len(changes) == 0 {
+				continue
+			}
+			object.Changes = changes
+		}
+		objects = append(objects, object)
+	}
+	return &DomainDiff{Domain: dn, Objects: objects}
+}
+
+// PreviewDomainTemplate displays the roles, policies and services that would
+// be created or changed in the domain by applying the template with the given
+// parameters. Unless only a dry run is requested, the template is applied
+// once the changes are confirmed.
+func (cli Zms) PreviewDomainTemplate(dn string, templateArgs []string, dryRun bool) (*string, error) {
+	templateNames, templateParams := parseTemplateArgs(templateArgs)
+	if len(templateNames) != 1 {
+		return nil, fmt.Errorf("exactly one template name must be specified")
+	}
+	template, err := cli.Zms.GetTemplate(templateNames[0])
+	if err != nil {
+		return nil, err
+	}
+	rendered, missing := renderTemplate(template, dn, templateParams)
+	if len(missing) != 0 {
+		return nil, fmt.Errorf("template %s requires values for keywords: %s", templateNames[0], strings.Join(missing, ", "))
+	}
+	current, err := cli.liveDomainData(dn)
+	if err != nil {
+		return nil, err
+	}
+	diff := previewTemplate(current, rendered)
+
+	oldYamlConverter := func(res interface{}) (*string, error) {
+		var buf bytes.Buffer
+		if len(diff.Objects) == 0 {
+			buf.WriteString("[no changes from template " + string(templateNames[0]) + " for domain " + dn + "]\n")
+		} else {
+			cli.dumpDomainDiff(&buf, diff)
+			buf.WriteString("[template " + string(templateNames[0]) + ": " + strconv.Itoa(diff.count(diffActionAdd)) + " to add, " +
+				strconv.Itoa(diff.count(diffActionUpdate)) + " to change]\n")
+		}
+		s := buf.String()
+		return &s, nil
+	}
+
+	if dryRun || len(diff.Objects) == 0 {
+		return cli.dumpByFormat(diff, oldYamlConverter)
+	}
+	output, err := oldYamlConverter(diff)
+	if err != nil {
+		return nil, err
+	}
+	if !cli.confirmChanges(*output) {
+		return nil, fmt.Errorf("preview-domain-template cancelled - template %s was not applied", templateNames[0])
+	}
+	return cli.SetDomainTemplate(dn, templateArgs)
+}
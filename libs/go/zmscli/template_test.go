@@ -0,0 +1,109 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"testing"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+func testTemplate() *zms.Template {
+	reviewDays := int32(30)
+	return &zms.Template{
+		Metadata: &zms.TemplateMetaData{KeywordsToReplace: "_service_"},
+		Roles: []*zms.Role{
+			{Name: "_domain_:role.launch_provider", RoleMembers: []*zms.RoleMember{{MemberName: "sys.auth.zts"}}},
+			{
+				Name:             "_domain_:role.readers",
+				MemberReviewDays: &reviewDays,
+				NotifyRoles:      "_domain_:role.admin",
+				RoleMembers:      []*zms.RoleMember{{MemberName: "_domain_._service_"}},
+			},
+		},
+		Policies: []*zms.Policy{
+			{
+				Name: "_domain_:policy.launch_provider",
+				Assertions: []*zms.Assertion{
+					{Role: "_domain_:role.launch_provider", Resource: "_domain_:service._service_", Action: "launch"},
+				},
+			},
+		},
+		Services: []*zms.ServiceIdentity{{Name: "_domain_._service_"}},
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	params := []*zms.TemplateParam{{Name: "service", Value: "api"}}
+	rendered, missing := renderTemplate(testTemplate(), "coretech", params)
+	if len(missing) != 0 {
+		t.Fatalf("unexpected missing keywords: %v", missing)
+	}
+	if rendered.Roles[0].Name != "coretech:role.launch_provider" || rendered.Roles[1].RoleMembers[0].MemberName != "coretech.api" {
+		t.Errorf("unexpected rendered roles: %s, %s", rendered.Roles[0].Name, rendered.Roles[1].RoleMembers[0].MemberName)
+	}
+	if role := rendered.Roles[1]; role.MemberReviewDays == nil || *role.MemberReviewDays != 30 || role.NotifyRoles != "coretech:role.admin" {
+		t.Errorf("template role meta is not rendered: %+v", role)
+	}
+	assertion := rendered.Policies.Contents.Policies[0].Assertions[0]
+	if assertion.Resource != "coretech:service.api" || assertion.Role != "coretech:role.launch_provider" {
+		t.Errorf("unexpected rendered assertion: %s", assertionString("coretech", assertion))
+	}
+	if rendered.Services[0].Name != "coretech.api" {
+		t.Errorf("unexpected rendered service: %s", rendered.Services[0].Name)
+	}
+
+	_, missing = renderTemplate(testTemplate(), "coretech", nil)
+	if len(missing) != 1 || missing[0] != "_service_" {
+		t.Errorf("expected missing _service_ keyword, got %v", missing)
+	}
+}
+
+func TestPreviewTemplate(t *testing.T) {
+	params := []*zms.TemplateParam{{Name: "service", Value: "api"}}
+	rendered, _ := renderTemplate(testTemplate(), "coretech", params)
+	selfServe := true
+	expiryDays := int32(90)
+	current := &zms.DomainData{
+		Name: "coretech",
+		Roles: []*zms.Role{
+			{Name: "coretech:role.admin", RoleMembers: []*zms.RoleMember{{MemberName: "user.john"}}},
+			{
+				Name:             "coretech:role.readers",
+				SelfServe:        &selfServe,
+				MemberExpiryDays: &expiryDays,
+				NotifyRoles:      "coretech:role.admin",
+				RoleMembers:      []*zms.RoleMember{{MemberName: "user.jane"}},
+			},
+		},
+		Services: []*zms.ServiceIdentity{{Name: "coretech.api", Hosts: []string{"host1"}}},
+	}
+	diff := previewTemplate(current, rendered)
+	expected := []struct {
+		action     string
+		objectType string
+		name       string
+		changes    int
+	}{
+		{diffActionAdd, diffObjectRole, "launch_provider", 0},
+		{diffActionUpdate, diffObjectRole, "readers", 2},
+		{diffActionAdd, diffObjectPolicy, "launch_provider", 0},
+	}
+	if len(diff.Objects) != len(expected) {
+		t.Fatalf("expected %d objects, got %d", len(expected), len(diff.Objects))
+	}
+	for i, object := range diff.Objects {
+		if object.Action != expected[i].action || object.ObjectType != expected[i].objectType ||
+			object.Name != expected[i].name || len(object.Changes) != expected[i].changes {
+			t.Errorf("unexpected object %d: %+v", i, object)
+		}
+	}
+	if change := diff.Objects[1].Changes[0]; change.Action != diffActionAdd || change.Value != "coretech.api" {
+		t.Errorf("unexpected readers change: %+v", change)
+	}
+	// only the meta attributes set in the template are reported
+	if change := diff.Objects[1].Changes[1]; change.Attribute != "member-review-days" || change.From != "0" || change.To != "30" {
+		t.Errorf("unexpected readers change: %+v", change)
+	}
+}
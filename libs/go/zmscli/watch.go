@@ -0,0 +1,244 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+const (
+	defaultWatchInterval = 60
+	watchHookTimeout     = 10 * time.Second
+)
+
+// DomainChangeEvent is a single change detected in a domain
+// between two consecutive polls of the modified domains
+type DomainChangeEvent struct {
+	Time       string `json:"time"`
+	Event      string `json:"event"`
+	Domain     string `json:"domain"`
+	ObjectType string `json:"objectType"`
+	Name       string `json:"name"`
+	Attribute  string `json:"attribute,omitempty"`
+	Value      string `json:"value,omitempty"`
+	From       string `json:"from,omitempty"`
+	To         string `json:"to,omitempty"`
+}
+
+// changeEventName returns the event name for the attribute change.
+// Members, assertions and public keys have their own events while
+// all other attributes are reported as meta changes.
+func changeEventName(objectType string, change *DomainAttributeDiff) string {
+	switch change.Attribute {
+	case "member":
+		switch change.Action {
+		case diffActionAdd:
+			return objectType + "-member-added"
+		case diffActionDelete:
+			return objectType + "-member-removed"
+		}
+		return objectType + "-member-updated"
+	case "assertion":
+		if change.Action == diffActionAdd {
+			return "assertion-added"
+		}
+		return "assertion-removed"
+	case "public-key":
+		switch change.Action {
+		case diffActionAdd:
+			return "public-key-added"
+		case diffActionDelete:
+			return "public-key-removed"
+		}
+		return "public-key-rotated"
+	}
+	return objectType + "-meta-changed"
+}
+
+// domainChangeEvents converts the domain diff into the list of events
+func domainChangeEvents(diff *DomainDiff, timestamp string) []*DomainChangeEvent {
+	events := make([]*DomainChangeEvent, 0)
+	for _, object := range diff.Objects {
+		if object.Action != diffActionUpdate {
+			event := object.ObjectType + "-added"
+			if object.Action == diffActionDelete {
+				event = object.ObjectType + "-deleted"
+			}
+			events = append(events, &DomainChangeEvent{
+				Time:       timestamp,
+				Event:      event,
+				Domain:     diff.Domain,
+				ObjectType: object.ObjectType,
+				Name:       object.Name,
+			})
+			continue
+		}
+		for _, change := range object.Changes {
+			events = append(events, &DomainChangeEvent{
+				Time:       timestamp,
+				Event:      changeEventName(object.ObjectType, change),
+				Domain:     diff.Domain,
+				ObjectType: object.ObjectType,
+				Name:       object.Name,
+				Attribute:  change.Attribute,
+				Value:      change.Value,
+				From:       change.From,
+				To:         change.To,
+			})
+		}
+	}
+	return events
+}
+
+// domainWatcher keeps the last snapshot of every watched domain
+// along with the etag of the last modified domains request
+type domainWatcher struct {
+	prefix    string
+	etag      string
+	snapshots map[string]*zms.DomainData
+}
+
+func newDomainWatcher(prefix string) *domainWatcher {
+	return &domainWatcher{
+		prefix:    prefix,
+		snapshots: make(map[string]*zms.DomainData),
+	}
+}
+
+// update replaces the snapshots of the modified domains and returns
+// the change events compared to the previous snapshots. The initial
+// update only records the snapshots without generating any events.
+func (w *domainWatcher) update(domains []*zms.SignedDomain, initial bool, now time.Time) []*DomainChangeEvent {
+	timestamp := rdl.Timestamp{Time: now}.String()
+	events := make([]*DomainChangeEvent, 0)
+	for _, signedDomain := range domains {
+		if signedDomain == nil || signedDomain.Domain == nil {
+			continue
+		}
+		domainData := signedDomain.Domain
+		dn := string(domainData.Name)
+		if !strings.HasPrefix(dn, w.prefix) {
+			continue
+		}
+		previous, ok := w.snapshots[dn]
+		w.snapshots[dn] = domainData
+		switch {
+		case initial:
+		case !ok:
+			events = append(events, &DomainChangeEvent{
+				Time:       timestamp,
+				Event:      "domain-added",
+				Domain:     dn,
+				ObjectType: diffObjectDomain,
+				Name:       dn,
+			})
+		default:
+			events = append(events, domainChangeEvents(diffDomainData(previous, domainData), timestamp)...)
+		}
+	}
+	return events
+}
+
+// poll retrieves the domains modified since the last request
+// and returns the change events for those domains
+func (w *domainWatcher) poll(cli Zms, initial bool) ([]*DomainChangeEvent, error) {
+	master := true
+	conditions := true
+	signedDomains, etag, err := cli.Zms.GetSignedDomains("", "false", "", &master, &conditions, w.etag)
+	if err != nil {
+		return nil, err
+	}
+	if etag != "" {
+		w.etag = etag
+	}
+	// not modified responses have no body
+	if signedDomains == nil {
+		return nil, nil
+	}
+	return w.update(signedDomains.Domains, initial, time.Now()), nil
+}
+
+// runEventHook executes the command with the event json on its stdin
+// and the event name and domain in the environment
+func runEventHook(command string, event *DomainChangeEvent, data []byte) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"ZMS_EVENT="+event.Event,
+		"ZMS_EVENT_DOMAIN="+event.Domain,
+		"ZMS_EVENT_OBJECT="+event.ObjectType+":"+event.Name,
+	)
+	return cmd.Run()
+}
+
+// postEventWebhook posts the event json to the given url
+func postEventWebhook(url string, data []byte) error {
+	client := http.Client{Timeout: watchHookTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// WatchDomains polls the modified domains at the given interval in seconds
+// and writes every change as a json line to stdout. For each event, the
+// optional command is executed and the optional webhook is notified. Hook
+// failures are reported on stderr without stopping the watch.
+func (cli Zms) WatchDomains(prefix string, interval int, command string, webhook string) (*string, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid interval %d - must be a positive number of seconds", interval)
+	}
+	watcher := newDomainWatcher(prefix)
+	_, err := watcher.poll(cli, true)
+	if err != nil {
+		return nil, err
+	}
+	if cli.Verbose {
+		_, _ = fmt.Fprintf(os.Stderr, "Watching %d domains...\n", len(watcher.snapshots))
+	}
+	for {
+		time.Sleep(time.Duration(interval) * time.Second)
+		events, err := watcher.poll(cli, false)
+		if err != nil {
+			// the server might be temporarily unavailable so
+			// we'll retry with the same etag on the next poll
+			_, _ = fmt.Fprintln(os.Stderr, "***", err)
+			continue
+		}
+		for _, event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Println(string(data))
+			if command != "" {
+				if err := runEventHook(command, event, data); err != nil {
+					_, _ = fmt.Fprintln(os.Stderr, "*** hook failed:", err)
+				}
+			}
+			if webhook != "" {
+				if err := postEventWebhook(webhook, data); err != nil {
+					_, _ = fmt.Fprintln(os.Stderr, "*** webhook failed:", err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright The Athenz Authors
+// Licensed under the terms of the Apache version 2.0 license. See LICENSE file for terms.
+
+package zmscli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AthenZ/athenz/clients/go/zms"
+)
+
+func testWatchDomain(dn string, admins []string, keyValue string) *zms.SignedDomain {
+	members := make([]*zms.RoleMember, 0)
+	for _, admin := range admins {
+		members = append(members, &zms.RoleMember{MemberName: zms.MemberName(admin)})
+	}
+	return &zms.SignedDomain{
+		Domain: &zms.DomainData{
+			Name:  zms.DomainName(dn),
+			Roles: []*zms.Role{{Name: zms.ResourceName(dn + ":role.admin"), RoleMembers: members}},
+			Services: []*zms.ServiceIdentity{
+				{Name: zms.ServiceName(dn + ".api"), PublicKeys: []*zms.PublicKeyEntry{{Id: "0", Key: keyValue}}},
+			},
+		},
+	}
+}
+
+func TestDomainWatcherUpdate(t *testing.T) {
+	watcher := newDomainWatcher("coretech")
+	now := time.Now()
+
+	events := watcher.update([]*zms.SignedDomain{
+		testWatchDomain("coretech", []string{"user.jane"}, "key1"),
+		testWatchDomain("sports", []string{"user.joe"}, "key1"),
+	}, true, now)
+	if len(events) != 0 || len(watcher.snapshots) != 1 {
+		t.Fatalf("initial update must only record snapshots: %d events %d snapshots", len(events), len(watcher.snapshots))
+	}
+
+	events = watcher.update([]*zms.SignedDomain{
+		testWatchDomain("coretech", []string{"user.john"}, "key2"),
+		testWatchDomain("coretech.api", nil, "key1"),
+		testWatchDomain("sports", []string{"user.jane"}, "key1"),
+	}, false, now)
+	expected := []struct {
+		event  string
+		domain string
+		name   string
+		value  string
+	}{
+		{"public-key-rotated", "coretech", "api", "0"},
+		{"role-member-removed", "coretech", "admin", "user.jane"},
+		{"role-member-added", "coretech", "admin", "user.john"},
+		{"domain-added", "coretech.api", "coretech.api", ""},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected), len(events))
+	}
+	for i, event := range events {
+		if event.Event != expected[i].event || event.Domain != expected[i].domain || event.Name != expected[i].name || event.Value != expected[i].value {
+			t.Errorf("unexpected event %d: %+v", i, event)
+		}
+		if event.Time == "" {
+			t.Errorf("event %d has no timestamp", i)
+		}
+	}
+
+	// no changes since the previous snapshot
+	events = watcher.update([]*zms.SignedDomain{testWatchDomain("coretech", []string{"user.john"}, "key2")}, false, now)
+	if len(events) != 0 {
+		t.Errorf("unexpected events for unchanged domain: %d", len(events))
+	}
+}
+
+func TestChangeEventName(t *testing.T) {
+	tests := []struct {
+		objectType string
+		change     DomainAttributeDiff
+		expected   string
+	}{
+		{diffObjectGroup, DomainAttributeDiff{Action: diffActionUpdate, Attribute: "member"}, "group-member-updated"},
+		{diffObjectPolicy, DomainAttributeDiff{Action: diffActionAdd, Attribute: "assertion"}, "assertion-added"},
+		{diffObjectPolicy, DomainAttributeDiff{Action: diffActionDelete, Attribute: "assertion"}, "assertion-removed"},
+		{diffObjectService, DomainAttributeDiff{Action: diffActionAdd, Attribute: "public-key"}, "public-key-added"},
+		{diffObjectDomain, DomainAttributeDiff{Action: diffActionUpdate, Attribute: "description"}, "domain-meta-changed"},
+		{diffObjectRole, DomainAttributeDiff{Action: diffActionAdd, Attribute: "tag"}, "role-meta-changed"},
+	}
+	for _, test := range tests {
+		if name := changeEventName(test.objectType, &test.change); name != test.expected {
+			t.Errorf("unexpected event name for %s %s: %s", test.objectType, test.change.Attribute, name)
+		}
+	}
+}
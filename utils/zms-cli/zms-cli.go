@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/tls"
 	"crypto/x509"
@@ -52,6 +53,10 @@ func defaultSocksProxy() string {
 	return os.Getenv("SOCKS5_PROXY")
 }
 
+func defaultProfile() string {
+	return os.Getenv("ATHENZ_PROFILE")
+}
+
 func defaultDebug() bool {
 	sDebug := os.Getenv("ZMS_DEBUG")
 	return sDebug == "true"
@@ -147,6 +152,9 @@ func usage() string {
 	buf.WriteString("   -b                  Bulk import/update mode. Do not read/display updated role/policy/service objects (default=false)\n")
 	buf.WriteString("   -c cacert_file      CA Certificate file path\n")
 	buf.WriteString("   -cert x509_cert     Athenz X.509 Certificate file for authentication\n")
+	buf.WriteString("   -columns columns    Comma separated list of columns for table and csv output formats\n")
+	buf.WriteString("   -conf athenz_conf   Athenz configuration file with ZMS public keys used to verify signed domains\n")
+	buf.WriteString("                       (default=/home/athenz/conf/athenz.conf)\n")
 	buf.WriteString("   -d domain           The domain used for every command that takes a domain argument\n")
 	buf.WriteString("   -e skip_errors      Skip errors during import domain operation\n")
 	buf.WriteString("   -f ntoken_file      Principal Authority NToken file used for authentication\n")
@@ -154,16 +162,21 @@ func usage() string {
 	buf.WriteString("                       (default=" + defaultIdentity() + ")\n")
 	buf.WriteString("   -k                  Disable peer verification of SSL certificates.\n")
 	buf.WriteString("   -key x509_key       Athenz X.509 Key file for authentication\n")
-	buf.WriteString("   -o output_format    Output format - json or yaml (default=yaml)\n")
+	buf.WriteString("   -o output_format    Output format - json, yaml, table or csv (default=yaml)\n")
+	buf.WriteString("   -profile name       Named profile from " + zmscli.DefaultProfileConfigFile() + " to use\n")
+	buf.WriteString("                       (default ATHENZ_PROFILE=" + defaultProfile() + " or the current profile)\n")
 	buf.WriteString("   -s host:port        The SOCKS5 proxy to route requests through\n")
 	buf.WriteString("   -v                  Verbose mode. Full resource names are included in output (default=false)\n")
 	buf.WriteString("   -x                  For user token output, exclude the header name (default=false)\n")
+	buf.WriteString("   -y                  Apply domain changes without asking for confirmation (default=false)\n")
 	buf.WriteString("   -z zms_url          Base URL of the ZMS server to use\n")
 	buf.WriteString("                       (default ZMS=" + defaultZmsURL() + ")\n")
 	buf.WriteString("   -debug              Debug mode. Generates debug NTokens (default=false)\n")
 	buf.WriteString("\n")
+	buf.WriteString(" type 'zms-cli shell' to start an interactive session\n")
 	buf.WriteString(" type 'zms-cli help' to see all available commands\n")
 	buf.WriteString(" type 'zms-cli help [command]' for usage of the specified command\n")
+	buf.WriteString(" type 'zms-cli config list|use profile|show [profile]' to manage the named profiles\n")
 	return buf.String()
 }
 
@@ -175,6 +188,34 @@ func loadNtokenFromFile(fileName string) (string, error) {
 	return strings.TrimSpace(string(buf)), nil
 }
 
+// applyProfile sets the values of all flags that were not explicitly
+// specified on the command line to the values in the given profile
+func applyProfile(profile *zmscli.Profile) {
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	values := map[string]string{
+		"z":    profile.ZmsUrl,
+		"key":  profile.X509KeyFile,
+		"cert": profile.X509CertFile,
+		"f":    profile.NtokenFile,
+		"c":    profile.CACertFile,
+		"s":    profile.SocksProxy,
+		"u":    profile.UserDomain,
+		"h":    profile.HomeDomain,
+		"o":    profile.OutputFormat,
+		"d":    profile.Domain,
+	}
+	for name, value := range values {
+		if value != "" && !explicit[name] {
+			if err := flag.Set(name, value); err != nil {
+				log.Fatalf("Unable to apply profile value for flag -%s: %v\n", name, err)
+			}
+		}
+	}
+}
+
 func printVersion() {
 	if VERSION == "" {
 		fmt.Println("zms-cli (development version)")
@@ -197,7 +238,8 @@ func main() {
 	pHomeDomain := flag.String("h", "home", "Home domain name as configured in Athenz systems")
 	pSocks := flag.String("s", defaultSocksProxy(), "The SOCKS5 proxy to route requests through, i.e. 127.0.0.1:1080")
 	pSkipVerify := flag.Bool("k", false, "Disable peer verification of SSL certificates")
-	pOutputFormat := flag.String("o", "manualYaml", "Output format - json or yaml")
+	pOutputFormat := flag.String("o", "manualYaml", "Output format - json, yaml, table or csv")
+	pColumns := flag.String("columns", "", "Comma separated list of columns for table and csv output formats")
 	pDebug := flag.Bool("debug", defaultDebug(), "debug mode (for authentication, mainly)")
 	pAuditRef := flag.String("a", "", "Audit Reference Token if auditing is enabled for the domain")
 	pExcludeHeader := flag.Bool("x", false, "Exclude header in user-token output")
@@ -205,6 +247,9 @@ func main() {
 	pX509CertFile := flag.String("cert", "", "x.509 certificate key file for authentication")
 	pShowVersion := flag.Bool("version", false, "Show version")
 	pSkipErrors := flag.Bool("e", true, "Skip all errors during import domain operation")
+	pAutoConfirm := flag.Bool("y", false, "Apply changes without asking for confirmation")
+	pAthenzConf := flag.String("conf", "/home/athenz/conf/athenz.conf", "Athenz configuration file with ZMS public keys")
+	pProfile := flag.String("profile", defaultProfile(), "Named profile from the zms-cli configuration file")
 
 	flag.Usage = func() {
 		fmt.Println(usage())
@@ -220,6 +265,32 @@ func main() {
 		return
 	}
 
+	// the config command manages the profiles so it must be
+	// processed before the selected profile is applied
+
+	configFile := zmscli.DefaultProfileConfigFile()
+	if flag.NArg() > 0 && flag.Arg(0) == "config" {
+		msg, err := zmscli.ProfileCommand(configFile, *pProfile, flag.Args()[1:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "***", err)
+			os.Exit(1)
+		}
+		fmt.Print(*msg)
+		return
+	}
+
+	profileConfig, err := zmscli.LoadProfileConfig(configFile)
+	if err != nil {
+		log.Fatalf("Unable to load profiles: %v\n", err)
+	}
+	profile, err := profileConfig.Profile(*pProfile)
+	if err != nil {
+		log.Fatalf("Unable to select profile: %v\n", err)
+	}
+	if profile != nil {
+		applyProfile(profile)
+	}
+
 	if *pZMS == "" {
 		fmt.Println("No ZMS Url specified")
 		return
@@ -273,6 +344,10 @@ func main() {
 		AddSelf:          *pAddSelf,
 		OutputFormat:     *pOutputFormat,
 		SkipErrors:       *pSkipErrors,
+		AutoConfirm:      *pAutoConfirm,
+		AthenzConf:       *pAthenzConf,
+		Columns:          *pColumns,
+		Stdin:            bufio.NewReader(os.Stdin),
 	}
 
 	if *pX509KeyFile != "" && *pX509CertFile != "" {
@@ -327,7 +402,20 @@ func main() {
 		}
 	}
 
+	if args[0] == "shell" {
+		err := cli.Shell()
+		if err != nil {
+			log.Fatalf("Unable to run interactive shell: %v\n", err)
+		}
+		return
+	}
+
 	msg, err := cli.EvalCommand(args)
+	if failure, ok := err.(*zmscli.CommandFailedError); ok {
+		fmt.Println(failure.Output)
+		fmt.Fprintln(os.Stderr, "***", failure.Reason)
+		os.Exit(1)
+	}
 	if err != nil {
 		if reflect.ValueOf(err).Kind() != reflect.Struct {
 			err = rdl.ResourceError{